@@ -0,0 +1,90 @@
+// Command airports loads an OurAirports-style airport database, used by the
+// processor to attribute takeoffs and landings.
+//
+//	airports import -airports airports.csv [-runways runways.csv] [-types T] [-batch N]
+//
+// Airports are upserted by ident and their runways replaced. -types is a
+// comma-separated list of airport types to keep, by default small, medium
+// and large airports. Restart the processor to pick up a new import.
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/dandyZicky/opensky-collector/internal/config"
+	"github.com/dandyZicky/opensky-collector/internal/domain/airport"
+	"github.com/dandyZicky/opensky-collector/internal/infra/pg"
+)
+
+const usage = "usage: airports import -airports F [-runways F] [-types T] [-batch N]"
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "import" {
+		log.Fatal(usage)
+	}
+
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	airportsPath := fs.String("airports", "", "OurAirports airports.csv")
+	runwaysPath := fs.String("runways", "", "OurAirports runways.csv")
+	types := fs.String("types", "small_airport,medium_airport,large_airport", "airport types to import, empty for all")
+	batchSize := fs.Int("batch", 500, "airports per transaction")
+	fs.Parse(os.Args[2:])
+
+	if *airportsPath == "" {
+		log.Fatal(usage)
+	}
+
+	config.InitConfig()
+
+	db, err := pg.NewDB(pg.Config{
+		Host:     config.AppConfig.Database.Host,
+		Port:     config.AppConfig.Database.Port,
+		User:     config.AppConfig.Database.User,
+		Password: config.AppConfig.Database.Pass,
+		Dbname:   config.AppConfig.Database.Name,
+	})
+	if err != nil {
+		log.Fatalf("Failed to init db: %v", err)
+	}
+
+	airports, err := os.Open(*airportsPath)
+	if err != nil {
+		log.Fatalf("Failed to open %s: %v", *airportsPath, err)
+	}
+	defer airports.Close()
+
+	var runways io.Reader
+	if *runwaysPath != "" {
+		f, err := os.Open(*runwaysPath)
+		if err != nil {
+			log.Fatalf("Failed to open %s: %v", *runwaysPath, err)
+		}
+		defer f.Close()
+		runways = f
+	}
+
+	importer := &airport.Importer{
+		Store:     &pg.PgAirportRepository{DB: db},
+		BatchSize: *batchSize,
+		Types:     parseTypes(*types),
+	}
+	imported, ends, err := importer.Import(airports, runways)
+	if err != nil {
+		log.Fatalf("Failed to import airports: %v", err)
+	}
+	log.Printf("Imported %d airports with %d runway ends", imported, ends)
+}
+
+func parseTypes(s string) map[string]bool {
+	types := make(map[string]bool)
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types[t] = true
+		}
+	}
+	return types
+}
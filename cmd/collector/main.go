@@ -12,8 +12,10 @@ import (
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/dandyZicky/opensky-collector/internal/config"
 	"github.com/dandyZicky/opensky-collector/internal/domain/collector"
+	"github.com/dandyZicky/opensky-collector/internal/domain/flight"
 	producer "github.com/dandyZicky/opensky-collector/internal/infra/kafka"
 	"github.com/dandyZicky/opensky-collector/internal/infra/opensky"
+	"github.com/dandyZicky/opensky-collector/pkg/events"
 )
 
 func main() {
@@ -41,17 +43,48 @@ func main() {
 		"acks":              config.AppConfig.Kafka.Acks,
 	}
 
-	producerKafka := producer.KafkaProducer{
-		Producer: producer.NewKafkaProducer(kafkaConf),
+	codec, err := events.CodecFor(config.AppConfig.Kafka.Producer.Format)
+	if err != nil {
+		log.Fatalf("Invalid producer config: %v", err)
 	}
+	producerKafka := producer.NewKafkaProducer(kafkaConf, config.AppConfig.Kafka.Producer.FlushTimeoutMs)
+	producerKafka.Codec = codec
 
-	defer producerKafka.Producer.Close()
+	// Deferred calls run last to first: wait for the poller to stop
+	// publishing, then flush what is still queued.
+	defer producerKafka.Close()
 
 	flightDataCollector := &collector.CollectorService{
 		Client:   flightClient,
-		Producer: &producerKafka,
+		Producer: producerKafka,
+		Regions:  collectorRegions(),
 	}
 
-	go flightDataCollector.Poll(ctx, time.Duration(config.AppConfig.OpenSky.TickerInterval)*time.Millisecond)
+	pollerDone := make(chan struct{})
+	go func() {
+		defer close(pollerDone)
+		flightDataCollector.Poll(ctx, time.Duration(config.AppConfig.OpenSky.TickerInterval)*time.Millisecond)
+	}()
 	<-ctx.Done()
+	<-pollerDone
+}
+
+func collectorRegions() []collector.Region {
+	if config.AppConfig.OpenSky.Global {
+		return []collector.Region{{Name: "global"}}
+	}
+
+	regions := make([]collector.Region, 0, len(config.AppConfig.OpenSky.Regions))
+	for _, r := range config.AppConfig.OpenSky.Regions {
+		regions = append(regions, collector.Region{
+			Name: r.Name,
+			Box: &flight.BoundingBox{
+				LaMin: r.LaMin,
+				LoMin: r.LoMin,
+				LaMax: r.LaMax,
+				LoMax: r.LoMax,
+			},
+		})
+	}
+	return regions
 }
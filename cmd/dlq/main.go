@@ -0,0 +1,179 @@
+// Command dlq inspects and replays the dead-letter topic.
+//
+//	dlq inspect [-n 20] [-idle 10s]
+//	dlq replay  [-n 0] [-reason persist] [-topic telemetry.raw] [-idle 10s]
+//
+// inspect prints dead letters without committing anything. replay produces
+// the original payloads back to their source topic (or -topic) and commits
+// its progress under its own consumer group, so a replay can be resumed.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/dandyZicky/opensky-collector/internal/config"
+	infrakafka "github.com/dandyZicky/opensky-collector/internal/infra/kafka"
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+const (
+	usage       = "usage: dlq inspect [-n N] [-idle D] | dlq replay [-n N] [-reason R] [-topic T] [-idle D]"
+	replayGroup = "opensky-dlq-replay"
+	pollMs      = 500
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+
+	config.InitConfig()
+
+	switch os.Args[1] {
+	case "inspect":
+		inspect(os.Args[2:])
+	case "replay":
+		replay(os.Args[2:])
+	default:
+		log.Fatal(usage)
+	}
+}
+
+func inspect(args []string) {
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+	limit := fs.Int("n", 20, "number of dead letters to print, 0 for all")
+	idle := fs.Duration("idle", 10*time.Second, "stop after this long without new messages")
+	fs.Parse(args)
+
+	// A throwaway group that never commits, so inspecting has no side effects.
+	c := newConsumer(fmt.Sprintf("opensky-dlq-inspect-%d", time.Now().UnixNano()))
+	defer c.Client.Close()
+
+	count := 0
+	consume(c, *limit, *idle, func(msg *kafka.Message, dl events.DeadLetter) {
+		count++
+		fmt.Printf("#%d dlq offset %d, dead-lettered %s\n", count, msg.TopicPartition.Offset, time.Unix(dl.Timestamp, 0).Format(time.RFC3339))
+		fmt.Printf("  reason:  %s\n", dl.Reason)
+		fmt.Printf("  source:  %s[%d]@%d\n", dl.Topic, dl.Partition, dl.Offset)
+		fmt.Printf("  error:   %s\n", dl.Error)
+		if len(dl.Headers) > 0 {
+			fmt.Printf("  headers: %v\n", dl.Headers)
+		}
+		if dl.Headers[events.HeaderFormat] == events.FormatProtobuf {
+			fmt.Printf("  payload: %x\n", dl.Payload)
+		} else {
+			fmt.Printf("  payload: %s\n", dl.Payload)
+		}
+	})
+	log.Printf("Inspected %d dead letters", count)
+}
+
+func replay(args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	limit := fs.Int("n", 0, "number of dead letters to replay, 0 for all")
+	reason := fs.String("reason", "", "only replay dead letters with this reason (decode or persist)")
+	topic := fs.String("topic", "", "produce to this topic instead of each letter's source topic")
+	idle := fs.Duration("idle", 10*time.Second, "stop after this long without new messages")
+	fs.Parse(args)
+
+	c := newConsumer(replayGroup)
+	defer c.Client.Close()
+
+	p := infrakafka.NewKafkaProducer(&kafka.ConfigMap{
+		"bootstrap.servers": config.AppConfig.Kafka.BootstrapServers,
+		"client.id":         config.AppConfig.Kafka.ClientID,
+		"acks":              config.AppConfig.Kafka.Acks,
+	}, config.AppConfig.Kafka.Producer.FlushTimeoutMs)
+	defer p.Close()
+
+	replayed, skipped := 0, 0
+	deliveries := make(chan kafka.Event, 1)
+	consume(c, *limit, *idle, func(msg *kafka.Message, dl events.DeadLetter) {
+		if *reason == "" || dl.Reason == *reason {
+			target := dl.Topic
+			if *topic != "" {
+				target = *topic
+			}
+			out := &kafka.Message{
+				TopicPartition: kafka.TopicPartition{Topic: &target, Partition: kafka.PartitionAny},
+				Key:            dl.Key,
+				Value:          dl.Payload,
+			}
+			for k, v := range dl.Headers {
+				out.Headers = append(out.Headers, kafka.Header{Key: k, Value: []byte(v)})
+			}
+			if err := p.Producer.Produce(out, deliveries); err != nil {
+				log.Fatalf("Failed to replay dlq offset %d: %v", msg.TopicPartition.Offset, err)
+			}
+			if m := (<-deliveries).(*kafka.Message); m.TopicPartition.Error != nil {
+				log.Fatalf("Failed to replay dlq offset %d: %v", msg.TopicPartition.Offset, m.TopicPartition.Error)
+			}
+			replayed++
+		} else {
+			skipped++
+		}
+
+		if _, err := c.Client.StoreMessage(msg); err != nil {
+			log.Fatalf("Failed to store offset %d: %v", msg.TopicPartition.Offset, err)
+		}
+		if (replayed+skipped)%100 == 0 {
+			commit(c)
+		}
+	})
+	commit(c)
+	log.Printf("Replayed %d dead letters, skipped %d", replayed, skipped)
+}
+
+func newConsumer(group string) *infrakafka.KafkaConsumer {
+	return infrakafka.NewKafkaConsumer(&kafka.ConfigMap{
+		"bootstrap.servers": config.AppConfig.Kafka.BootstrapServers,
+		"group.id":          group,
+		"auto.offset.reset": "earliest",
+	}, events.TelemetryDLQ, infrakafka.ConsumerConfig{
+		ConnTimeoutMs: config.AppConfig.Kafka.Consumer.ConnTimeoutMs,
+	})
+}
+
+// consume calls handle for each dead letter until limit letters were seen
+// (0 means no limit) or the topic stays idle for the given duration.
+func consume(c *infrakafka.KafkaConsumer, limit int, idle time.Duration, handle func(*kafka.Message, events.DeadLetter)) {
+	if err := c.Client.Subscribe(c.Topic.String(), nil); err != nil {
+		log.Fatalf("Subscribing to %s: %v", c.Topic, err)
+	}
+
+	seen := 0
+	lastMessage := time.Now()
+	for limit == 0 || seen < limit {
+		switch e := c.Client.Poll(pollMs).(type) {
+		case *kafka.Message:
+			lastMessage = time.Now()
+			dl, err := events.DeserializeDeadLetter(e.Value)
+			if err != nil {
+				log.Printf("Skipping malformed dead letter at offset %d: %v", e.TopicPartition.Offset, err)
+				continue
+			}
+			seen++
+			handle(e, dl)
+		case kafka.Error:
+			log.Fatalf("Consumer error: %v", e)
+		default:
+			if time.Since(lastMessage) > idle {
+				return
+			}
+		}
+	}
+}
+
+func commit(c *infrakafka.KafkaConsumer) {
+	if _, err := c.Client.Commit(); err != nil {
+		if kerr, ok := err.(kafka.Error); ok && kerr.Code() == kafka.ErrNoOffset {
+			return
+		}
+		log.Printf("Failed to commit replay progress: %v", err)
+	}
+}
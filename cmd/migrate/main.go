@@ -0,0 +1,98 @@
+// Command migrate applies and reverts the processor database schema.
+//
+//	migrate up            apply every pending migration
+//	migrate down          revert the latest applied migration
+//	migrate status        list migrations and whether they are applied
+//	migrate to <version>  apply or revert until <version> is the latest applied
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/dandyZicky/opensky-collector/internal/config"
+	"github.com/dandyZicky/opensky-collector/internal/infra/pg"
+	"github.com/dandyZicky/opensky-collector/internal/infra/pg/migrations"
+)
+
+const usage = "usage: migrate up | down | status | to <version>"
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+
+	config.InitConfig()
+
+	db, err := pg.NewDB(pg.Config{
+		Host:     config.AppConfig.Database.Host,
+		Port:     config.AppConfig.Database.Port,
+		User:     config.AppConfig.Database.User,
+		Password: config.AppConfig.Database.Pass,
+		Dbname:   config.AppConfig.Database.Name,
+	})
+	if err != nil {
+		log.Fatalf("Failed to init db: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("Failed to get db handle: %v", err)
+	}
+	defer sqlDB.Close()
+
+	migrator, err := migrations.New(sqlDB)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	switch os.Args[1] {
+	case "up":
+		err = migrator.Up()
+	case "down":
+		err = migrator.Down()
+	case "to":
+		if len(os.Args) < 3 {
+			log.Fatal(usage)
+		}
+		version, perr := strconv.ParseInt(os.Args[2], 10, 64)
+		if perr != nil {
+			log.Fatalf("Invalid version %q: %v", os.Args[2], perr)
+		}
+		err = migrator.To(version)
+	case "status":
+		err = printStatus(migrator)
+	default:
+		log.Fatal(usage)
+	}
+	if err != nil {
+		log.Fatalf("Migration failed: %v", err)
+	}
+
+	version, err := migrator.Version()
+	if err != nil {
+		log.Fatalf("Failed to read schema version: %v", err)
+	}
+	log.Printf("Schema at version %d (latest %d)", version, migrator.Latest())
+}
+
+func printStatus(migrator *migrations.Migrator) error {
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, s := range statuses {
+		appliedAt := "pending"
+		if s.Applied {
+			appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+	}
+	return w.Flush()
+}
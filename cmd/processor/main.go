@@ -2,17 +2,33 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"net"
 	"os"
 	"os/signal"
+	"time"
 
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/dandyZicky/opensky-collector/internal/config"
+	"github.com/dandyZicky/opensky-collector/internal/domain/airport"
+	"github.com/dandyZicky/opensky-collector/internal/domain/alerting"
+	"github.com/dandyZicky/opensky-collector/internal/domain/enrichment"
+	"github.com/dandyZicky/opensky-collector/internal/domain/geofence"
+	"github.com/dandyZicky/opensky-collector/internal/domain/live"
 	"github.com/dandyZicky/opensky-collector/internal/domain/processor"
+	"github.com/dandyZicky/opensky-collector/internal/domain/registry"
+	"github.com/dandyZicky/opensky-collector/internal/domain/segmentation"
+	"github.com/dandyZicky/opensky-collector/internal/domain/validation"
+	"github.com/dandyZicky/opensky-collector/internal/infra/api"
+	"github.com/dandyZicky/opensky-collector/internal/infra/grpcserver"
 	consumer "github.com/dandyZicky/opensky-collector/internal/infra/kafka"
 	"github.com/dandyZicky/opensky-collector/internal/infra/pg"
+	"github.com/dandyZicky/opensky-collector/internal/infra/pg/migrations"
 	"github.com/dandyZicky/opensky-collector/internal/infra/sse"
 	"github.com/dandyZicky/opensky-collector/pkg/events"
+	"google.golang.org/grpc"
+	"gorm.io/gorm"
 )
 
 func main() {
@@ -31,6 +47,10 @@ func main() {
 		User:     config.AppConfig.Database.User,
 		Password: config.AppConfig.Database.Pass,
 		Dbname:   config.AppConfig.Database.Name,
+
+		ChunkInterval:   config.AppConfig.Database.ChunkInterval,
+		CompressAfter:   config.AppConfig.Database.CompressAfter,
+		RetentionPeriod: config.AppConfig.Database.RetentionPeriod,
 	}
 
 	db, err := pg.NewDB(dbConf)
@@ -38,24 +58,209 @@ func main() {
 		log.Panicf("Failed to init db: %s", err.Error())
 	}
 
+	if err := ensureSchemaCurrent(db); err != nil {
+		log.Panicf("Refusing to start: %s", err.Error())
+	}
+	if err := pg.ApplyTimescalePolicies(db, dbConf); err != nil {
+		log.Panicf("Failed to apply timescale policies: %s", err.Error())
+	}
+
 	inserter := pg.PgInserter{DB: db}
 
-	broadcasterSSE := sse.NewSSEBroadcaster(ctx, config.AppConfig.SSE.AllowedOrigins)
+	liveTTL := time.Duration(config.AppConfig.Live.TTLMs) * time.Millisecond
+	liveStore := live.NewStore(liveTTL)
+	go liveStore.Run(ctx, liveTTL)
+
+	aircraftRegistry := registry.NewCache(&pg.PgAircraftRepository{DB: db})
+	if err := aircraftRegistry.Refresh(); err != nil {
+		log.Panicf("Failed to load aircraft registry: %s", err.Error())
+	}
+	go aircraftRegistry.Run(ctx, time.Duration(config.AppConfig.Registry.RefreshMs)*time.Millisecond)
+
+	// Publishes dead letters, enriched events, flight events, geofence
+	// events and alerts.
+	producer := consumer.NewKafkaProducer(&kafka.ConfigMap{
+		"bootstrap.servers": config.AppConfig.Kafka.BootstrapServers,
+		"client.id":         config.AppConfig.Kafka.ClientID,
+		"acks":              config.AppConfig.Kafka.Acks,
+	}, config.AppConfig.Kafka.Producer.FlushTimeoutMs)
+	defer producer.Close()
+
+	broadcasterSSE := sse.NewSSEBroadcaster(ctx, sseConfig())
+	broadcasterSSE.Live = liveStore
+
+	geofenceRepo := &pg.PgGeofenceRepository{DB: db}
+	geofences := geofence.NewEvaluator(geofenceRepo, producer, events.GeofenceEvents)
+	geofences.Live = broadcasterSSE
+	geofences.MaxAge = time.Duration(config.AppConfig.Geofences.MaxAgeMs) * time.Millisecond
+	if err := geofences.Reload(); err != nil {
+		log.Panicf("Failed to load geofences: %s", err.Error())
+	}
+	go geofences.Run(ctx, time.Duration(config.AppConfig.Geofences.RefreshMs)*time.Millisecond)
+
 	sseServer := sse.NewSSEServer(broadcasterSSE, "8081")
+	flightRepo := &pg.PgFlightRepository{DB: db}
+	apiHandler := api.NewHandler(flightRepo)
+	apiHandler.Live = liveStore
+	apiHandler.Geofences = geofenceRepo
+	apiHandler.Fences = geofences
+	apiHandler.Register(sseServer)
 	go broadcasterSSE.Run()
 	go sseServer.Start()
 
-	kafkaConsumer := consumer.NewKafkaConsumer(kafkaConf, events.TelemetryRaw)
-	defer kafkaConsumer.Client.Close()
+	grpcListener, err := net.Listen("tcp", ":"+config.AppConfig.GRPC.Port)
+	if err != nil {
+		log.Panicf("Failed to listen for gRPC: %s", err.Error())
+	}
+	grpcServer := grpc.NewServer()
+	grpcserver.NewServer(flightRepo, broadcasterSSE).Register(grpcServer)
+	go func() {
+		log.Printf("gRPC server starting on port %s", config.AppConfig.GRPC.Port)
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			log.Printf("gRPC server stopped: %v", err)
+		}
+	}()
+	// Streams end once the broadcaster shuts down with ctx.
+	defer grpcServer.GracefulStop()
+
+	movements, err := newMovementDetector(db, producer)
+	if err != nil {
+		log.Panicf("Failed to load airports: %s", err.Error())
+	}
+
+	kafkaConsumer := consumer.NewKafkaConsumer(kafkaConf, events.TelemetryRaw, consumerConfig())
+	kafkaConsumer.DeadLetters = producer
+	kafkaConsumer.DeadLetterTopic = events.TelemetryDLQ
 	flightDataProcessor := &processor.ProcessorService{
 		Ctx:         ctx,
 		Inserter:    &inserter,
 		Consumer:    kafkaConsumer,
 		Broadcaster: broadcasterSSE,
+		Validator:   newValidationStage(db),
+		Store:       liveStore,
+		Registry:    aircraftRegistry,
+		Segmenter: segmentation.NewSegmenter(
+			&pg.PgSegmentRepository{DB: db},
+			time.Duration(config.AppConfig.Segmentation.GapMs)*time.Millisecond,
+		),
+		Movements: movements,
+		Geofences: geofences,
+		Alerts:    newAlerter(ctx, producer),
+		Enricher: &enrichment.EnrichmentService{
+			Enricher:  enrichment.NewEnricher(time.Duration(config.AppConfig.Enrichment.MaxGapMs) * time.Millisecond),
+			Publisher: producer,
+			Topic:     events.TelemetryEnriched,
+		},
 	}
 
-	go flightDataProcessor.NewSubscriberService()
+	// Wait for the subscriber to flush and commit its last batch before
+	// exiting.
+	subscriberDone := make(chan struct{})
+	go func() {
+		flightDataProcessor.NewSubscriberService()
+		close(subscriberDone)
+	}()
 
 	<-ctx.Done()
+	<-subscriberDone
+
+}
+
+// ensureSchemaCurrent fails when migrations are pending; the processor never
+// migrates on its own, run cmd/migrate first.
+func ensureSchemaCurrent(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	migrator, err := migrations.New(sqlDB)
+	if err != nil {
+		return err
+	}
 
+	pending, err := migrator.Pending()
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("database schema is behind: %d pending migration(s) up to version %d, run `go run ./cmd/migrate up`", len(pending), migrator.Latest())
+	}
+	return nil
+}
+
+// newMovementDetector loads the airport database once; re-run the processor
+// after importing new airports.
+func newMovementDetector(db *gorm.DB, publisher airport.EventPublisher) (*airport.Detector, error) {
+	repo := &pg.PgAirportRepository{DB: db}
+	airports, err := repo.All()
+	if err != nil {
+		return nil, err
+	}
+	if len(airports) == 0 {
+		log.Println("No airports imported, takeoffs and landings will not be detected; run `go run ./cmd/airports import`")
+	}
+
+	detector := airport.NewDetector(airport.NewIndex(airports), repo, publisher, events.FlightEvents)
+	detector.MaxDistanceM = config.AppConfig.Airports.MaxDistanceM
+	detector.MaxHeightFt = config.AppConfig.Airports.MaxHeightFt
+	detector.MaxGap = time.Duration(config.AppConfig.Airports.MaxGapMs) * time.Millisecond
+	return detector, nil
+}
+
+// newValidationStage builds the rules enabled by validation.rules and
+// quarantines the events they reject.
+func newValidationStage(db *gorm.DB) *validation.Stage {
+	rules, err := validation.SelectRules(
+		config.AppConfig.Validation.Rules,
+		time.Duration(config.AppConfig.Validation.MaxFutureMs)*time.Millisecond,
+	)
+	if err != nil {
+		log.Panicf("Invalid validation config: %s", err.Error())
+	}
+	return &validation.Stage{
+		Validator:  validation.NewValidator(rules),
+		Quarantine: &pg.PgQuarantineRepository{DB: db},
+	}
+}
+
+// newAlerter loads the alerting rules, or returns nil when alerting.rules_file
+// is not set.
+func newAlerter(ctx context.Context, publisher alerting.Publisher) processor.Alerter {
+	path := config.AppConfig.Alerting.RulesFile
+	if path == "" {
+		return nil
+	}
+	rules, err := alerting.LoadRules(path)
+	if err != nil {
+		log.Panicf("Failed to load alerting rules: %s", err.Error())
+	}
+	log.Printf("Loaded %d alerting rules from %s", len(rules.Rules), path)
+	sinks := alerting.NewSinks(rules.Sinks, publisher, events.Alerts)
+	for _, sink := range sinks {
+		if async, ok := sink.(*alerting.Async); ok {
+			go async.Run(ctx)
+		}
+	}
+	return alerting.NewEngine(rules.Rules, sinks)
+}
+
+func consumerConfig() consumer.ConsumerConfig {
+	return consumer.ConsumerConfig{
+		BatchSize:     config.AppConfig.Kafka.Consumer.BatchSize,
+		MaxLingerMs:   config.AppConfig.Kafka.Consumer.MaxLingerMs,
+		SubTimeoutMs:  config.AppConfig.Kafka.Consumer.SubTimeoutMs,
+		ConnTimeoutMs: config.AppConfig.Kafka.Consumer.ConnTimeoutMs,
+	}
+}
+
+func sseConfig() sse.Config {
+	return sse.Config{
+		AllowedOrigins: config.AppConfig.SSE.AllowedOrigins,
+		ReplayBuffer:   config.AppConfig.SSE.ReplayBuffer,
+		ClientBuffer:   config.AppConfig.SSE.ClientBuffer,
+		MaxDrops:       config.AppConfig.SSE.MaxDrops,
+		MaxClients:     config.AppConfig.SSE.MaxClients,
+		HeartbeatMs:    config.AppConfig.SSE.HeartbeatMs,
+	}
 }
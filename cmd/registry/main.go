@@ -0,0 +1,145 @@
+// Command registry loads the OpenSky aircraft database into the registry
+// table the processor attaches aircraft metadata from.
+//
+//	registry import  -file aircraftDatabase.csv [-every D] [-batch N]
+//	registry unknown [-since 24h] [-n 20]
+//
+// import upserts every aircraft in the file. With -every it keeps running and
+// imports the file again whenever its modification time changes. After every
+// import, and with unknown, it reports the aircraft in stored telemetry that
+// the registry does not know.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/dandyZicky/opensky-collector/internal/config"
+	"github.com/dandyZicky/opensky-collector/internal/domain/registry"
+	"github.com/dandyZicky/opensky-collector/internal/infra/pg"
+)
+
+const usage = "usage: registry import -file F [-every D] [-batch N] | registry unknown [-since D] [-n N]"
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+
+	config.InitConfig()
+
+	db, err := pg.NewDB(pg.Config{
+		Host:     config.AppConfig.Database.Host,
+		Port:     config.AppConfig.Database.Port,
+		User:     config.AppConfig.Database.User,
+		Password: config.AppConfig.Database.Pass,
+		Dbname:   config.AppConfig.Database.Name,
+	})
+	if err != nil {
+		log.Fatalf("Failed to init db: %v", err)
+	}
+	repo := &pg.PgAircraftRepository{DB: db}
+
+	switch os.Args[1] {
+	case "import":
+		importFile(repo, os.Args[2:])
+	case "unknown":
+		fs := flag.NewFlagSet("unknown", flag.ExitOnError)
+		since := fs.Duration("since", 24*time.Hour, "only count aircraft seen within this window")
+		limit := fs.Int("n", 20, "number of unknown aircraft to list")
+		fs.Parse(os.Args[2:])
+		report(repo, *since, *limit)
+	default:
+		log.Fatal(usage)
+	}
+}
+
+func importFile(repo *pg.PgAircraftRepository, args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	path := fs.String("file", "", "aircraft database CSV")
+	every := fs.Duration("every", 0, "check the file for changes this often and import it again, 0 to import once")
+	batchSize := fs.Int("batch", registry.DefaultBatchSize, "rows per insert")
+	fs.Parse(args)
+
+	if *path == "" {
+		log.Fatal(usage)
+	}
+	if *batchSize <= 0 {
+		log.Fatalf("-batch must be positive, got %d", *batchSize)
+	}
+
+	importer := &registry.Importer{Store: repo, BatchSize: *batchSize}
+	modified, err := load(importer, *path)
+	if err != nil {
+		log.Fatalf("Failed to import %s: %v", *path, err)
+	}
+	report(repo, 24*time.Hour, 10)
+	if *every == 0 {
+		return
+	}
+
+	// A failed refresh is only logged, the file may be in the middle of
+	// being replaced.
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	ticker := time.NewTicker(*every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(*path)
+			if err != nil {
+				log.Printf("Failed to check %s: %v", *path, err)
+				continue
+			}
+			if info.ModTime().Equal(modified) {
+				continue
+			}
+			if modified, err = load(importer, *path); err != nil {
+				log.Printf("Failed to import %s: %v", *path, err)
+				continue
+			}
+			report(repo, 24*time.Hour, 10)
+		}
+	}
+}
+
+// load imports the file and returns the modification time it had.
+func load(importer *registry.Importer, path string) (time.Time, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	start := time.Now()
+	read, err := importer.Import(f)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("after %d aircraft: %w", read, err)
+	}
+	log.Printf("Imported %d aircraft from %s in %s", read, path, time.Since(start).Round(time.Millisecond))
+	return info.ModTime(), nil
+}
+
+func report(repo *pg.PgAircraftRepository, since time.Duration, limit int) {
+	stats, err := repo.Unknown(time.Now().Add(-since), limit)
+	if err != nil {
+		log.Printf("Failed to count unknown aircraft: %v", err)
+		return
+	}
+	log.Printf("%d of %d aircraft seen in the last %s are not in the registry", stats.Unknown, stats.Seen, since)
+	for _, a := range stats.Top {
+		log.Printf("  %s  %d state vectors", a.Icao24, a.States)
+	}
+}
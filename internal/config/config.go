@@ -15,33 +15,123 @@ type Config struct {
 		Pass    string `mapstructure:"pass"`
 		Name    string `mapstructure:"name"`
 		SSLMode string `mapstructure:"sslmode"`
+		// TimescaleDB intervals in PostgreSQL interval syntax. Leave
+		// compress_after or retention_period empty to disable that policy.
+		ChunkInterval   string `mapstructure:"chunk_interval"`
+		CompressAfter   string `mapstructure:"compress_after"`
+		RetentionPeriod string `mapstructure:"retention_period"`
 	} `mapstructure:"database"`
 	Kafka struct {
 		Producer struct {
 			ClientID string `mapstructure:"producer_client_id"`
+			// How long Flush and Close wait for queued messages to be
+			// delivered.
+			FlushTimeoutMs int `mapstructure:"flush_timeout_ms"`
+			// Encoding of published events: json or protobuf.
+			Format string `mapstructure:"format"`
 		} `mapstructure:"producer"`
 		Consumer struct {
 			GroupID      string `mapstructure:"consumer_group_id"`
 			AutoOffReset string `mapstructure:"auto_offset_reset"`
+			// A batch is flushed at batch_size messages or once its first
+			// message is max_linger_ms old, whichever comes first.
+			BatchSize     int `mapstructure:"batch_size"`
+			MaxLingerMs   int `mapstructure:"max_linger_ms"`
+			SubTimeoutMs  int `mapstructure:"sub_timeout_ms"`
+			ConnTimeoutMs int `mapstructure:"conn_timeout_ms"`
 		} `mapstructure:"consumer"`
-		BootstrapServers string `mapstructure:"bootstrap_servers"`
-		ClientID         string `mapstructure:"client_id"`
-		Acks             string `mapstructure:"acks"`
-		TopicRaw         string `mapstructure:"topic_raw"`
-		TopicEnriched    string `mapstructure:"topic_enriched"`
+		BootstrapServers    string `mapstructure:"bootstrap_servers"`
+		ClientID            string `mapstructure:"client_id"`
+		Acks                string `mapstructure:"acks"`
+		TopicRaw            string `mapstructure:"topic_raw"`
+		TopicEnriched       string `mapstructure:"topic_enriched"`
+		TopicDLQ            string `mapstructure:"topic_dlq"`
+		TopicFlightEvents   string `mapstructure:"topic_flight_events"`
+		TopicGeofenceEvents string `mapstructure:"topic_geofence_events"`
+		TopicAlerts         string `mapstructure:"topic_alerts"`
 	} `mapstructure:"kafka"`
 	SSE struct {
 		Port           string   `mapstructure:"port"`
 		AllowedOrigins []string `mapstructure:"allowed_origins"`
+		// Number of recent state events kept for clients that reconnect
+		// with a Last-Event-ID.
+		ReplayBuffer int `mapstructure:"replay_buffer"`
+		// Batches queued per client; a client that misses max_drops
+		// batches because its queue was full is disconnected.
+		ClientBuffer int `mapstructure:"client_buffer"`
+		MaxDrops     int `mapstructure:"max_drops"`
+		MaxClients   int `mapstructure:"max_clients"`
+		HeartbeatMs  int `mapstructure:"heartbeat_ms"`
 	} `mapstructure:"sse"`
+	GRPC struct {
+		Port string `mapstructure:"port"`
+	} `mapstructure:"grpc"`
+	Live struct {
+		// Aircraft whose last contact is older than ttl_ms are dropped from
+		// the live snapshot.
+		TTLMs int `mapstructure:"ttl_ms"`
+	} `mapstructure:"live"`
+	Registry struct {
+		// How often the processor reloads the aircraft registry.
+		RefreshMs int `mapstructure:"refresh_ms"`
+	} `mapstructure:"registry"`
+	Segmentation struct {
+		// A point more than gap_ms after the previous one of the same
+		// aircraft starts a new flight.
+		GapMs int `mapstructure:"gap_ms"`
+	} `mapstructure:"segmentation"`
+	Airports struct {
+		// A takeoff or landing is attributed to the nearest airport within
+		// max_distance_m, when the airborne point is at most max_height_ft
+		// above it and the two points are at most max_gap_ms apart.
+		MaxDistanceM float64 `mapstructure:"max_distance_m"`
+		MaxHeightFt  float64 `mapstructure:"max_height_ft"`
+		MaxGapMs     int     `mapstructure:"max_gap_ms"`
+	} `mapstructure:"airports"`
+	Geofences struct {
+		// How often the processor reloads the active geofences, on top of
+		// reloading after every change made through the API.
+		RefreshMs int `mapstructure:"refresh_ms"`
+		// An aircraft not seen for max_age_ms is forgotten without an exit
+		// event; when it reappears inside a fence it enters it again.
+		MaxAgeMs int `mapstructure:"max_age_ms"`
+	} `mapstructure:"geofences"`
+	Validation struct {
+		// Reason codes of the rules to apply, all of them when empty.
+		Rules []string `mapstructure:"rules"`
+		// last_contact may be at most max_future_ms ahead of the
+		// processor's clock.
+		MaxFutureMs int `mapstructure:"max_future_ms"`
+	} `mapstructure:"validation"`
+	Alerting struct {
+		// YAML file with the alerting rules and sinks. Alerting is off
+		// when it is empty.
+		RulesFile string `mapstructure:"rules_file"`
+	} `mapstructure:"alerting"`
+	Enrichment struct {
+		// Distance moved is only measured against a previous position at
+		// most max_gap_ms older.
+		MaxGapMs int `mapstructure:"max_gap_ms"`
+	} `mapstructure:"enrichment"`
 	OpenSky struct {
-		BaseURL         string `mapstructure:"base_url"`
-		AuthURL         string `mapstructure:"auth_url"`
-		CredentialsFile string `mapstructure:"credentials_file"`
-		TickerInterval  int    `mapstructure:"ticker_interval_ms"`
+		BaseURL         string   `mapstructure:"base_url"`
+		AuthURL         string   `mapstructure:"auth_url"`
+		CredentialsFile string   `mapstructure:"credentials_file"`
+		TickerInterval  int      `mapstructure:"ticker_interval_ms"`
+		Global          bool     `mapstructure:"global"`
+		Regions         []Region `mapstructure:"regions"`
 	} `mapstructure:"opensky"`
 }
 
+// Region is a named bounding box polled by the collector.
+type Region struct {
+	Name  string  `mapstructure:"name"`
+	LaMin float64 `mapstructure:"lamin"`
+	LoMin float64 `mapstructure:"lomin"`
+	LaMax float64 `mapstructure:"lamax"`
+	LoMax float64 `mapstructure:"lomax"`
+}
+
 var AppConfig Config
 
 func InitConfig() {
@@ -81,6 +171,15 @@ func InitConfig() {
 	if AppConfig.Database.SSLMode == "" {
 		AppConfig.Database.SSLMode = "disable"
 	}
+	if AppConfig.Database.ChunkInterval == "" {
+		AppConfig.Database.ChunkInterval = "1 day"
+	}
+	if !viper.IsSet("database.compress_after") {
+		AppConfig.Database.CompressAfter = "7 days"
+	}
+	if !viper.IsSet("database.retention_period") {
+		AppConfig.Database.RetentionPeriod = "90 days"
+	}
 
 	if AppConfig.Kafka.BootstrapServers == "" {
 		AppConfig.Kafka.BootstrapServers = "localhost:29092"
@@ -91,6 +190,12 @@ func InitConfig() {
 	if AppConfig.Kafka.Producer.ClientID == "" {
 		AppConfig.Kafka.Producer.ClientID = "openskyCollector"
 	}
+	if AppConfig.Kafka.Producer.FlushTimeoutMs == 0 {
+		AppConfig.Kafka.Producer.FlushTimeoutMs = 10000
+	}
+	if AppConfig.Kafka.Producer.Format == "" {
+		AppConfig.Kafka.Producer.Format = "json"
+	}
 	if AppConfig.Kafka.Acks == "" {
 		AppConfig.Kafka.Acks = "all"
 	}
@@ -100,12 +205,84 @@ func InitConfig() {
 	if AppConfig.Kafka.TopicEnriched == "" {
 		AppConfig.Kafka.TopicEnriched = "telemetry.enriched"
 	}
+	if AppConfig.Kafka.TopicDLQ == "" {
+		AppConfig.Kafka.TopicDLQ = "telemetry.dlq"
+	}
+	if AppConfig.Kafka.TopicFlightEvents == "" {
+		AppConfig.Kafka.TopicFlightEvents = "flight.events"
+	}
+	if AppConfig.Kafka.TopicGeofenceEvents == "" {
+		AppConfig.Kafka.TopicGeofenceEvents = "geofence.events"
+	}
+	if AppConfig.Kafka.TopicAlerts == "" {
+		AppConfig.Kafka.TopicAlerts = "alerts"
+	}
 	if AppConfig.Kafka.Consumer.AutoOffReset == "" {
 		AppConfig.Kafka.Consumer.AutoOffReset = "earliest"
 	}
+	if AppConfig.Kafka.Consumer.BatchSize == 0 {
+		AppConfig.Kafka.Consumer.BatchSize = 100
+	}
+	if AppConfig.Kafka.Consumer.MaxLingerMs == 0 {
+		AppConfig.Kafka.Consumer.MaxLingerMs = 1000
+	}
+	if AppConfig.Kafka.Consumer.SubTimeoutMs == 0 {
+		AppConfig.Kafka.Consumer.SubTimeoutMs = 100
+	}
+	if AppConfig.Kafka.Consumer.ConnTimeoutMs == 0 {
+		AppConfig.Kafka.Consumer.ConnTimeoutMs = 5000
+	}
 	if AppConfig.SSE.Port == "" {
 		AppConfig.SSE.Port = "8081"
 	}
+	if AppConfig.SSE.ReplayBuffer == 0 {
+		AppConfig.SSE.ReplayBuffer = 10000
+	}
+	if AppConfig.SSE.ClientBuffer == 0 {
+		AppConfig.SSE.ClientBuffer = 64
+	}
+	if AppConfig.SSE.MaxDrops == 0 {
+		AppConfig.SSE.MaxDrops = 10
+	}
+	if AppConfig.SSE.MaxClients == 0 {
+		AppConfig.SSE.MaxClients = 1000
+	}
+	if AppConfig.SSE.HeartbeatMs == 0 {
+		AppConfig.SSE.HeartbeatMs = 15000
+	}
+	if AppConfig.GRPC.Port == "" {
+		AppConfig.GRPC.Port = "9090"
+	}
+	if AppConfig.Live.TTLMs == 0 {
+		AppConfig.Live.TTLMs = 300000
+	}
+	if AppConfig.Registry.RefreshMs == 0 {
+		AppConfig.Registry.RefreshMs = 3600000
+	}
+	if AppConfig.Segmentation.GapMs == 0 {
+		AppConfig.Segmentation.GapMs = 900000
+	}
+	if AppConfig.Airports.MaxDistanceM == 0 {
+		AppConfig.Airports.MaxDistanceM = 8000
+	}
+	if AppConfig.Airports.MaxHeightFt == 0 {
+		AppConfig.Airports.MaxHeightFt = 3000
+	}
+	if AppConfig.Airports.MaxGapMs == 0 {
+		AppConfig.Airports.MaxGapMs = 300000
+	}
+	if AppConfig.Geofences.RefreshMs == 0 {
+		AppConfig.Geofences.RefreshMs = 60000
+	}
+	if AppConfig.Geofences.MaxAgeMs == 0 {
+		AppConfig.Geofences.MaxAgeMs = 900000
+	}
+	if AppConfig.Validation.MaxFutureMs == 0 {
+		AppConfig.Validation.MaxFutureMs = 300000
+	}
+	if AppConfig.Enrichment.MaxGapMs == 0 {
+		AppConfig.Enrichment.MaxGapMs = 600000
+	}
 	if len(AppConfig.SSE.AllowedOrigins) == 0 {
 		AppConfig.SSE.AllowedOrigins = []string{"http://localhost:3000"}
 	}
@@ -122,6 +299,11 @@ func InitConfig() {
 	if AppConfig.OpenSky.TickerInterval == 0 {
 		AppConfig.OpenSky.TickerInterval = 21600
 	}
+	if !AppConfig.OpenSky.Global && len(AppConfig.OpenSky.Regions) == 0 {
+		AppConfig.OpenSky.Regions = []Region{
+			{Name: "indonesia", LaMin: -11, LoMin: 95, LaMax: 6, LoMax: 141},
+		}
+	}
 
-	events.InitTopics(AppConfig.Kafka.TopicRaw, AppConfig.Kafka.TopicEnriched)
+	events.InitTopics(AppConfig.Kafka.TopicRaw, AppConfig.Kafka.TopicEnriched, AppConfig.Kafka.TopicDLQ, AppConfig.Kafka.TopicFlightEvents, AppConfig.Kafka.TopicGeofenceEvents, AppConfig.Kafka.TopicAlerts)
 }
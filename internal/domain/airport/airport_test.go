@@ -0,0 +1,205 @@
+package airport
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/flight"
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+const airportsCSV = `"id","ident","type","name","latitude_deg","longitude_deg","elevation_ft","continent","iso_country","iso_region","municipality","scheduled_service","gps_code","iata_code","local_code"
+26555,"WIII","large_airport","Soekarno-Hatta International Airport",-6.1255698204,106.65599823,34,"AS","ID","ID-BT","Jakarta","yes","WIII","CGK",""
+26560,"WIHH","medium_airport","Halim Perdanakusuma International Airport",-6.26661014557,106.891998291,84,"AS","ID","ID-JK","Jakarta","yes","WIHH","HLP",""
+300001,"ID-0001","heliport","Some Helipad",-6.2,106.8,,"AS","ID","ID-JK","Jakarta","no","","",""
+300002,"ID-0002","small_airport","Strip Without Code",-7.0,110.0,,"AS","ID","ID-JT","","no","","",""
+`
+
+const runwaysCSV = `"id","airport_ref","airport_ident","length_ft","width_ft","surface","lighted","closed","le_ident","le_latitude_deg","le_longitude_deg","le_elevation_ft","le_heading_degT","le_displaced_threshold_ft","he_ident","he_latitude_deg","he_longitude_deg","he_elevation_ft","he_heading_degT","he_displaced_threshold_ft"
+1,26555,"WIII",12008,197,"ASP",1,0,"07R",,,,70.6,,"25L",,,,250.6,
+2,26555,"WIII",11811,197,"ASP",1,0,"07L",,,,,,"25R",,,,,
+3,26560,"WIHH",9843,148,"ASP",1,1,"06",,,,,,"24",,,,,
+4,300001,"ID-0001",50,50,"CON",0,0,"H1",,,,,,"",,,,,
+`
+
+type MockStore struct {
+	mock.Mock
+}
+
+func (m *MockStore) All() ([]Airport, error) {
+	args := m.Called()
+	return args.Get(0).([]Airport), args.Error(1)
+}
+
+func (m *MockStore) Upsert(airports []Airport, batchSize int) error {
+	args := m.Called(append([]Airport(nil), airports...), batchSize)
+	return args.Error(0)
+}
+
+type MockEventRepository struct {
+	mock.Mock
+}
+
+func (m *MockEventRepository) SaveEvents(flightEvents []events.FlightEvent) error {
+	args := m.Called(flightEvents)
+	return args.Error(0)
+}
+
+type MockEventPublisher struct {
+	mock.Mock
+}
+
+func (m *MockEventPublisher) PublishFlightEvents(flightEvents []events.FlightEvent, topic events.Topic) error {
+	args := m.Called(flightEvents, topic)
+	return args.Error(0)
+}
+
+func TestImporter(t *testing.T) {
+	store := &MockStore{}
+	var stored []Airport
+	store.On("Upsert", mock.Anything, 500).Run(func(args mock.Arguments) {
+		stored = append(stored, args.Get(0).([]Airport)...)
+	}).Return(nil)
+
+	importer := &Importer{Store: store, BatchSize: 500, Types: DefaultTypes}
+	airports, ends, err := importer.Import(strings.NewReader(airportsCSV), strings.NewReader(runwaysCSV))
+	require.NoError(t, err)
+	assert.Equal(t, 3, airports)
+	assert.Equal(t, 4, ends)
+
+	require.Len(t, stored, 3)
+	assert.Equal(t, "WIII", stored[0].Code)
+	assert.Equal(t, 34.0, *stored[0].ElevationFt)
+	assert.Equal(t, []Runway{
+		{Ident: "07R", HeadingDeg: 70.6},
+		{Ident: "25L", HeadingDeg: 250.6},
+		{Ident: "07L", HeadingDeg: 70},
+		{Ident: "25R", HeadingDeg: 250},
+	}, stored[0].Runways)
+	assert.Empty(t, stored[1].Runways, "closed runways are skipped")
+	assert.Equal(t, "ID-0002", stored[2].Code)
+	assert.Nil(t, stored[2].ElevationFt)
+}
+
+func TestIndex_Nearest(t *testing.T) {
+	idx := NewIndex([]Airport{
+		{Code: "WIII", Lat: -6.1256, Lon: 106.6560},
+		{Code: "WIHH", Lat: -6.2666, Lon: 106.8920},
+		{Code: "NZCH", Lat: -43.4894, Lon: 172.5320},
+	})
+
+	a, ok := idx.Nearest(-6.13, 106.64, 5000)
+	require.True(t, ok)
+	assert.Equal(t, "WIII", a.Code)
+
+	// Across a cell boundary from the airport.
+	a, ok = idx.Nearest(-5.999, 106.66, 50000)
+	require.True(t, ok)
+	assert.Equal(t, "WIII", a.Code)
+
+	_, ok = idx.Nearest(-7.5, 110.4, 8000)
+	assert.False(t, ok)
+}
+
+func TestGuessRunway(t *testing.T) {
+	runways := []Runway{{Ident: "07R", HeadingDeg: 70.6}, {Ident: "25L", HeadingDeg: 250.6}, {Ident: "36", HeadingDeg: 360}}
+
+	assert.Equal(t, "07R", guessRunway(runways, ptr(75.0)))
+	assert.Equal(t, "25L", guessRunway(runways, ptr(245.0)))
+	assert.Equal(t, "36", guessRunway(runways, ptr(2.0)))
+	assert.Empty(t, guessRunway(runways, ptr(160.0)))
+	assert.Empty(t, guessRunway(runways, nil))
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+var base = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func state(seconds int, lat, lon float64, alt float64, onGround bool) flight.FlightState {
+	at := base.Add(time.Duration(seconds) * time.Second)
+	return flight.FlightState{
+		Icao24:       "8a0001",
+		Callsign:     ptr("GIA402"),
+		Lat:          ptr(lat),
+		Lon:          ptr(lon),
+		BaroAltitude: ptr(alt),
+		TrueTrack:    ptr(250.0),
+		TimePosition: &at,
+		LastContact:  at,
+		OnGround:     onGround,
+	}
+}
+
+func newTestDetector() (*Detector, *MockEventRepository, *MockEventPublisher) {
+	idx := NewIndex([]Airport{{
+		Code: "WIII", Lat: -6.1256, Lon: 106.6560, ElevationFt: ptr(34.0),
+		Runways: []Runway{{Ident: "07R", HeadingDeg: 70.6}, {Ident: "25L", HeadingDeg: 250.6}},
+	}})
+	repo := &MockEventRepository{}
+	publisher := &MockEventPublisher{}
+	return NewDetector(idx, repo, publisher, "flight.events"), repo, publisher
+}
+
+func TestDetector_TakeoffAndLanding(t *testing.T) {
+	d, repo, publisher := newTestDetector()
+	takeoff := events.FlightEvent{
+		Type: events.FlightEventTakeoff, Icao24: "8a0001", Callsign: ptr("GIA402"),
+		Airport: "WIII", Runway: "25L", Time: base.Add(20 * time.Second).Unix(), Lat: -6.12, Lon: 106.63,
+	}
+	repo.On("SaveEvents", []events.FlightEvent{takeoff}).Return(nil).Once()
+	publisher.On("PublishFlightEvents", []events.FlightEvent{takeoff}, events.Topic("flight.events")).Return(nil).Once()
+
+	require.NoError(t, d.Detect([]flight.FlightState{
+		state(20, -6.12, 106.63, 150, false),
+		state(0, -6.125, 106.65, 0, true),
+		state(600, -6.13, 106.70, 9000, false),
+	}))
+	// Far above the field: glitches in the ground flag, not a landing and
+	// takeoff.
+	require.NoError(t, d.Detect([]flight.FlightState{
+		state(620, -6.13, 106.70, 9000, true),
+		state(640, -6.13, 106.70, 9000, false),
+	}))
+	repo.AssertExpectations(t)
+	publisher.AssertExpectations(t)
+
+	landing := mock.MatchedBy(func(e []events.FlightEvent) bool {
+		return len(e) == 1 && e[0].Type == events.FlightEventLanding && e[0].Airport == "WIII"
+	})
+	repo.On("SaveEvents", landing).Return(nil).Once()
+	publisher.On("PublishFlightEvents", landing, events.Topic("flight.events")).Return(nil).Once()
+	require.NoError(t, d.Detect([]flight.FlightState{
+		state(700, -6.13, 106.68, 100, false),
+		state(720, -6.128, 106.66, 0, true),
+	}))
+	repo.AssertExpectations(t)
+}
+
+func TestDetector_RetriesAfterFailure(t *testing.T) {
+	d, repo, publisher := newTestDetector()
+	batch := []flight.FlightState{
+		state(0, -6.125, 106.65, 0, true),
+		state(20, -6.12, 106.63, 150, false),
+	}
+
+	repo.On("SaveEvents", mock.Anything).Return(nil)
+	publisher.On("PublishFlightEvents", mock.Anything, mock.Anything).Return(errors.New("broker down")).Once()
+	publisher.On("PublishFlightEvents", mock.Anything, mock.Anything).Return(nil).Once()
+
+	assert.Error(t, d.Detect(batch))
+	assert.Empty(t, d.last)
+	require.NoError(t, d.Detect(batch))
+	publisher.AssertNumberOfCalls(t, "PublishFlightEvents", 2)
+
+	// Redelivered again after success: nothing new.
+	require.NoError(t, d.Detect(batch))
+	publisher.AssertNumberOfCalls(t, "PublishFlightEvents", 2)
+}
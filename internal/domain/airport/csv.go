@@ -0,0 +1,124 @@
+package airport
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// record reads CSV rows by header name.
+type record struct {
+	columns map[string]int
+	values  []string
+}
+
+func (r record) get(name string) string {
+	i, ok := r.columns[name]
+	if !ok || i >= len(r.values) {
+		return ""
+	}
+	return strings.TrimSpace(r.values[i])
+}
+
+func (r record) float(name string) (float64, bool) {
+	v, err := strconv.ParseFloat(r.get(name), 64)
+	return v, err == nil
+}
+
+// readCSV calls fn for every row after the header, which must contain the
+// required columns.
+func readCSV(r io.Reader, required []string, fn func(record) error) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("reading header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range required {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("header has no %s column", name)
+		}
+	}
+
+	for {
+		values, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(record{columns: columns, values: values}); err != nil {
+			return err
+		}
+	}
+}
+
+// ParseAirports reads an OurAirports airports.csv and calls fn for every
+// airport with a position whose type is in types, or for all when types is
+// empty.
+func ParseAirports(r io.Reader, types map[string]bool, fn func(Airport) error) error {
+	required := []string{"ident", "type", "latitude_deg", "longitude_deg"}
+	return readCSV(r, required, func(rec record) error {
+		a := Airport{
+			Ident:   rec.get("ident"),
+			Name:    rec.get("name"),
+			Type:    rec.get("type"),
+			Country: rec.get("iso_country"),
+		}
+		if a.Ident == "" || (len(types) > 0 && !types[a.Type]) {
+			return nil
+		}
+		var okLat, okLon bool
+		a.Lat, okLat = rec.float("latitude_deg")
+		a.Lon, okLon = rec.float("longitude_deg")
+		if !okLat || !okLon {
+			return nil
+		}
+		if elevation, ok := rec.float("elevation_ft"); ok {
+			a.ElevationFt = &elevation
+		}
+		for _, code := range []string{rec.get("icao_code"), rec.get("gps_code"), a.Ident} {
+			if code != "" {
+				a.Code = code
+				break
+			}
+		}
+		return fn(a)
+	})
+}
+
+// ParseRunways reads an OurAirports runways.csv and calls fn with both ends
+// of every open runway. An end without a heading takes it from its name.
+func ParseRunways(r io.Reader, fn func(airportIdent string, runway Runway) error) error {
+	required := []string{"airport_ident", "le_ident", "he_ident"}
+	return readCSV(r, required, func(rec record) error {
+		if rec.get("closed") == "1" {
+			return nil
+		}
+		for _, end := range []string{"le", "he"} {
+			ident := rec.get(end + "_ident")
+			if ident == "" {
+				continue
+			}
+			heading, ok := rec.float(end + "_heading_degT")
+			if !ok {
+				if heading, ok = headingFromIdent(ident); !ok {
+					continue
+				}
+			}
+			if err := fn(rec.get("airport_ident"), Runway{Ident: ident, HeadingDeg: heading}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
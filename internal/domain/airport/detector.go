@@ -0,0 +1,160 @@
+package airport
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/flight"
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+const (
+	// runwayTolerance is the largest difference in degrees between the
+	// aircraft's track and a runway heading for that runway to be guessed.
+	runwayTolerance = 30.0
+
+	DefaultMaxDistanceM = 8000.0
+	DefaultMaxHeightFt  = 3000.0
+	DefaultMaxGap       = 5 * time.Minute
+)
+
+// Detector turns on_ground transitions near an airport into takeoff and
+// landing events. A transition counts when its two points are at most MaxGap
+// apart, the event position is within MaxDistanceM of the airport and the
+// airborne point, when it has an altitude, is at most MaxHeightFt above the
+// airport elevation.
+type Detector struct {
+	Airports  *Index
+	Repo      EventRepository
+	Publisher EventPublisher
+	Topic     events.Topic
+
+	MaxDistanceM float64
+	MaxHeightFt  float64
+	MaxGap       time.Duration
+
+	mu   sync.Mutex
+	last map[string]flight.FlightState
+}
+
+func NewDetector(airports *Index, repo EventRepository, publisher EventPublisher, topic events.Topic) *Detector {
+	return &Detector{
+		Airports:     airports,
+		Repo:         repo,
+		Publisher:    publisher,
+		Topic:        topic,
+		MaxDistanceM: DefaultMaxDistanceM,
+		MaxHeightFt:  DefaultMaxHeightFt,
+		MaxGap:       DefaultMaxGap,
+		last:         make(map[string]flight.FlightState),
+	}
+}
+
+// Detect finds the takeoffs and landings in a batch of states, stores them
+// and publishes them. States not newer than the previous one of their
+// aircraft are ignored. Nothing is kept when storing or publishing fails, so
+// the batch can be retried.
+func (d *Detector) Detect(states []flight.FlightState) error {
+	points := flight.Positioned(states)
+	if len(points) == 0 {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	next := make(map[string]flight.FlightState)
+	var detected []events.FlightEvent
+	for _, p := range points {
+		prev, ok := next[p.Icao24]
+		if !ok {
+			prev, ok = d.last[p.Icao24]
+		}
+		if ok && !p.TimePosition.After(*prev.TimePosition) {
+			continue
+		}
+		next[p.Icao24] = p
+		if !ok || prev.OnGround == p.OnGround || p.TimePosition.Sub(*prev.TimePosition) > d.MaxGap {
+			continue
+		}
+		if event, ok := d.transition(prev, p); ok {
+			detected = append(detected, event)
+		}
+	}
+
+	if len(detected) > 0 {
+		if err := d.Repo.SaveEvents(detected); err != nil {
+			return err
+		}
+		if err := d.Publisher.PublishFlightEvents(detected, d.Topic); err != nil {
+			return err
+		}
+	}
+
+	var newest time.Time
+	for icao24, p := range next {
+		d.last[icao24] = p
+		if p.TimePosition.After(newest) {
+			newest = *p.TimePosition
+		}
+	}
+	for icao24, p := range d.last {
+		if p.TimePosition.Before(newest.Add(-d.MaxGap)) {
+			delete(d.last, icao24)
+		}
+	}
+	return nil
+}
+
+// transition builds the event for a change of the ground flag from prev to p.
+// The event is placed at p, the first point after the change.
+func (d *Detector) transition(prev, p flight.FlightState) (events.FlightEvent, bool) {
+	kind, airborne := events.FlightEventTakeoff, p
+	if p.OnGround {
+		kind, airborne = events.FlightEventLanding, prev
+	}
+
+	a, ok := d.Airports.Nearest(*p.Lat, *p.Lon, d.MaxDistanceM)
+	if !ok {
+		return events.FlightEvent{}, false
+	}
+	if alt := flight.Altitude(airborne.BaroAltitude, airborne.GeoAltitude); alt != nil && a.ElevationFt != nil && *alt*flight.FeetPerMeter-*a.ElevationFt > d.MaxHeightFt {
+		return events.FlightEvent{}, false
+	}
+
+	callsign := p.Callsign
+	if callsign == nil {
+		callsign = prev.Callsign
+	}
+	track := airborne.TrueTrack
+	if track == nil {
+		track = p.TrueTrack
+	}
+	return events.FlightEvent{
+		Type:     kind,
+		Icao24:   p.Icao24,
+		Callsign: callsign,
+		Airport:  a.Code,
+		Runway:   guessRunway(a.Runways, track),
+		Time:     p.TimePosition.Unix(),
+		Lat:      *p.Lat,
+		Lon:      *p.Lon,
+	}, true
+}
+
+// guessRunway returns the runway end whose heading is closest to the track,
+// or "" when none is within runwayTolerance.
+func guessRunway(runways []Runway, track *float64) string {
+	if track == nil {
+		return ""
+	}
+	best, bestDiff := "", runwayTolerance
+	for _, r := range runways {
+		diff := math.Abs(math.Mod(*track-r.HeadingDeg+540, 360) - 180)
+		if diff <= bestDiff {
+			best, bestDiff = r.Ident, diff
+		}
+	}
+	return best
+}
@@ -0,0 +1,66 @@
+package airport
+
+import "io"
+
+// DefaultTypes are the airport types imported unless told otherwise;
+// heliports, seaplane bases and closed airports are left out.
+var DefaultTypes = map[string]bool{
+	"small_airport":  true,
+	"medium_airport": true,
+	"large_airport":  true,
+}
+
+// Importer loads OurAirports dumps into the airport store.
+type Importer struct {
+	Store     Store
+	BatchSize int
+	Types     map[string]bool
+}
+
+// Import reads the airports and, when runways is not nil, their runways, and
+// upserts them. It returns how many airports and runway ends were stored.
+func (i *Importer) Import(airports, runways io.Reader) (int, int, error) {
+	byIdent := make(map[string]*Airport)
+	var order []string
+	err := ParseAirports(airports, i.Types, func(a Airport) error {
+		if _, ok := byIdent[a.Ident]; !ok {
+			order = append(order, a.Ident)
+		}
+		byIdent[a.Ident] = &a
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	ends := 0
+	if runways != nil {
+		err = ParseRunways(runways, func(ident string, r Runway) error {
+			if a, ok := byIdent[ident]; ok {
+				a.Runways = append(a.Runways, r)
+				ends++
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	batch := make([]Airport, 0, i.BatchSize)
+	for _, ident := range order {
+		batch = append(batch, *byIdent[ident])
+		if len(batch) >= i.BatchSize {
+			if err := i.Store.Upsert(batch, i.BatchSize); err != nil {
+				return 0, 0, err
+			}
+			batch = batch[:0]
+		}
+	}
+	if len(batch) > 0 {
+		if err := i.Store.Upsert(batch, i.BatchSize); err != nil {
+			return 0, 0, err
+		}
+	}
+	return len(order), ends, nil
+}
@@ -0,0 +1,65 @@
+package airport
+
+import (
+	"math"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/flight"
+)
+
+// cell is a one degree square of latitude and longitude.
+type cell struct {
+	lat, lon int
+}
+
+func cellOf(lat, lon float64) cell {
+	return cell{lat: int(math.Floor(lat)), lon: int(math.Floor(lon))}
+}
+
+// Index finds the airport nearest to a position. It searches the cell of the
+// position and its neighbours, so distances beyond the width of a cell,
+// about 19km at 80 degrees latitude, are not reliable.
+type Index struct {
+	cells map[cell][]Airport
+	size  int
+}
+
+func NewIndex(airports []Airport) *Index {
+	idx := &Index{cells: make(map[cell][]Airport), size: len(airports)}
+	for _, a := range airports {
+		c := cellOf(a.Lat, a.Lon)
+		idx.cells[c] = append(idx.cells[c], a)
+	}
+	return idx
+}
+
+func (idx *Index) Len() int {
+	return idx.size
+}
+
+// Nearest returns the closest airport within maxDistanceM of the position.
+func (idx *Index) Nearest(lat, lon, maxDistanceM float64) (Airport, bool) {
+	var best Airport
+	bestDistance := math.Inf(1)
+	center := cellOf(lat, lon)
+	for dLat := -1; dLat <= 1; dLat++ {
+		for dLon := -1; dLon <= 1; dLon++ {
+			c := cell{lat: center.lat + dLat, lon: wrapLon(center.lon + dLon)}
+			for _, a := range idx.cells[c] {
+				if d := flight.Distance(lat, lon, a.Lat, a.Lon); d < bestDistance {
+					best, bestDistance = a, d
+				}
+			}
+		}
+	}
+	return best, bestDistance <= maxDistanceM
+}
+
+func wrapLon(lon int) int {
+	switch {
+	case lon < -180:
+		return lon + 360
+	case lon >= 180:
+		return lon - 360
+	}
+	return lon
+}
@@ -0,0 +1,64 @@
+// Package airport holds the airport database and detects takeoffs and
+// landings at its airports.
+package airport
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+// Airport is one entry of an OurAirports-style database. Code is the ICAO
+// code used in flight events: the icao_code, else the gps_code, else the
+// ident.
+type Airport struct {
+	Ident       string
+	Code        string
+	Name        string
+	Type        string
+	Lat         float64
+	Lon         float64
+	ElevationFt *float64
+	Country     string
+	Runways     []Runway
+}
+
+// Runway is one end of a runway, named like "27L". HeadingDeg is the true
+// heading of an aircraft using that end.
+type Runway struct {
+	Ident      string
+	HeadingDeg float64
+}
+
+// headingFromIdent reads the magnetic heading encoded in a runway name such
+// as "09L", for runways listed without a heading.
+func headingFromIdent(ident string) (float64, bool) {
+	digits := strings.TrimRight(ident, "LCRTW")
+	n, err := strconv.Atoi(digits)
+	if err != nil || n < 1 || n > 36 {
+		return 0, false
+	}
+	return float64(n * 10), true
+}
+
+// Store holds the imported airport database.
+type Store interface {
+	All() ([]Airport, error)
+	// Upsert inserts or replaces airports by ident together with their
+	// runways.
+	Upsert(airports []Airport, batchSize int) error
+}
+
+// EventRepository persists detected flight events; saving an event again is
+// a no-op.
+type EventRepository interface {
+	SaveEvents(flightEvents []events.FlightEvent) error
+}
+
+// EventPublisher writes takeoffs and landings to a topic. The Detector saves
+// them first and keeps no state when it fails, so the retried batch saves
+// them again as a no-op and republishes them.
+type EventPublisher interface {
+	PublishFlightEvents(flightEvents []events.FlightEvent, topic events.Topic) error
+}
@@ -0,0 +1,304 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+const rulesYAML = `
+rules:
+  - name: emergency
+    type: squawk
+    severity: critical
+    cooldown: 10m
+  - name: spi
+    type: spi
+    severity: info
+  - name: steep-climb
+    type: vertical_rate
+    max: 20
+  - name: rapid-descent
+    type: rapid_descent
+    drop_m: 1000
+    within: 1m
+  - name: lost
+    type: lost_contact
+    after: 2m
+sinks:
+  - type: kafka
+  - type: webhook
+    url: http://localhost:9999/alerts
+    timeout: 2s
+    queue: 10
+  - type: log
+`
+
+type MockSink struct {
+	mock.Mock
+}
+
+func (m *MockSink) Send(alerts []events.Alert) error {
+	args := m.Called(alerts)
+	return args.Error(0)
+}
+
+type MockPublisher struct {
+	mock.Mock
+}
+
+func (m *MockPublisher) PublishAlerts(alerts []events.Alert, topic events.Topic) error {
+	args := m.Called(alerts, topic)
+	return args.Error(0)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func loadTestRules(t *testing.T, content string) (RuleSet, error) {
+	path := filepath.Join(t.TempDir(), "alerts.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return LoadRules(path)
+}
+
+// collect returns a sink that records every alert it receives.
+func collect(sent *[]events.Alert) *MockSink {
+	sink := &MockSink{}
+	sink.On("Send", mock.Anything).Run(func(args mock.Arguments) {
+		*sent = append(*sent, args.Get(0).([]events.Alert)...)
+	}).Return(nil)
+	return sink
+}
+
+func rules(t *testing.T) []Rule {
+	rs, err := loadTestRules(t, rulesYAML)
+	require.NoError(t, err)
+	return rs.Rules
+}
+
+func event(icao24 string, at int64) events.TelemetryRawEvent {
+	return events.TelemetryRawEvent{Icao24: icao24, LastContact: at, Lat: ptr(-6.1), Lon: ptr(106.6)}
+}
+
+func names(alerts []events.Alert) []string {
+	var out []string
+	for _, a := range alerts {
+		out = append(out, a.Rule+":"+a.Icao24)
+	}
+	return out
+}
+
+func TestLoadRules(t *testing.T) {
+	rs, err := loadTestRules(t, rulesYAML)
+	require.NoError(t, err)
+	require.Len(t, rs.Rules, 5)
+	assert.Equal(t, EmergencySquawks, rs.Rules[0].Squawks)
+	assert.Equal(t, 10*time.Minute, rs.Rules[0].Cooldown)
+	assert.Equal(t, DefaultCooldown, rs.Rules[1].Cooldown)
+	assert.Equal(t, SeverityWarning, rs.Rules[2].Severity)
+	assert.Equal(t, 20.0, *rs.Rules[2].Max)
+	assert.Equal(t, time.Minute, rs.Rules[3].Within)
+	assert.Equal(t, AltitudeBaro, rs.Rules[3].Altitude)
+	require.Len(t, rs.Sinks, 3)
+	assert.Equal(t, 2*time.Second, rs.Sinks[1].Timeout)
+	assert.Equal(t, 10, rs.Sinks[1].Queue)
+
+	sinks := NewSinks(rs.Sinks, &MockPublisher{}, "alerts")
+	require.Len(t, sinks, 3)
+	assert.IsType(t, &KafkaSink{}, sinks[0])
+	assert.IsType(t, &Async{}, sinks[1])
+
+	for name, content := range map[string]string{
+		"no rules":       "rules: []",
+		"unknown type":   "rules: [{name: a, type: wind}]",
+		"duplicate":      "rules: [{name: a, type: spi}, {name: a, type: spi}]",
+		"no threshold":   "rules: [{name: a, type: vertical_rate}]",
+		"no after":       "rules: [{name: a, type: lost_contact}]",
+		"severity":       "rules: [{name: a, type: spi, severity: loud}]",
+		"altitude":       "rules: [{name: a, type: rapid_descent, drop_m: 1, within: 1m, altitude: gps}]",
+		"webhook no url": "rules: [{name: a, type: spi}]\nsinks: [{type: webhook}]",
+	} {
+		_, err := loadTestRules(t, content)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestEngine_EventRules(t *testing.T) {
+	var sent []events.Alert
+	// Without lost_contact, which would report 8a0002 going quiet.
+	engine := NewEngine(rules(t)[:4], []Sink{collect(&sent)})
+
+	squawking := event("8a0001", 1000)
+	squawking.Squawk = ptr("7700")
+	climbing := event("8a0002", 1000)
+	climbing.VerticalRate = ptr(25.0)
+	climbing.Spi = true
+	require.NoError(t, engine.Evaluate([]events.TelemetryRawEvent{squawking, climbing}))
+	assert.ElementsMatch(t, []string{"emergency:8a0001", "spi:8a0002", "steep-climb:8a0002"}, names(sent))
+	assert.Equal(t, SeverityCritical, sent[0].Severity)
+	assert.Equal(t, "squawk 7700", sent[0].Message)
+
+	// Redelivered and still squawking within the cooldown: nothing new.
+	sent = nil
+	require.NoError(t, engine.Evaluate([]events.TelemetryRawEvent{squawking}))
+	squawking.LastContact = 1300
+	require.NoError(t, engine.Evaluate([]events.TelemetryRawEvent{squawking}))
+	assert.Empty(t, sent)
+
+	// After the cooldown it alerts again.
+	squawking.LastContact = 1000 + 601
+	require.NoError(t, engine.Evaluate([]events.TelemetryRawEvent{squawking}))
+	assert.Equal(t, []string{"emergency:8a0001"}, names(sent))
+}
+
+func TestEngine_RapidDescent(t *testing.T) {
+	var sent []events.Alert
+	engine := NewEngine(rules(t), []Sink{collect(&sent)})
+
+	var batch []events.TelemetryRawEvent
+	for i, alt := range []float64{9000, 8900, 8700, 8400} {
+		ev := event("8a0001", 1000+int64(i)*30)
+		ev.BaroAltitude = ptr(alt)
+		batch = append(batch, ev)
+	}
+	require.NoError(t, engine.Evaluate(batch))
+	assert.Empty(t, sent, "600 m over 90 s is not rapid")
+
+	ev := event("8a0001", 1120)
+	ev.BaroAltitude = ptr(7600.0)
+	require.NoError(t, engine.Evaluate([]events.TelemetryRawEvent{ev}))
+	require.Len(t, sent, 1)
+	assert.Equal(t, "rapid-descent", sent[0].Rule)
+	assert.Equal(t, "descended 1100 m in 1m0s", sent[0].Message)
+}
+
+func TestEngine_RapidDescentKeepsToOneSource(t *testing.T) {
+	var sent []events.Alert
+	engine := NewEngine(rules(t)[3:4], []Sink{collect(&sent)})
+
+	// Geometric altitude sits 1200 m above barometric; switching from one
+	// to the other is no descent.
+	geoOnly := event("8a0001", 1000)
+	geoOnly.GeoAltitude = ptr(9200.0)
+	baroOnly := event("8a0001", 1030)
+	baroOnly.BaroAltitude = ptr(8000.0)
+	require.NoError(t, engine.Evaluate([]events.TelemetryRawEvent{geoOnly, baroOnly}))
+	assert.Empty(t, sent)
+
+	high := event("8a0002", 1000)
+	high.BaroAltitude, high.GeoAltitude = ptr(8000.0), ptr(9200.0)
+
+	geo := Rule{Name: "geo-descent", Type: TypeRapidDescent, DropM: 1000, Within: time.Minute, Altitude: AltitudeGeo}
+	require.NoError(t, (&RuleSet{Rules: []Rule{geo}}).Validate())
+	engine = NewEngine([]Rule{geo}, []Sink{collect(&sent)})
+	lower := event("8a0002", 1030)
+	lower.BaroAltitude, lower.GeoAltitude = ptr(9100.0), ptr(8100.0)
+	require.NoError(t, engine.Evaluate([]events.TelemetryRawEvent{high, lower}))
+	assert.Equal(t, []string{"geo-descent:8a0002"}, names(sent))
+}
+
+func TestEngine_LostContact(t *testing.T) {
+	var sent []events.Alert
+	engine := NewEngine(rules(t), []Sink{collect(&sent)})
+
+	parked := event("8a0002", 1000)
+	parked.OnGround = true
+	require.NoError(t, engine.Evaluate([]events.TelemetryRawEvent{event("8a0001", 1000), parked}))
+
+	// Only the stream's clock counts: another aircraft moves it on.
+	require.NoError(t, engine.Evaluate([]events.TelemetryRawEvent{event("8a0003", 1060)}))
+	assert.Empty(t, sent)
+	require.NoError(t, engine.Evaluate([]events.TelemetryRawEvent{event("8a0003", 1130)}))
+	assert.Equal(t, []string{"lost:8a0001"}, names(sent))
+	assert.Equal(t, int64(1130), sent[0].Time)
+
+	// Reported once per silence.
+	require.NoError(t, engine.Evaluate([]events.TelemetryRawEvent{event("8a0003", 1200)}))
+	assert.Len(t, sent, 1)
+}
+
+func TestEngine_SinkFailureKeepsNothing(t *testing.T) {
+	sink := &MockSink{}
+	engine := NewEngine(rules(t), []Sink{sink})
+
+	spi := event("8a0001", 1000)
+	spi.Spi = true
+	sink.On("Send", mock.Anything).Return(errors.New("broker down")).Once()
+	assert.Error(t, engine.Evaluate([]events.TelemetryRawEvent{spi}))
+
+	sink.On("Send", mock.MatchedBy(func(alerts []events.Alert) bool {
+		return len(alerts) == 1 && alerts[0].Rule == "spi"
+	})).Return(nil).Once()
+	require.NoError(t, engine.Evaluate([]events.TelemetryRawEvent{spi}))
+	sink.AssertExpectations(t)
+}
+
+func TestWebhookSink(t *testing.T) {
+	var received []events.Alert
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL, 0)
+	alert := events.Alert{Rule: "emergency", Kind: TypeSquawk, Icao24: "8a0001", Time: 1000, Message: "squawk 7700"}
+	require.NoError(t, sink.Send([]events.Alert{alert}))
+	assert.Equal(t, []events.Alert{alert}, received)
+
+	status = http.StatusInternalServerError
+	assert.Error(t, sink.Send([]events.Alert{alert}))
+}
+
+func TestAsync(t *testing.T) {
+	release := make(chan struct{})
+	delivered := make(chan []events.Alert, 4)
+	sink := &MockSink{}
+	sink.On("Send", mock.Anything).Run(func(args mock.Arguments) {
+		<-release
+		delivered <- args.Get(0).([]events.Alert)
+	}).Return(errors.New("endpoint down"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	async := NewAsync(sink, 1)
+	go async.Run(ctx)
+
+	batch := func(rule string) []events.Alert { return []events.Alert{{Rule: rule}} }
+	require.NoError(t, async.Send(batch("first")))
+	// The first batch is being sent, the second waits and the third does not
+	// fit. None of them blocks the caller or fails it.
+	assert.Eventually(t, func() bool { return len(async.queue) == 0 }, time.Second, time.Millisecond)
+	require.NoError(t, async.Send(batch("second")))
+	dropped := counter("dropped")
+	require.NoError(t, async.Send(batch("third")))
+	assert.Equal(t, dropped+1, counter("dropped"))
+
+	close(release)
+	assert.Equal(t, batch("first"), <-delivered)
+	assert.Equal(t, batch("second"), <-delivered)
+}
+
+func counter(key string) int64 {
+	if v, ok := metrics.Get(key).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
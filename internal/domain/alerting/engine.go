@@ -0,0 +1,271 @@
+package alerting
+
+import (
+	"expvar"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+// Published through expvar under "alerts": alerts fired, alerts an Async
+// sink failed to deliver and alerts it dropped.
+var metrics = expvar.NewMap("alerts")
+
+// DefaultRetain is how long an aircraft's state is kept after its last
+// contact, unless a rule needs it longer.
+const DefaultRetain = time.Hour
+
+// Engine evaluates the rules against every event, in last contact order per
+// aircraft. Events not newer than the previous contact of their aircraft are
+// ignored, so a redelivered batch raises nothing twice. Time is the stream's
+// own: lost contact is measured against the newest contact of any aircraft,
+// so a stalled feed does not report every aircraft as lost.
+type Engine struct {
+	Rules []Rule
+	Sinks []Sink
+	// Retain bounds how long an aircraft is tracked after its last contact.
+	Retain time.Duration
+
+	mu       sync.Mutex
+	now      time.Time
+	aircraft map[string]*track
+}
+
+// track is what the engine remembers of one aircraft.
+type track struct {
+	event       events.TelemetryRawEvent
+	lastContact time.Time
+	// altitudes holds recent altitude samples for rapid_descent.
+	altitudes []sample
+	fired     map[string]time.Time
+	// lost holds the lost_contact rules already fired for the current
+	// silence.
+	lost map[string]bool
+}
+
+// sample holds the altitudes of one contact, each nil when not reported.
+type sample struct {
+	at   time.Time
+	baro *float64
+	geo  *float64
+}
+
+// altitude returns the altitude of the sample from source.
+func (s sample) altitude(source string) *float64 {
+	if source == AltitudeGeo {
+		return s.geo
+	}
+	return s.baro
+}
+
+func (t *track) clone() *track {
+	c := *t
+	c.altitudes = slices.Clone(t.altitudes)
+	c.fired = maps.Clone(t.fired)
+	c.lost = maps.Clone(t.lost)
+	return &c
+}
+
+func NewEngine(rules []Rule, sinks []Sink) *Engine {
+	retain := DefaultRetain
+	for _, r := range rules {
+		retain = max(retain, 2*r.After, 2*r.Within)
+	}
+	return &Engine{
+		Rules:    rules,
+		Sinks:    sinks,
+		Retain:   retain,
+		aircraft: make(map[string]*track),
+	}
+}
+
+// Evaluate runs the rules over a batch and sends the alerts to every sink.
+// Nothing is kept when a sink fails, so the batch can be retried.
+func (e *Engine) Evaluate(raw []events.TelemetryRawEvent) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	sorted := slices.Clone(raw)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].LastContact < sorted[j].LastContact })
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	next := make(map[string]*track)
+	now := e.now
+	var alerts []events.Alert
+	for _, ev := range sorted {
+		at := time.Unix(ev.LastContact, 0)
+		t, ok := next[ev.Icao24]
+		if !ok {
+			if prev, known := e.aircraft[ev.Icao24]; known {
+				t = prev.clone()
+			} else {
+				t = &track{fired: make(map[string]time.Time), lost: make(map[string]bool)}
+			}
+		}
+		if !t.lastContact.IsZero() && !at.After(t.lastContact) {
+			continue
+		}
+		next[ev.Icao24] = t
+		t.see(ev, at)
+		e.trim(t)
+		if at.After(now) {
+			now = at
+		}
+		for _, r := range e.Rules {
+			if msg, ok := r.match(t); ok {
+				alerts = appendAlert(alerts, t, r, at, msg)
+			}
+		}
+	}
+
+	// Lost contact is checked on every tracked aircraft once the batch has
+	// moved the clock.
+	for icao24, prev := range e.aircraft {
+		if _, ok := next[icao24]; ok {
+			continue
+		}
+		for _, r := range e.Rules {
+			if r.silent(prev, now) {
+				next[icao24] = prev.clone()
+				break
+			}
+		}
+	}
+	for _, t := range next {
+		for _, r := range e.Rules {
+			if !r.silent(t, now) {
+				continue
+			}
+			t.lost[r.Name] = true
+			alerts = appendAlert(alerts, t, r, now, fmt.Sprintf("no contact for %s", now.Sub(t.lastContact)))
+		}
+	}
+
+	for _, s := range e.Sinks {
+		if len(alerts) == 0 {
+			break
+		}
+		if err := s.Send(alerts); err != nil {
+			return err
+		}
+	}
+	metrics.Add("fired", int64(len(alerts)))
+
+	e.now = now
+	for icao24, t := range next {
+		e.aircraft[icao24] = t
+	}
+	for icao24, t := range e.aircraft {
+		if now.Sub(t.lastContact) > e.Retain {
+			delete(e.aircraft, icao24)
+		}
+	}
+	return nil
+}
+
+// silent reports whether r is a lost_contact rule that is due for t and
+// has not fired since its last contact. Aircraft last seen on the ground are
+// expected to go quiet and never count.
+func (r Rule) silent(t *track, now time.Time) bool {
+	return r.Type == TypeLostContact && !t.lost[r.Name] && !t.event.OnGround && now.Sub(t.lastContact) >= r.After
+}
+
+// see records a new contact: it ends any silence and keeps the altitude
+// samples rapid_descent rules look back on.
+func (t *track) see(ev events.TelemetryRawEvent, at time.Time) {
+	t.event = ev
+	t.lastContact = at
+	clear(t.lost)
+	if ev.BaroAltitude != nil || ev.GeoAltitude != nil {
+		t.altitudes = append(t.altitudes, sample{at: at, baro: ev.BaroAltitude, geo: ev.GeoAltitude})
+	}
+}
+
+// appendAlert adds an alert unless the rule fired for the aircraft within
+// its cooldown.
+func appendAlert(alerts []events.Alert, t *track, r Rule, at time.Time, msg string) []events.Alert {
+	if last, ok := t.fired[r.Name]; ok && at.Sub(last) < r.Cooldown {
+		return alerts
+	}
+	t.fired[r.Name] = at
+	return append(alerts, events.Alert{
+		Rule:     r.Name,
+		Kind:     r.Type,
+		Severity: r.Severity,
+		Icao24:   t.event.Icao24,
+		Callsign: t.event.Callsign,
+		Time:     at.Unix(),
+		Lat:      t.event.Lat,
+		Lon:      t.event.Lon,
+		Message:  msg,
+	})
+}
+
+// match checks the latest event of t against an event rule. lost_contact is
+// not an event rule and never matches here.
+func (r Rule) match(t *track) (string, bool) {
+	ev := t.event
+	switch r.Type {
+	case TypeSquawk:
+		if ev.Squawk != nil && slices.Contains(r.Squawks, *ev.Squawk) {
+			return fmt.Sprintf("squawk %s", *ev.Squawk), true
+		}
+	case TypeSPI:
+		if ev.Spi {
+			return "special position indicator set", true
+		}
+	case TypeVerticalRate:
+		if ev.VerticalRate == nil {
+			return "", false
+		}
+		rate := *ev.VerticalRate
+		if (r.Min != nil && rate < *r.Min) || (r.Max != nil && rate > *r.Max) {
+			return fmt.Sprintf("vertical rate %.1f m/s", rate), true
+		}
+	case TypeRapidDescent:
+		return r.descent(t)
+	}
+	return "", false
+}
+
+// descent compares the latest altitude from the rule's source with the
+// highest one from the same source in the last Within.
+func (r Rule) descent(t *track) (string, bool) {
+	latest := sample{at: t.lastContact, baro: t.event.BaroAltitude, geo: t.event.GeoAltitude}
+	current := latest.altitude(r.Altitude)
+	if current == nil {
+		return "", false
+	}
+	highest, highestAt := *current, latest.at
+	for _, s := range t.altitudes {
+		if alt := s.altitude(r.Altitude); alt != nil && latest.at.Sub(s.at) <= r.Within && *alt > highest {
+			highest, highestAt = *alt, s.at
+		}
+	}
+	if drop := highest - *current; drop >= r.DropM {
+		return fmt.Sprintf("descended %.0f m in %s", drop, latest.at.Sub(highestAt)), true
+	}
+	return "", false
+}
+
+// trim drops altitude samples older than any rapid_descent rule looks at.
+func (e *Engine) trim(t *track) {
+	var within time.Duration
+	for _, r := range e.Rules {
+		if r.Type == TypeRapidDescent {
+			within = max(within, r.Within)
+		}
+	}
+	keep := 0
+	for keep < len(t.altitudes) && t.lastContact.Sub(t.altitudes[keep].at) > within {
+		keep++
+	}
+	t.altitudes = t.altitudes[keep:]
+}
@@ -0,0 +1,183 @@
+// Package alerting evaluates a declarative rule set against the telemetry
+// stream and sends the matches to sinks.
+package alerting
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Rule types.
+const (
+	TypeSquawk       = "squawk"
+	TypeSPI          = "spi"
+	TypeVerticalRate = "vertical_rate"
+	TypeRapidDescent = "rapid_descent"
+	TypeLostContact  = "lost_contact"
+)
+
+// Severities of a rule; SeverityWarning is the default.
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Altitude sources of a rapid_descent rule; AltitudeBaro is the default.
+// Barometric and geometric altitude often differ by hundreds of meters, so
+// a rule only ever compares altitudes of one source.
+const (
+	AltitudeBaro = "baro"
+	AltitudeGeo  = "geo"
+)
+
+const DefaultCooldown = 5 * time.Minute
+
+// EmergencySquawks are matched by a squawk rule that lists none: hijack,
+// radio failure and general emergency.
+var EmergencySquawks = []string{"7500", "7600", "7700"}
+
+// RuleSet is the content of a rules file.
+type RuleSet struct {
+	Rules []Rule     `mapstructure:"rules"`
+	Sinks []SinkSpec `mapstructure:"sinks"`
+}
+
+// Rule is one alert condition. Which of the condition fields are used
+// depends on Type:
+//
+//	squawk         squawks (default EmergencySquawks)
+//	spi            none, matches when the special position indicator is set
+//	vertical_rate  min and/or max in m/s, matches outside of them
+//	rapid_descent  drop_m lost within the last within, in altitude
+//	               (default AltitudeBaro)
+//	lost_contact   after without a contact, for airborne aircraft
+//
+// An aircraft matching a rule again within Cooldown of its last alert for
+// that rule is not alerted again.
+type Rule struct {
+	Name     string        `mapstructure:"name"`
+	Type     string        `mapstructure:"type"`
+	Severity string        `mapstructure:"severity"`
+	Cooldown time.Duration `mapstructure:"cooldown"`
+
+	Squawks  []string      `mapstructure:"squawks"`
+	Min      *float64      `mapstructure:"min"`
+	Max      *float64      `mapstructure:"max"`
+	DropM    float64       `mapstructure:"drop_m"`
+	Within   time.Duration `mapstructure:"within"`
+	Altitude string        `mapstructure:"altitude"`
+	After    time.Duration `mapstructure:"after"`
+}
+
+// SinkSpec configures one sink: kafka (Topic defaults to the alerts topic),
+// webhook (URL, Timeout, Queue in batches) or log.
+type SinkSpec struct {
+	Type    string        `mapstructure:"type"`
+	Topic   string        `mapstructure:"topic"`
+	URL     string        `mapstructure:"url"`
+	Timeout time.Duration `mapstructure:"timeout"`
+	Queue   int           `mapstructure:"queue"`
+}
+
+// LoadRules reads and validates a YAML rules file and fills in defaults.
+func LoadRules(path string) (RuleSet, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("yaml")
+	if err := v.ReadInConfig(); err != nil {
+		return RuleSet{}, err
+	}
+	var rs RuleSet
+	if err := v.Unmarshal(&rs); err != nil {
+		return RuleSet{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := rs.Validate(); err != nil {
+		return RuleSet{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return rs, nil
+}
+
+// Validate checks every rule and sink and fills in their defaults.
+func (rs *RuleSet) Validate() error {
+	if len(rs.Rules) == 0 {
+		return errors.New("no rules defined")
+	}
+	names := make(map[string]bool)
+	for i := range rs.Rules {
+		r := &rs.Rules[i]
+		if r.Name == "" {
+			return fmt.Errorf("rule %d has no name", i)
+		}
+		if names[r.Name] {
+			return fmt.Errorf("rule %q is defined twice", r.Name)
+		}
+		names[r.Name] = true
+		if err := r.validate(); err != nil {
+			return fmt.Errorf("rule %q: %w", r.Name, err)
+		}
+	}
+	for i, s := range rs.Sinks {
+		switch s.Type {
+		case "kafka", "log":
+		case "webhook":
+			if s.URL == "" {
+				return fmt.Errorf("sink %d: webhook needs a url", i)
+			}
+		default:
+			return fmt.Errorf("sink %d: unknown type %q", i, s.Type)
+		}
+	}
+	return nil
+}
+
+func (r *Rule) validate() error {
+	if r.Severity == "" {
+		r.Severity = SeverityWarning
+	}
+	if !slices.Contains([]string{SeverityInfo, SeverityWarning, SeverityCritical}, r.Severity) {
+		return fmt.Errorf("unknown severity %q", r.Severity)
+	}
+	if r.Cooldown == 0 {
+		r.Cooldown = DefaultCooldown
+	}
+	if r.Cooldown < 0 {
+		return errors.New("cooldown must not be negative")
+	}
+
+	switch r.Type {
+	case TypeSquawk:
+		if len(r.Squawks) == 0 {
+			r.Squawks = EmergencySquawks
+		}
+	case TypeSPI:
+	case TypeVerticalRate:
+		if r.Min == nil && r.Max == nil {
+			return errors.New("vertical_rate needs min or max")
+		}
+		if r.Min != nil && r.Max != nil && *r.Min > *r.Max {
+			return errors.New("min must not exceed max")
+		}
+	case TypeRapidDescent:
+		if r.DropM <= 0 || r.Within <= 0 {
+			return errors.New("rapid_descent needs a positive drop_m and within")
+		}
+		if r.Altitude == "" {
+			r.Altitude = AltitudeBaro
+		}
+		if r.Altitude != AltitudeBaro && r.Altitude != AltitudeGeo {
+			return fmt.Errorf("unknown altitude %q", r.Altitude)
+		}
+	case TypeLostContact:
+		if r.After <= 0 {
+			return errors.New("lost_contact needs a positive after")
+		}
+	default:
+		return fmt.Errorf("unknown type %q", r.Type)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+const (
+	defaultWebhookTimeout = 5 * time.Second
+	// defaultQueue is the number of batches an Async sink holds.
+	defaultQueue = 100
+)
+
+// Sink receives every batch of alerts. An error fails the processed batch,
+// so it is retried and its alerts are sent again.
+type Sink interface {
+	Send(alerts []events.Alert) error
+}
+
+// Publisher writes alerts to a topic for KafkaSink.
+type Publisher interface {
+	PublishAlerts(alerts []events.Alert, topic events.Topic) error
+}
+
+// KafkaSink publishes alerts to a topic.
+type KafkaSink struct {
+	Publisher Publisher
+	Topic     events.Topic
+}
+
+func (s *KafkaSink) Send(alerts []events.Alert) error {
+	return s.Publisher.PublishAlerts(alerts, s.Topic)
+}
+
+// WebhookSink posts every batch as a JSON array and expects a 2xx answer.
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewWebhookSink(url string, timeout time.Duration) *WebhookSink {
+	if timeout == 0 {
+		timeout = defaultWebhookTimeout
+	}
+	return &WebhookSink{URL: url, Client: &http.Client{Timeout: timeout}}
+}
+
+func (s *WebhookSink) Send(alerts []events.Alert) error {
+	body, err := json.Marshal(alerts)
+	if err != nil {
+		return err
+	}
+	resp, err := s.Client.Post(s.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %s answered %s", s.URL, resp.Status)
+	}
+	return nil
+}
+
+// LogSink writes one log line per alert.
+type LogSink struct{}
+
+func (LogSink) Send(alerts []events.Alert) error {
+	for _, a := range alerts {
+		log.Printf("ALERT [%s] %s %s: %s", a.Severity, a.Rule, a.Icao24, a.Message)
+	}
+	return nil
+}
+
+// Async delivers through Sink from a queue of up to a fixed number of
+// batches, so a slow or unreachable endpoint cannot hold up the stream.
+// Failed deliveries are logged and counted; batches arriving while the queue
+// is full are dropped and counted.
+type Async struct {
+	Sink  Sink
+	queue chan []events.Alert
+}
+
+func NewAsync(sink Sink, size int) *Async {
+	if size <= 0 {
+		size = defaultQueue
+	}
+	return &Async{Sink: sink, queue: make(chan []events.Alert, size)}
+}
+
+// Send queues alerts for Run and never fails.
+func (s *Async) Send(alerts []events.Alert) error {
+	select {
+	case s.queue <- alerts:
+	default:
+		log.Printf("Alert queue full, dropping %d alerts", len(alerts))
+		metrics.Add("dropped", int64(len(alerts)))
+	}
+	return nil
+}
+
+// Run delivers queued batches until ctx is done.
+func (s *Async) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case alerts := <-s.queue:
+			if err := s.Sink.Send(alerts); err != nil {
+				log.Printf("Failed to deliver %d alerts: %v", len(alerts), err)
+				metrics.Add("sink_errors", int64(len(alerts)))
+			}
+		}
+	}
+}
+
+// NewSinks builds the sinks of a rule set, or a log sink when it names none.
+// Kafka is the durable sink; webhooks are sent asynchronously and must be
+// started with Run.
+func NewSinks(specs []SinkSpec, publisher Publisher, topic events.Topic) []Sink {
+	if len(specs) == 0 {
+		return []Sink{LogSink{}}
+	}
+	sinks := make([]Sink, 0, len(specs))
+	for _, spec := range specs {
+		switch spec.Type {
+		case "kafka":
+			t := topic
+			if spec.Topic != "" {
+				t = events.Topic(spec.Topic)
+			}
+			sinks = append(sinks, &KafkaSink{Publisher: publisher, Topic: t})
+		case "webhook":
+			sinks = append(sinks, NewAsync(NewWebhookSink(spec.URL, spec.Timeout), spec.Queue))
+		case "log":
+			sinks = append(sinks, LogSink{})
+		}
+	}
+	return sinks
+}
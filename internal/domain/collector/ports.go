@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/dandyZicky/opensky-collector/internal/domain/flight"
 	"github.com/dandyZicky/opensky-collector/internal/dto"
 	"github.com/dandyZicky/opensky-collector/pkg/events"
 )
@@ -16,6 +17,9 @@ type Message interface {
 
 type Producer interface {
 	Publish(event events.TelemetryRawEvent, topic events.Topic) error
+	// Flush waits for published events to be delivered and returns the
+	// delivery failures reported since the previous Flush.
+	Flush() error
 }
 
 type Collector interface {
@@ -24,5 +28,5 @@ type Collector interface {
 
 type Client interface {
 	Do(req *http.Request) (*http.Response, error)
-	GetAllStateVectors() (*dto.StatesResponse, error)
+	GetAllStateVectors(box *flight.BoundingBox) (*dto.StatesResponse, error)
 }
@@ -3,32 +3,66 @@ package collector
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"time"
 
+	"github.com/dandyZicky/opensky-collector/internal/domain/flight"
 	"github.com/dandyZicky/opensky-collector/pkg/events"
 	"github.com/dandyZicky/opensky-collector/pkg/retry"
 )
 
+// Region is a named area polled on every cycle. A nil Box covers the whole
+// globe.
+type Region struct {
+	Name string
+	Box  *flight.BoundingBox
+}
+
 type CollectorService struct {
 	Producer Producer
 	Client   Client
+	Regions  []Region
 }
 
-func (c *CollectorService) processAndPublish() error {
-	flights, err := c.Client.GetAllStateVectors()
+func (c *CollectorService) processAndPublish(region Region) error {
+	flights, err := c.Client.GetAllStateVectors(region.Box)
 	if err != nil {
 		return err
 	}
 
 	for _, state := range flights.States {
-		if c.Producer.Publish(events.StateVectorToTelemetryRawEvent(state), events.TelemetryRaw) != nil {
+		event := events.StateVectorToTelemetryRawEvent(state)
+		event.Region = region.Name
+		if c.Producer.Publish(event, events.TelemetryRaw) != nil {
 			log.Println("Problems publishing message")
 		}
 	}
 	return nil
 }
 
+// collect polls every region once. A failing region does not stop the
+// others; their errors are joined into the cycle error together with any
+// delivery failures of the events published in this cycle.
+func (c *CollectorService) collect() error {
+	var errs []error
+	for _, region := range c.Regions {
+		err := retry.Do(
+			func() error { return c.processAndPublish(region) },
+			retry.WithAttempts(3),
+			retry.WithBackoff(10*time.Second, 2.0),
+		)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("region %s: %w", region.Name, err))
+		}
+	}
+	if err := c.Producer.Flush(); err != nil {
+		errs = append(errs, fmt.Errorf("delivery: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
 func (c *CollectorService) Poll(ctx context.Context, interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
@@ -42,11 +76,7 @@ func (c *CollectorService) Poll(ctx context.Context, interval time.Duration) {
 			log.Println("Collector service shutting down.")
 			return
 		case <-ticker.C:
-			err := retry.Do(
-				c.processAndPublish,
-				retry.WithAttempts(3),
-				retry.WithBackoff(10*time.Second, 2.0),
-			)
+			err := c.collect()
 
 			if err != nil {
 				consecutiveFailures++
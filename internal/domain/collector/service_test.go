@@ -0,0 +1,127 @@
+package collector
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/flight"
+	"github.com/dandyZicky/opensky-collector/internal/dto"
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+type MockClient struct {
+	mock.Mock
+}
+
+func (m *MockClient) Do(req *http.Request) (*http.Response, error) {
+	args := m.Called(req)
+	return args.Get(0).(*http.Response), args.Error(1)
+}
+
+func (m *MockClient) GetAllStateVectors(box *flight.BoundingBox) (*dto.StatesResponse, error) {
+	args := m.Called(box)
+	return args.Get(0).(*dto.StatesResponse), args.Error(1)
+}
+
+type MockProducer struct {
+	mock.Mock
+}
+
+func (m *MockProducer) Publish(event events.TelemetryRawEvent, topic events.Topic) error {
+	args := m.Called(event, topic)
+	return args.Error(0)
+}
+
+func (m *MockProducer) Flush() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func TestCollectorService_Collect_TagsEventsWithRegion(t *testing.T) {
+	mockClient := &MockClient{}
+	mockProducer := &MockProducer{}
+
+	java := &flight.BoundingBox{LaMin: -9, LoMin: 105, LaMax: -5, LoMax: 115}
+	bali := &flight.BoundingBox{LaMin: -9, LoMin: 114, LaMax: -8, LoMax: 116}
+
+	service := &CollectorService{
+		Client:   mockClient,
+		Producer: mockProducer,
+		Regions: []Region{
+			{Name: "java", Box: java},
+			{Name: "bali", Box: bali},
+		},
+	}
+
+	mockClient.On("GetAllStateVectors", java).Return(&dto.StatesResponse{
+		States: []dto.State{{Icao24: "8a0001"}},
+	}, nil)
+	mockClient.On("GetAllStateVectors", bali).Return(&dto.StatesResponse{
+		States: []dto.State{{Icao24: "8a0002"}, {Icao24: "8a0003"}},
+	}, nil)
+	mockProducer.On("Publish", mock.MatchedBy(func(e events.TelemetryRawEvent) bool {
+		return e.Icao24 == "8a0001" && e.Region == "java"
+	}), events.TelemetryRaw).Return(nil).Once()
+	mockProducer.On("Publish", mock.MatchedBy(func(e events.TelemetryRawEvent) bool {
+		return e.Region == "bali"
+	}), events.TelemetryRaw).Return(nil).Twice()
+	mockProducer.On("Flush").Return(nil).Once()
+
+	err := service.collect()
+
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+	mockProducer.AssertExpectations(t)
+}
+
+func TestCollectorService_Collect_GlobalRegion(t *testing.T) {
+	mockClient := &MockClient{}
+	mockProducer := &MockProducer{}
+
+	service := &CollectorService{
+		Client:   mockClient,
+		Producer: mockProducer,
+		Regions:  []Region{{Name: "global"}},
+	}
+
+	mockClient.On("GetAllStateVectors", (*flight.BoundingBox)(nil)).Return(&dto.StatesResponse{
+		States: []dto.State{{Icao24: "3c6444"}},
+	}, nil)
+	mockProducer.On("Publish", mock.MatchedBy(func(e events.TelemetryRawEvent) bool {
+		return e.Icao24 == "3c6444" && e.Region == "global"
+	}), events.TelemetryRaw).Return(nil)
+	mockProducer.On("Flush").Return(nil)
+
+	err := service.collect()
+
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+	mockProducer.AssertExpectations(t)
+}
+
+func TestCollectorService_Collect_ReportsDeliveryFailures(t *testing.T) {
+	mockClient := &MockClient{}
+	mockProducer := &MockProducer{}
+
+	service := &CollectorService{
+		Client:   mockClient,
+		Producer: mockProducer,
+		Regions:  []Region{{Name: "global"}},
+	}
+
+	mockClient.On("GetAllStateVectors", (*flight.BoundingBox)(nil)).Return(&dto.StatesResponse{
+		States: []dto.State{{Icao24: "3c6444"}},
+	}, nil)
+	mockProducer.On("Publish", mock.Anything, events.TelemetryRaw).Return(nil)
+	deliveryErr := errors.New("1 deliveries failed")
+	mockProducer.On("Flush").Return(deliveryErr)
+
+	err := service.collect()
+
+	assert.ErrorIs(t, err, deliveryErr)
+	mockProducer.AssertExpectations(t)
+}
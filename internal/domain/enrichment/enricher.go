@@ -0,0 +1,165 @@
+// Package enrichment derives speed, altitude and movement data from raw
+// telemetry for the enriched topic.
+package enrichment
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/flight"
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+const (
+	knotsPerMeterPerSecond = 1.943844
+
+	// climbRate is the vertical rate in m/s, about 300 ft/min, beyond which
+	// an airborne aircraft counts as climbing or descending.
+	climbRate = 1.5
+)
+
+type fix struct {
+	lat, lon float64
+	at       int64
+}
+
+// Enricher derives values for each event. It remembers the last position of
+// every aircraft to measure the distance moved since; a position more than
+// MaxGap older than the next one is not measured against.
+type Enricher struct {
+	MaxGap time.Duration
+
+	mu    sync.Mutex
+	fixes map[string]fix
+}
+
+func NewEnricher(maxGap time.Duration) *Enricher {
+	return &Enricher{
+		MaxGap: maxGap,
+		fixes:  make(map[string]fix),
+	}
+}
+
+// Staged holds the fixes of an enriched batch until they are committed.
+type Staged map[string]fix
+
+// Enrich derives values for a batch in order. Events that are not newer than
+// the last position seen for their aircraft, such as a redelivered batch, get
+// no distance. The batch's positions are only remembered once the returned
+// fixes are passed to Commit, so a batch that fails to publish can be
+// enriched again.
+func (e *Enricher) Enrich(raw []events.TelemetryRawEvent) ([]events.TelemetryEnrichedEvent, Staged) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	staged := make(Staged)
+	enriched := make([]events.TelemetryEnrichedEvent, 0, len(raw))
+	for _, event := range raw {
+		level := flightLevel(event)
+		enriched = append(enriched, events.TelemetryEnrichedEvent{
+			TelemetryRawEvent: event,
+			GroundSpeedKt:     groundSpeedKt(event),
+			FlightLevel:       level,
+			AltitudeBand:      altitudeBand(event, level),
+			DistanceM:         e.distance(event, staged),
+			Phase:             phase(event),
+		})
+	}
+	return enriched, staged
+}
+
+// Commit remembers the fixes of a published batch and forgets those more than
+// MaxGap older than its newest one.
+func (e *Enricher) Commit(staged Staged) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var newest int64
+	for icao24, f := range staged {
+		if prev, ok := e.fixes[icao24]; !ok || f.at > prev.at {
+			e.fixes[icao24] = f
+		}
+		if f.at > newest {
+			newest = f.at
+		}
+	}
+	if len(staged) > 0 {
+		e.forgetBefore(newest - int64(e.MaxGap/time.Second))
+	}
+}
+
+// distance measures from the previous fix of the aircraft, staged or
+// committed, and stages the event as its latest fix.
+func (e *Enricher) distance(event events.TelemetryRawEvent, staged Staged) *float64 {
+	if event.Lat == nil || event.Lon == nil || event.TimePosition == nil {
+		return nil
+	}
+	at := *event.TimePosition
+	prev, ok := staged[event.Icao24]
+	if !ok {
+		prev, ok = e.fixes[event.Icao24]
+	}
+	if ok && at <= prev.at {
+		return nil
+	}
+	staged[event.Icao24] = fix{lat: *event.Lat, lon: *event.Lon, at: at}
+	if !ok || time.Duration(at-prev.at)*time.Second > e.MaxGap {
+		return nil
+	}
+	d := flight.Distance(prev.lat, prev.lon, *event.Lat, *event.Lon)
+	return &d
+}
+
+func (e *Enricher) forgetBefore(at int64) {
+	for icao24, f := range e.fixes {
+		if f.at < at {
+			delete(e.fixes, icao24)
+		}
+	}
+}
+
+func groundSpeedKt(event events.TelemetryRawEvent) *float64 {
+	if event.Velocity == nil {
+		return nil
+	}
+	kt := *event.Velocity * knotsPerMeterPerSecond
+	return &kt
+}
+
+// flightLevel is the barometric altitude in hundreds of feet.
+func flightLevel(event events.TelemetryRawEvent) *int {
+	if event.BaroAltitude == nil {
+		return nil
+	}
+	level := int(math.Round(*event.BaroAltitude * flight.FeetPerMeter / 100))
+	return &level
+}
+
+func altitudeBand(event events.TelemetryRawEvent, level *int) string {
+	switch {
+	case event.OnGround:
+		return events.BandGround
+	case level == nil:
+		return ""
+	case *level < 100:
+		return events.BandLow
+	case *level < 250:
+		return events.BandMedium
+	}
+	return events.BandHigh
+}
+
+func phase(event events.TelemetryRawEvent) string {
+	switch {
+	case event.OnGround:
+		return events.PhaseGround
+	case event.VerticalRate == nil:
+		return ""
+	case *event.VerticalRate > climbRate:
+		return events.PhaseClimb
+	case *event.VerticalRate < -climbRate:
+		return events.PhaseDescent
+	}
+	return events.PhaseCruise
+}
@@ -0,0 +1,123 @@
+package enrichment
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func fixAt(icao24 string, lat, lon float64, at int64) events.TelemetryRawEvent {
+	return events.TelemetryRawEvent{Icao24: icao24, Lat: ptr(lat), Lon: ptr(lon), TimePosition: ptr(at), LastContact: at}
+}
+
+// enrich enriches a batch as if it was published.
+func enrich(e *Enricher, raw []events.TelemetryRawEvent) []events.TelemetryEnrichedEvent {
+	enriched, staged := e.Enrich(raw)
+	e.Commit(staged)
+	return enriched
+}
+
+type MockPublisher struct {
+	mock.Mock
+}
+
+func (m *MockPublisher) PublishEnriched(enriched []events.TelemetryEnrichedEvent, topic events.Topic) error {
+	args := m.Called(enriched, topic)
+	return args.Error(0)
+}
+
+func TestEnricher_DerivedValues(t *testing.T) {
+	e := NewEnricher(10 * time.Minute)
+
+	enriched := enrich(e, []events.TelemetryRawEvent{
+		{Icao24: "8a0001", Velocity: ptr(231.5), BaroAltitude: ptr(10972.8), VerticalRate: ptr(0.3)},
+		{Icao24: "8a0002", Velocity: ptr(80.0), BaroAltitude: ptr(1524.0), VerticalRate: ptr(9.1)},
+		{Icao24: "8a0003", BaroAltitude: ptr(5486.4), VerticalRate: ptr(-7.6)},
+		{Icao24: "8a0004", Velocity: ptr(5.0), OnGround: true},
+		{Icao24: "8a0005"},
+	})
+	require.Len(t, enriched, 5)
+
+	assert.InDelta(t, 450.0, *enriched[0].GroundSpeedKt, 0.1)
+	assert.Equal(t, 360, *enriched[0].FlightLevel)
+	assert.Equal(t, events.BandHigh, enriched[0].AltitudeBand)
+	assert.Equal(t, events.PhaseCruise, enriched[0].Phase)
+
+	assert.Equal(t, 50, *enriched[1].FlightLevel)
+	assert.Equal(t, events.BandLow, enriched[1].AltitudeBand)
+	assert.Equal(t, events.PhaseClimb, enriched[1].Phase)
+
+	assert.Nil(t, enriched[2].GroundSpeedKt)
+	assert.Equal(t, events.BandMedium, enriched[2].AltitudeBand)
+	assert.Equal(t, events.PhaseDescent, enriched[2].Phase)
+
+	assert.Nil(t, enriched[3].FlightLevel)
+	assert.Equal(t, events.BandGround, enriched[3].AltitudeBand)
+	assert.Equal(t, events.PhaseGround, enriched[3].Phase)
+
+	assert.Empty(t, enriched[4].AltitudeBand)
+	assert.Empty(t, enriched[4].Phase)
+	assert.Equal(t, "8a0005", enriched[4].Icao24)
+}
+
+func TestEnricher_Distance(t *testing.T) {
+	e := NewEnricher(10 * time.Minute)
+
+	first := enrich(e, []events.TelemetryRawEvent{fixAt("8a0001", -6.0, 106.0, 1700000000)})
+	assert.Nil(t, first[0].DistanceM, "no previous fix")
+
+	// One hundredth of a degree of latitude is about 1112m.
+	next := enrich(e, []events.TelemetryRawEvent{
+		fixAt("8a0001", -5.99, 106.0, 1700000010),
+		fixAt("8a0001", -5.99, 106.0, 1700000010),
+	})
+	require.NotNil(t, next[0].DistanceM)
+	assert.InDelta(t, 1112.0, *next[0].DistanceM, 1)
+	assert.Nil(t, next[1].DistanceM, "not newer than the last fix")
+
+	late := enrich(e, []events.TelemetryRawEvent{fixAt("8a0001", -5.0, 106.0, 1700003600)})
+	assert.Nil(t, late[0].DistanceM, "previous fix is beyond the max gap")
+}
+
+func TestEnricher_ForgetsStaleFixes(t *testing.T) {
+	e := NewEnricher(time.Minute)
+
+	enrich(e, []events.TelemetryRawEvent{
+		fixAt("8a0001", -6.0, 106.0, 1700000000),
+		fixAt("8a0002", -6.0, 106.0, 1700000100),
+	})
+
+	assert.Len(t, e.fixes, 1)
+	assert.Contains(t, e.fixes, "8a0002")
+}
+
+func TestEnrichmentService_RetryKeepsDistance(t *testing.T) {
+	publisher := &MockPublisher{}
+	service := &EnrichmentService{Enricher: NewEnricher(10 * time.Minute), Publisher: publisher, Topic: events.TelemetryEnriched}
+	publisher.On("PublishEnriched", mock.Anything, events.TelemetryEnriched).Return(nil).Once()
+	require.NoError(t, service.Enrich([]events.TelemetryRawEvent{fixAt("8a0001", -6.0, 106.0, 1700000000)}))
+
+	var published []events.TelemetryEnrichedEvent
+	batch := []events.TelemetryRawEvent{fixAt("8a0001", -5.99, 106.0, 1700000010)}
+	publisher.On("PublishEnriched", mock.Anything, events.TelemetryEnriched).Return(errors.New("broker down")).Once()
+	assert.Error(t, service.Enrich(batch))
+	publisher.On("PublishEnriched", mock.Anything, events.TelemetryEnriched).Run(func(args mock.Arguments) {
+		published = args.Get(0).([]events.TelemetryEnrichedEvent)
+	}).Return(nil).Once()
+	require.NoError(t, service.Enrich(batch))
+
+	require.Len(t, published, 1)
+	require.NotNil(t, published[0].DistanceM, "the failed publish kept nothing")
+	assert.InDelta(t, 1112.0, *published[0].DistanceM, 1)
+	publisher.AssertExpectations(t)
+}
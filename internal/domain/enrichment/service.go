@@ -0,0 +1,29 @@
+package enrichment
+
+import "github.com/dandyZicky/opensky-collector/pkg/events"
+
+// Publisher writes enriched events to a topic. The service commits the
+// distance fixes only after it succeeds.
+type Publisher interface {
+	PublishEnriched(events []events.TelemetryEnrichedEvent, topic events.Topic) error
+}
+
+type EnrichmentService struct {
+	Enricher  *Enricher
+	Publisher Publisher
+	Topic     events.Topic
+}
+
+// Enrich derives values for a batch of raw events and publishes them. The
+// enricher only remembers the batch once it is published.
+func (s *EnrichmentService) Enrich(raw []events.TelemetryRawEvent) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	enriched, staged := s.Enricher.Enrich(raw)
+	if err := s.Publisher.PublishEnriched(enriched, s.Topic); err != nil {
+		return err
+	}
+	s.Enricher.Commit(staged)
+	return nil
+}
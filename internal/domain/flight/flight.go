@@ -0,0 +1,25 @@
+package flight
+
+import "time"
+
+// Flight is one continuous trip of an aircraft, reconstructed from its state
+// vectors. Positions are the first and last ones reported in the flight.
+type Flight struct {
+	ID        int64     `json:"id"`
+	Icao24    string    `json:"icao24"`
+	Callsign  *string   `json:"callsign"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	StartLat  float64   `json:"start_lat"`
+	StartLon  float64   `json:"start_lon"`
+	EndLat    float64   `json:"end_lat"`
+	EndLon    float64   `json:"end_lon"`
+	// MaxAltitude is the highest barometric, else geometric, altitude in
+	// meters, nil when none was reported.
+	MaxAltitude *float64 `json:"max_altitude"`
+	DistanceM   float64  `json:"distance_m"`
+	Points      int      `json:"points"`
+	// EndOnGround is the ground flag of the last point, so a later takeoff
+	// starts a new flight.
+	EndOnGround bool `json:"end_on_ground"`
+}
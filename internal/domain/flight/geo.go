@@ -0,0 +1,62 @@
+package flight
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// BoundingBox is a WGS84 latitude/longitude rectangle, matching the
+// lamin/lomin/lamax/lomax parameters of the OpenSky states API.
+type BoundingBox struct {
+	LaMin float64
+	LoMin float64
+	LaMax float64
+	LoMax float64
+}
+
+func (b BoundingBox) Contains(lat, lon float64) bool {
+	return lat >= b.LaMin && lat <= b.LaMax && lon >= b.LoMin && lon <= b.LoMax
+}
+
+// ParseBoundingBox reads "lamin,lomin,lamax,lomax", the order OpenSky uses.
+// An empty string means no box and returns nil.
+func ParseBoundingBox(s string) (*BoundingBox, error) {
+	if s == "" {
+		return nil, nil
+	}
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("bbox must be lamin,lomin,lamax,lomax")
+	}
+	var coords [4]float64
+	for i, part := range parts {
+		c, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("bbox: %q is not a number", part)
+		}
+		coords[i] = c
+	}
+	box := &BoundingBox{LaMin: coords[0], LoMin: coords[1], LaMax: coords[2], LoMax: coords[3]}
+	if box.LaMin > box.LaMax || box.LoMin > box.LoMax {
+		return nil, fmt.Errorf("bbox minimums must not exceed maximums")
+	}
+	return box, nil
+}
+
+// earthRadiusM is the mean Earth radius used for great-circle distances.
+const earthRadiusM = 6371008.8
+
+// Distance returns the great-circle distance in meters between two WGS84
+// positions, using the haversine formula.
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	dPhi := (lat2 - lat1) * math.Pi / 180
+	dLambda := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * earthRadiusM * math.Asin(math.Min(1, math.Sqrt(a)))
+}
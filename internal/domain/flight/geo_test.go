@@ -0,0 +1,38 @@
+package flight
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBoundingBox(t *testing.T) {
+	box, err := ParseBoundingBox("-9, 105,-5,115")
+	require.NoError(t, err)
+	assert.Equal(t, &BoundingBox{LaMin: -9, LoMin: 105, LaMax: -5, LoMax: 115}, box)
+	assert.True(t, box.Contains(-6.1, 106.6))
+	assert.True(t, box.Contains(-9, 115), "edges are inside")
+	assert.False(t, box.Contains(-6.1, 120))
+
+	box, err = ParseBoundingBox("")
+	require.NoError(t, err)
+	assert.Nil(t, box)
+
+	for _, s := range []string{"-9,105,-5", "-9,105,-5,east", "-5,105,-9,115", "-9,115,-5,105"} {
+		_, err := ParseBoundingBox(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestDistance(t *testing.T) {
+	assert.Zero(t, Distance(-6.1, 106.6, -6.1, 106.6))
+	// One degree of longitude on the equator.
+	assert.InDelta(t, 111195, Distance(0, 0, 0, 1), 1)
+	// Soekarno-Hatta to Ngurah Rai.
+	assert.InDelta(t, 983000, Distance(-6.1256, 106.6558, -8.7482, 115.1672), 1000)
+	assert.Equal(t, Distance(-6.1, 106.6, -8.7, 115.2), Distance(-8.7, 115.2, -6.1, 106.6))
+	// Antipodes are half the circumference apart.
+	assert.InDelta(t, earthRadiusM*math.Pi, Distance(0, 0, 0, 180), 1)
+}
@@ -1,33 +1,131 @@
 package flight
 
 import (
+	"sort"
 	"time"
 
 	"github.com/dandyZicky/opensky-collector/pkg/events"
 )
 
+// FlightState is the domain view of a state vector. Nil pointer fields mean
+// the value was not reported.
 type FlightState struct {
-	Icao24        string
-	OriginCountry string
-	Lat           float64
-	Lon           float64
-	Velocity      float64
-	TimePosition  time.Time
-	BaroAltitude  float64
-	GeoAltitude   float64
-	LastContact   time.Time
+	Icao24         string     `json:"icao24"`
+	Callsign       *string    `json:"callsign"`
+	OriginCountry  string     `json:"origin_country"`
+	Lat            *float64   `json:"lat"`
+	Lon            *float64   `json:"lon"`
+	Velocity       *float64   `json:"velocity"`
+	TimePosition   *time.Time `json:"time_position"`
+	BaroAltitude   *float64   `json:"baro_altitude"`
+	GeoAltitude    *float64   `json:"geo_altitude"`
+	LastContact    time.Time  `json:"last_contact"`
+	OnGround       bool       `json:"on_ground"`
+	TrueTrack      *float64   `json:"true_track"`
+	VerticalRate   *float64   `json:"vertical_rate"`
+	Sensors        []int      `json:"sensors"`
+	Squawk         *string    `json:"squawk"`
+	Spi            bool       `json:"spi"`
+	PositionSource int        `json:"position_source"`
+	Category       int        `json:"category"`
+	Region         string     `json:"region,omitempty"`
+	// Aircraft is registry metadata, when the processor knows the
+	// aircraft. It is not stored with the state vector.
+	Aircraft *events.Aircraft `json:"aircraft,omitempty"`
 }
 
 func EventToFlightState(event events.TelemetryRawEvent) FlightState {
 	return FlightState{
-		Icao24:        event.Icao24,
-		OriginCountry: event.OriginCountry,
-		Lat:           event.Lat,
-		Lon:           event.Lon,
-		Velocity:      event.Velocity,
-		TimePosition:  time.Unix(event.TimePosition, 0),
-		BaroAltitude:  event.BaroAltitude,
-		GeoAltitude:   event.GeoAltitude,
-		LastContact:   time.Unix(event.LastContact, 0),
+		Icao24:         event.Icao24,
+		Callsign:       event.Callsign,
+		OriginCountry:  event.OriginCountry,
+		Lat:            event.Lat,
+		Lon:            event.Lon,
+		Velocity:       event.Velocity,
+		TimePosition:   unixTime(event.TimePosition),
+		BaroAltitude:   event.BaroAltitude,
+		GeoAltitude:    event.GeoAltitude,
+		LastContact:    time.Unix(event.LastContact, 0),
+		OnGround:       event.OnGround,
+		TrueTrack:      event.TrueTrack,
+		VerticalRate:   event.VerticalRate,
+		Sensors:        event.Sensors,
+		Squawk:         event.Squawk,
+		Spi:            event.Spi,
+		PositionSource: event.PositionSource,
+		Category:       event.Category,
+		Region:         event.Region,
+		Aircraft:       event.Aircraft,
 	}
 }
+
+// FlightStateToEvent is the inverse of EventToFlightState.
+func FlightStateToEvent(state FlightState) events.TelemetryRawEvent {
+	return events.TelemetryRawEvent{
+		Icao24:         state.Icao24,
+		Callsign:       state.Callsign,
+		OriginCountry:  state.OriginCountry,
+		Lat:            state.Lat,
+		Lon:            state.Lon,
+		Velocity:       state.Velocity,
+		TimePosition:   unixSeconds(state.TimePosition),
+		BaroAltitude:   state.BaroAltitude,
+		GeoAltitude:    state.GeoAltitude,
+		LastContact:    state.LastContact.Unix(),
+		OnGround:       state.OnGround,
+		TrueTrack:      state.TrueTrack,
+		VerticalRate:   state.VerticalRate,
+		Sensors:        state.Sensors,
+		Squawk:         state.Squawk,
+		Spi:            state.Spi,
+		PositionSource: state.PositionSource,
+		Category:       state.Category,
+		Region:         state.Region,
+		Aircraft:       state.Aircraft,
+	}
+}
+
+// Positioned returns the states that have a position and a position time,
+// ordered by that time. Stages that follow a track use it to skip states
+// that cannot be placed on it.
+func Positioned(states []FlightState) []FlightState {
+	points := make([]FlightState, 0, len(states))
+	for _, st := range states {
+		if st.TimePosition != nil && st.Lat != nil && st.Lon != nil {
+			points = append(points, st)
+		}
+	}
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].TimePosition.Before(*points[j].TimePosition)
+	})
+	return points
+}
+
+// FeetPerMeter converts the altitudes in meters of a state vector to the
+// feet aviation uses.
+const FeetPerMeter = 3.280840
+
+// Altitude returns the barometric altitude, else the geometric one, or nil
+// when neither was reported.
+func Altitude(baro, geo *float64) *float64 {
+	if baro != nil {
+		return baro
+	}
+	return geo
+}
+
+func unixTime(sec *int64) *time.Time {
+	if sec == nil {
+		return nil
+	}
+	t := time.Unix(*sec, 0)
+	return &t
+}
+
+func unixSeconds(t *time.Time) *int64 {
+	if t == nil {
+		return nil
+	}
+	sec := t.Unix()
+	return &sec
+}
@@ -0,0 +1,37 @@
+package flight
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestAltitude(t *testing.T) {
+	baro, geo := ptr(10000.0), ptr(10050.0)
+	assert.Equal(t, baro, Altitude(baro, geo))
+	assert.Equal(t, geo, Altitude(nil, geo))
+	assert.Nil(t, Altitude(nil, nil))
+}
+
+func TestPositioned(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration, lat, lon *float64) FlightState {
+		return FlightState{Icao24: "8a0001", TimePosition: ptr(base.Add(d)), Lat: lat, Lon: lon}
+	}
+	late := at(2*time.Minute, ptr(-6.2), ptr(106.8))
+	early := at(0, ptr(-6.0), ptr(106.5))
+	tied := at(0, ptr(-6.05), ptr(106.55))
+	noFix := FlightState{Icao24: "8a0001", Lat: ptr(-6.1), Lon: ptr(106.6)}
+	noLon := at(time.Minute, ptr(-6.1), nil)
+
+	states := []FlightState{late, noFix, early, noLon, tied}
+	assert.Equal(t, []FlightState{early, tied, late}, Positioned(states), "ordered by time, ties kept in order")
+	assert.Len(t, states, 5, "the input is not modified")
+	assert.Equal(t, late, states[0])
+	assert.Empty(t, Positioned(nil))
+}
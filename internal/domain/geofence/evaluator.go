@@ -0,0 +1,184 @@
+package geofence
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/flight"
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+const DefaultMaxAge = 15 * time.Minute
+
+// Evaluator tracks which active fences every aircraft is inside and turns
+// changes into enter and exit events. An aircraft first seen inside a fence
+// enters it. An aircraft not seen for MaxAge is forgotten without an exit,
+// and so is its presence in a fence that is deleted or deactivated.
+type Evaluator struct {
+	Fences    Repository
+	Publisher EventPublisher
+	Topic     events.Topic
+	// Live, when set, receives every event once it is published.
+	Live Broadcaster
+
+	MaxAge time.Duration
+
+	mu     sync.Mutex
+	active []shaped
+	inside map[string]presence
+}
+
+type shaped struct {
+	fence Fence
+	shape Shape
+}
+
+// presence is the last evaluated state of an aircraft: its time and the
+// fences it was inside.
+type presence struct {
+	at     time.Time
+	fences map[int64]bool
+}
+
+func NewEvaluator(fences Repository, publisher EventPublisher, topic events.Topic) *Evaluator {
+	return &Evaluator{
+		Fences:    fences,
+		Publisher: publisher,
+		Topic:     topic,
+		MaxAge:    DefaultMaxAge,
+		inside:    make(map[string]presence),
+	}
+}
+
+// Reload replaces the evaluated fences with the active ones in Fences. A
+// stored fence whose geometry no longer parses is skipped.
+func (e *Evaluator) Reload() error {
+	fences, err := e.Fences.Active()
+	if err != nil {
+		return err
+	}
+	active := make([]shaped, 0, len(fences))
+	ids := make(map[int64]bool, len(fences))
+	for _, f := range fences {
+		shape, err := ParseGeoJSON(f.Geometry)
+		if err != nil {
+			log.Printf("Skipping geofence %d %q: %v", f.ID, f.Name, err)
+			continue
+		}
+		active = append(active, shaped{fence: f, shape: shape})
+		ids[f.ID] = true
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].fence.ID < active[j].fence.ID })
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.active = active
+	for _, p := range e.inside {
+		for id := range p.fences {
+			if !ids[id] {
+				delete(p.fences, id)
+			}
+		}
+	}
+	return nil
+}
+
+// Run reloads the fences every interval until ctx is done. A failed reload
+// keeps the previous fences.
+func (e *Evaluator) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := e.Reload(); err != nil {
+				log.Printf("Failed to reload geofences: %v", err)
+			}
+		}
+	}
+}
+
+// Evaluate checks a batch of states against the active fences and publishes
+// the resulting events. States not newer than the previous one of their
+// aircraft are ignored. Nothing is kept when publishing fails, so the batch
+// can be retried.
+func (e *Evaluator) Evaluate(states []flight.FlightState) error {
+	points := flight.Positioned(states)
+	if len(points) == 0 {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	next := make(map[string]presence)
+	var detected []events.GeofenceEvent
+	for _, p := range points {
+		prev, ok := next[p.Icao24]
+		if !ok {
+			prev, ok = e.inside[p.Icao24]
+		}
+		if ok && !p.TimePosition.After(prev.at) {
+			continue
+		}
+
+		current := presence{at: *p.TimePosition, fences: make(map[int64]bool)}
+		for _, a := range e.active {
+			in := a.shape.Contains(*p.Lat, *p.Lon)
+			if in {
+				current.fences[a.fence.ID] = true
+			}
+			if in != prev.fences[a.fence.ID] {
+				detected = append(detected, event(p, a.fence, in))
+			}
+		}
+		next[p.Icao24] = current
+	}
+
+	if len(detected) > 0 {
+		if err := e.Publisher.PublishGeofenceEvents(detected, e.Topic); err != nil {
+			return err
+		}
+		if e.Live != nil {
+			if err := e.Live.BroadcastGeofenceEvents(detected); err != nil {
+				log.Printf("Failed to broadcast %d geofence events: %v", len(detected), err)
+			}
+		}
+	}
+
+	var newest time.Time
+	for icao24, p := range next {
+		e.inside[icao24] = p
+		if p.at.After(newest) {
+			newest = p.at
+		}
+	}
+	for icao24, p := range e.inside {
+		if p.at.Before(newest.Add(-e.MaxAge)) {
+			delete(e.inside, icao24)
+		}
+	}
+	return nil
+}
+
+func event(p flight.FlightState, f Fence, entered bool) events.GeofenceEvent {
+	kind := events.GeofenceExit
+	if entered {
+		kind = events.GeofenceEnter
+	}
+	return events.GeofenceEvent{
+		Type:      kind,
+		Icao24:    p.Icao24,
+		Callsign:  p.Callsign,
+		FenceID:   f.ID,
+		FenceName: f.Name,
+		Time:      p.TimePosition.Unix(),
+		Lat:       *p.Lat,
+		Lon:       *p.Lon,
+	}
+}
@@ -0,0 +1,195 @@
+package geofence
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/flight"
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+// A square around Jakarta with a square hole over Halim.
+const jakarta = `{"type":"Polygon","coordinates":[
+	[[106.5,-6.5],[107.0,-6.5],[107.0,-6.0],[106.5,-6.0],[106.5,-6.5]],
+	[[106.85,-6.3],[106.95,-6.3],[106.95,-6.2],[106.85,-6.2],[106.85,-6.3]]
+]}`
+
+const bali = `{"type":"Feature","properties":{},"geometry":{"type":"MultiPolygon","coordinates":[
+	[[[115.0,-9.0],[115.5,-9.0],[115.5,-8.5],[115.0,-8.5],[115.0,-9.0]]]
+]}}`
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) List(limit, offset int) ([]Fence, error) {
+	args := m.Called(limit, offset)
+	return args.Get(0).([]Fence), args.Error(1)
+}
+
+func (m *MockRepository) Active() ([]Fence, error) {
+	args := m.Called()
+	return args.Get(0).([]Fence), args.Error(1)
+}
+
+func (m *MockRepository) Get(id int64) (Fence, error) {
+	args := m.Called(id)
+	return args.Get(0).(Fence), args.Error(1)
+}
+
+func (m *MockRepository) Create(f Fence) (Fence, error) {
+	args := m.Called(f)
+	return args.Get(0).(Fence), args.Error(1)
+}
+
+func (m *MockRepository) Update(f Fence) (Fence, error) {
+	args := m.Called(f)
+	return args.Get(0).(Fence), args.Error(1)
+}
+
+func (m *MockRepository) Delete(id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+type MockEventPublisher struct {
+	mock.Mock
+}
+
+func (m *MockEventPublisher) PublishGeofenceEvents(geofenceEvents []events.GeofenceEvent, topic events.Topic) error {
+	args := m.Called(geofenceEvents, topic)
+	return args.Error(0)
+}
+
+type MockBroadcaster struct {
+	mock.Mock
+}
+
+func (m *MockBroadcaster) BroadcastGeofenceEvents(geofenceEvents []events.GeofenceEvent) error {
+	args := m.Called(geofenceEvents)
+	return args.Error(0)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func state(icao24 string, at time.Time, lat, lon float64) flight.FlightState {
+	return flight.FlightState{Icao24: icao24, TimePosition: ptr(at), Lat: ptr(lat), Lon: ptr(lon)}
+}
+
+func TestParseGeoJSON(t *testing.T) {
+	shape, err := ParseGeoJSON([]byte(jakarta))
+	require.NoError(t, err)
+	assert.True(t, shape.Contains(-6.12, 106.65))
+	assert.False(t, shape.Contains(-6.25, 106.9), "inside the hole")
+	assert.False(t, shape.Contains(-6.12, 107.2))
+	assert.False(t, shape.Contains(-7.0, 106.7))
+
+	shape, err = ParseGeoJSON([]byte(bali))
+	require.NoError(t, err)
+	assert.True(t, shape.Contains(-8.75, 115.17))
+
+	for name, geometry := range map[string]string{
+		"not json":   `{`,
+		"point":      `{"type":"Point","coordinates":[106.6,-6.1]}`,
+		"open ring":  `{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,1]]]}`,
+		"short ring": `{"type":"Polygon","coordinates":[[[0,0],[1,0],[0,0]]]}`,
+		"no rings":   `{"type":"Polygon","coordinates":[]}`,
+		"range":      `{"type":"Polygon","coordinates":[[[0,0],[200,0],[1,1],[0,0]]]}`,
+		"no feature": `{"type":"Feature","geometry":null}`,
+	} {
+		_, err := ParseGeoJSON([]byte(geometry))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestFence_Validate(t *testing.T) {
+	assert.NoError(t, Fence{Name: "Jakarta", Geometry: []byte(jakarta)}.Validate())
+	assert.Error(t, Fence{Name: " ", Geometry: []byte(jakarta)}.Validate())
+	assert.Error(t, Fence{Name: "Jakarta"}.Validate())
+}
+
+func newTestEvaluator(t *testing.T) (*Evaluator, *MockEventPublisher, *MockBroadcaster) {
+	repo := &MockRepository{}
+	repo.On("Active").Return([]Fence{
+		{ID: 2, Name: "Bali", Active: true, Geometry: []byte(bali)},
+		{ID: 1, Name: "Jakarta", Active: true, Geometry: []byte(jakarta)},
+		{ID: 3, Name: "Broken", Active: true, Geometry: []byte(`{}`)},
+	}, nil).Once()
+
+	publisher := &MockEventPublisher{}
+	live := &MockBroadcaster{}
+	e := NewEvaluator(repo, publisher, "geofence.events")
+	e.Live = live
+	require.NoError(t, e.Reload())
+	return e, publisher, live
+}
+
+func TestEvaluator_EnterAndExit(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	e, publisher, live := newTestEvaluator(t)
+
+	enter := events.GeofenceEvent{Type: events.GeofenceEnter, Icao24: "8a0001", FenceID: 1, FenceName: "Jakarta", Time: base.Add(time.Minute).Unix(), Lat: -6.3, Lon: 106.6}
+	publisher.On("PublishGeofenceEvents", []events.GeofenceEvent{enter}, events.Topic("geofence.events")).Return(nil).Once()
+	live.On("BroadcastGeofenceEvents", []events.GeofenceEvent{enter}).Return(nil).Once()
+
+	require.NoError(t, e.Evaluate([]flight.FlightState{
+		state("8a0001", base.Add(time.Minute), -6.3, 106.6),
+		state("8a0001", base, -6.7, 106.6),
+		state("8a0001", base.Add(2*time.Minute), -6.2, 106.6),
+	}))
+
+	// A redelivered batch changes nothing.
+	require.NoError(t, e.Evaluate([]flight.FlightState{state("8a0001", base.Add(time.Minute), -6.3, 106.6)}))
+
+	exit := events.GeofenceEvent{Type: events.GeofenceExit, Icao24: "8a0001", FenceID: 1, FenceName: "Jakarta", Time: base.Add(3 * time.Minute).Unix(), Lat: -6.25, Lon: 106.9}
+	publisher.On("PublishGeofenceEvents", []events.GeofenceEvent{exit}, events.Topic("geofence.events")).Return(nil).Once()
+	live.On("BroadcastGeofenceEvents", []events.GeofenceEvent{exit}).Return(nil).Once()
+
+	require.NoError(t, e.Evaluate([]flight.FlightState{state("8a0001", base.Add(3*time.Minute), -6.25, 106.9)}))
+
+	publisher.AssertExpectations(t)
+	live.AssertExpectations(t)
+}
+
+func TestEvaluator_PublishFailureKeepsNothing(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	e, publisher, live := newTestEvaluator(t)
+
+	batch := []flight.FlightState{state("8a0002", base, -8.75, 115.17)}
+	publisher.On("PublishGeofenceEvents", mock.Anything, events.Topic("geofence.events")).Return(errors.New("broker down")).Once()
+	assert.Error(t, e.Evaluate(batch))
+	live.AssertNotCalled(t, "BroadcastGeofenceEvents", mock.Anything)
+
+	publisher.On("PublishGeofenceEvents", mock.MatchedBy(func(evs []events.GeofenceEvent) bool {
+		return len(evs) == 1 && evs[0].Type == events.GeofenceEnter && evs[0].FenceID == 2
+	}), events.Topic("geofence.events")).Return(nil).Once()
+	live.On("BroadcastGeofenceEvents", mock.Anything).Return(nil).Once()
+	require.NoError(t, e.Evaluate(batch))
+
+	publisher.AssertExpectations(t)
+}
+
+func TestEvaluator_ReloadForgetsRemovedFences(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	e, publisher, live := newTestEvaluator(t)
+
+	publisher.On("PublishGeofenceEvents", mock.Anything, mock.Anything).Return(nil).Once()
+	live.On("BroadcastGeofenceEvents", mock.Anything).Return(nil).Once()
+	require.NoError(t, e.Evaluate([]flight.FlightState{state("8a0001", base, -6.12, 106.65)}))
+
+	repo := &MockRepository{}
+	repo.On("Active").Return([]Fence{}, nil).Once()
+	e.Fences = repo
+	require.NoError(t, e.Reload())
+
+	// Leaving a fence that was removed is not reported.
+	require.NoError(t, e.Evaluate([]flight.FlightState{state("8a0001", base.Add(time.Minute), -7.0, 106.65)}))
+	publisher.AssertExpectations(t)
+}
@@ -0,0 +1,127 @@
+package geofence
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Shape is the area of a fence: one or more polygons, each an exterior ring
+// followed by its holes. Positions are [lon, lat] as in GeoJSON. Polygons
+// crossing the antimeridian are not supported.
+type Shape struct {
+	Polygons [][][][2]float64
+
+	minLat, minLon, maxLat, maxLon float64
+}
+
+type geoJSON struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+	Geometry    *geoJSON        `json:"geometry"`
+}
+
+// ParseGeoJSON reads a Polygon or MultiPolygon geometry, or a Feature
+// holding one. Every ring must be closed and have at least four positions.
+func ParseGeoJSON(data []byte) (Shape, error) {
+	var g geoJSON
+	if err := json.Unmarshal(data, &g); err != nil {
+		return Shape{}, fmt.Errorf("geometry is not valid GeoJSON: %w", err)
+	}
+	if g.Type == "Feature" {
+		if g.Geometry == nil {
+			return Shape{}, errors.New("feature has no geometry")
+		}
+		g = *g.Geometry
+	}
+
+	var polygons [][][][2]float64
+	switch g.Type {
+	case "Polygon":
+		var polygon [][][2]float64
+		if err := json.Unmarshal(g.Coordinates, &polygon); err != nil {
+			return Shape{}, fmt.Errorf("polygon coordinates: %w", err)
+		}
+		polygons = [][][][2]float64{polygon}
+	case "MultiPolygon":
+		if err := json.Unmarshal(g.Coordinates, &polygons); err != nil {
+			return Shape{}, fmt.Errorf("multipolygon coordinates: %w", err)
+		}
+	default:
+		return Shape{}, fmt.Errorf("geometry type must be Polygon or MultiPolygon, got %q", g.Type)
+	}
+	return NewShape(polygons)
+}
+
+// NewShape validates polygons and computes their bounding box.
+func NewShape(polygons [][][][2]float64) (Shape, error) {
+	if len(polygons) == 0 {
+		return Shape{}, errors.New("geometry has no polygons")
+	}
+	s := Shape{
+		Polygons: polygons,
+		minLat:   math.Inf(1), minLon: math.Inf(1),
+		maxLat: math.Inf(-1), maxLon: math.Inf(-1),
+	}
+	for i, polygon := range polygons {
+		if len(polygon) == 0 {
+			return Shape{}, fmt.Errorf("polygon %d has no rings", i)
+		}
+		for j, ring := range polygon {
+			if len(ring) < 4 {
+				return Shape{}, fmt.Errorf("polygon %d ring %d needs at least 4 positions", i, j)
+			}
+			if ring[0] != ring[len(ring)-1] {
+				return Shape{}, fmt.Errorf("polygon %d ring %d is not closed", i, j)
+			}
+			for _, p := range ring {
+				lon, lat := p[0], p[1]
+				if lon < -180 || lon > 180 || lat < -90 || lat > 90 {
+					return Shape{}, fmt.Errorf("polygon %d ring %d: position %v out of range", i, j, p)
+				}
+				s.minLat, s.maxLat = math.Min(s.minLat, lat), math.Max(s.maxLat, lat)
+				s.minLon, s.maxLon = math.Min(s.minLon, lon), math.Max(s.maxLon, lon)
+			}
+		}
+	}
+	return s, nil
+}
+
+// Contains reports whether a position lies inside one of the polygons and
+// outside its holes.
+func (s Shape) Contains(lat, lon float64) bool {
+	if lat < s.minLat || lat > s.maxLat || lon < s.minLon || lon > s.maxLon {
+		return false
+	}
+	for _, polygon := range s.Polygons {
+		if !inRing(polygon[0], lat, lon) {
+			continue
+		}
+		inHole := false
+		for _, hole := range polygon[1:] {
+			if inRing(hole, lat, lon) {
+				inHole = true
+				break
+			}
+		}
+		if !inHole {
+			return true
+		}
+	}
+	return false
+}
+
+// inRing casts a ray from the position towards increasing longitude and
+// counts the edges it crosses.
+func inRing(ring [][2]float64, lat, lon float64) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		loI, laI := ring[i][0], ring[i][1]
+		loJ, laJ := ring[j][0], ring[j][1]
+		if (laI > lat) != (laJ > lat) && lon < (loJ-loI)*(lat-laI)/(laJ-laI)+loI {
+			inside = !inside
+		}
+	}
+	return inside
+}
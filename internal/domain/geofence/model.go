@@ -0,0 +1,68 @@
+// Package geofence keeps polygonal airspaces and reports aircraft entering
+// and leaving them.
+package geofence
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+// ErrNotFound is returned by Repository lookups that match no fence.
+var ErrNotFound = errors.New("geofence not found")
+
+// Fence is a named area. Geometry is the GeoJSON it was defined with, kept
+// as given. Only active fences are evaluated.
+type Fence struct {
+	ID        int64           `json:"id"`
+	Name      string          `json:"name"`
+	Active    bool            `json:"active"`
+	Geometry  json.RawMessage `json:"geometry"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// Validate checks that a fence has a name and a usable geometry.
+func (f Fence) Validate() error {
+	if strings.TrimSpace(f.Name) == "" {
+		return errors.New("name is required")
+	}
+	if len(f.Geometry) == 0 {
+		return errors.New("geometry is required")
+	}
+	_, err := ParseGeoJSON(f.Geometry)
+	return err
+}
+
+// Repository stores the fences.
+type Repository interface {
+	// List returns fences ordered by ID, skipping offset and returning at
+	// most limit.
+	List(limit, offset int) ([]Fence, error)
+	// Active returns every active fence.
+	Active() ([]Fence, error)
+	// Get returns one fence, or ErrNotFound.
+	Get(id int64) (Fence, error)
+	// Create stores a new fence and returns it with its ID.
+	Create(f Fence) (Fence, error)
+	// Update replaces the name, state and geometry of a fence, or returns
+	// ErrNotFound.
+	Update(f Fence) (Fence, error)
+	// Delete removes a fence, or returns ErrNotFound.
+	Delete(id int64) error
+}
+
+// EventPublisher writes geofence entries and exits to a topic. The Evaluator
+// keeps which fences an aircraft is inside only after it succeeds, so a
+// failure detects the same transitions again on retry.
+type EventPublisher interface {
+	PublishGeofenceEvents(geofenceEvents []events.GeofenceEvent, topic events.Topic) error
+}
+
+// Broadcaster pushes geofence events to live clients.
+type Broadcaster interface {
+	BroadcastGeofenceEvents(geofenceEvents []events.GeofenceEvent) error
+}
@@ -0,0 +1,99 @@
+// Package live keeps the last known state of every aircraft currently
+// reporting, so new clients can be served a full picture without waiting for
+// the next collector cycle.
+package live
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+// Store maps icao24 to the most recent state seen for it. A state expires
+// once its LastContact is older than TTL.
+type Store struct {
+	TTL time.Duration
+
+	mu     sync.RWMutex
+	states map[string]events.TelemetryRawEvent
+	now    func() time.Time
+}
+
+func NewStore(ttl time.Duration) *Store {
+	return &Store{
+		TTL:    ttl,
+		states: make(map[string]events.TelemetryRawEvent),
+		now:    time.Now,
+	}
+}
+
+// Update records the events. An event older than the state already held for
+// its aircraft, e.g. from a redelivered batch, is ignored.
+func (s *Store) Update(evs []events.TelemetryRawEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, ev := range evs {
+		if current, ok := s.states[ev.Icao24]; ok && current.LastContact > ev.LastContact {
+			continue
+		}
+		s.states[ev.Icao24] = ev
+	}
+}
+
+// Snapshot returns every unexpired state, ordered by icao24.
+func (s *Store) Snapshot() []events.TelemetryRawEvent {
+	cutoff := s.cutoff()
+
+	s.mu.RLock()
+	snapshot := make([]events.TelemetryRawEvent, 0, len(s.states))
+	for _, ev := range s.states {
+		if ev.LastContact >= cutoff {
+			snapshot = append(snapshot, ev)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(snapshot, func(i, j int) bool { return snapshot[i].Icao24 < snapshot[j].Icao24 })
+	return snapshot
+}
+
+// Expire drops expired states and returns how many were dropped. Snapshot
+// already hides them; this only reclaims memory.
+func (s *Store) Expire() int {
+	cutoff := s.cutoff()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expired := 0
+	for icao24, ev := range s.states {
+		if ev.LastContact < cutoff {
+			delete(s.states, icao24)
+			expired++
+		}
+	}
+	return expired
+}
+
+// Run calls Expire every interval until ctx is done.
+func (s *Store) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Expire()
+		}
+	}
+}
+
+func (s *Store) cutoff() int64 {
+	return s.now().Add(-s.TTL).Unix()
+}
@@ -0,0 +1,53 @@
+package live
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+func newTestStore(now time.Time) *Store {
+	s := NewStore(5 * time.Minute)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestStore_UpdateKeepsNewestState(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := newTestStore(now)
+
+	s.Update([]events.TelemetryRawEvent{
+		{Icao24: "8a0001", LastContact: now.Unix() - 10, OriginCountry: "newer"},
+		{Icao24: "8a0002", LastContact: now.Unix()},
+	})
+	s.Update([]events.TelemetryRawEvent{
+		{Icao24: "8a0001", LastContact: now.Unix() - 60, OriginCountry: "redelivered"},
+	})
+
+	snapshot := s.Snapshot()
+	require.Len(t, snapshot, 2)
+	assert.Equal(t, "8a0001", snapshot[0].Icao24)
+	assert.Equal(t, "newer", snapshot[0].OriginCountry)
+	assert.Equal(t, "8a0002", snapshot[1].Icao24)
+}
+
+func TestStore_ExpiresStaleAircraft(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := newTestStore(now)
+
+	s.Update([]events.TelemetryRawEvent{
+		{Icao24: "8a0001", LastContact: now.Add(-10 * time.Minute).Unix()},
+		{Icao24: "8a0002", LastContact: now.Add(-time.Minute).Unix()},
+	})
+
+	snapshot := s.Snapshot()
+	require.Len(t, snapshot, 1)
+	assert.Equal(t, "8a0002", snapshot[0].Icao24)
+
+	assert.Equal(t, 1, s.Expire())
+	assert.Len(t, s.states, 1)
+}
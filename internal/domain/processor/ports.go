@@ -2,6 +2,8 @@ package processor
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/dandyZicky/opensky-collector/internal/domain/flight"
 	"github.com/dandyZicky/opensky-collector/pkg/events"
@@ -19,6 +21,107 @@ type Broadcaster interface {
 	Broadcast(events []events.TelemetryRawEvent) error
 }
 
+// StateStore keeps the last known state per aircraft for live views.
+type StateStore interface {
+	Update(events []events.TelemetryRawEvent)
+}
+
+// Registry attaches aircraft metadata to events in place.
+type Registry interface {
+	Attach(events []events.TelemetryRawEvent)
+}
+
+// Segmenter groups stored states into flights.
+type Segmenter interface {
+	Segment(states []flight.FlightState) error
+}
+
+// MovementDetector finds takeoffs and landings in stored states.
+type MovementDetector interface {
+	Detect(states []flight.FlightState) error
+}
+
+// GeofenceEvaluator reports aircraft entering and leaving geofences in
+// stored states.
+type GeofenceEvaluator interface {
+	Evaluate(states []flight.FlightState) error
+}
+
+// Validator drops the invalid events of a batch. It must only return once
+// they are quarantined.
+type Validator interface {
+	Filter(events []events.TelemetryRawEvent) ([]events.TelemetryRawEvent, error)
+}
+
+// Alerter evaluates alerting rules against every event and delivers the
+// alerts. It must only return once durable sinks have them.
+type Alerter interface {
+	Evaluate(events []events.TelemetryRawEvent) error
+}
+
+// Enricher derives data from raw events and publishes it downstream. It runs
+// after the batch is stored; an error retries the batch, so a partial
+// publish may be repeated.
+type Enricher interface {
+	Enrich(events []events.TelemetryRawEvent) error
+}
+
+// InsertResult counts what happened to each state handed to InsertBatch.
+type InsertResult struct {
+	Inserted   int
+	Duplicates int
+	// Skipped states had no position timestamp and cannot be stored.
+	Skipped int
+}
+
 type Inserter interface {
-	InsertBatch(states []flight.FlightState, batchSize int) error
+	InsertBatch(states []flight.FlightState, batchSize int) (InsertResult, error)
+}
+
+// ErrNotFound is returned by FlightRepository lookups that match nothing.
+var ErrNotFound = errors.New("not found")
+
+// ErrUnprocessable is wrapped by errors that retrying the batch cannot fix,
+// such as rows the database rejects. Consumers dead-letter such batches
+// instead of waiting for them to succeed.
+var ErrUnprocessable = errors.New("unprocessable")
+
+// Page sizes shared by every query API.
+const (
+	DefaultPageLimit = 100
+	MaxPageLimit     = 1000
+)
+
+// Page selects a window of a query result: Limit rows after skipping Offset.
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+// CountryHourStats counts the state vectors and distinct aircraft reported
+// for one origin country within one hour.
+type CountryHourStats struct {
+	Country  string    `json:"country"`
+	Hour     time.Time `json:"hour"`
+	States   int64     `json:"states"`
+	Aircraft int64     `json:"aircraft"`
+}
+
+// FlightRepository reads back the stored state vectors.
+type FlightRepository interface {
+	// Track returns the states of one aircraft with a position time in
+	// [from, to), oldest first.
+	Track(icao24 string, from, to time.Time, page Page) ([]flight.FlightState, error)
+	// Snapshot returns the latest state of every aircraft seen in
+	// (at-maxAge, at], restricted to box unless it is nil.
+	Snapshot(box *flight.BoundingBox, at time.Time, maxAge time.Duration, page Page) ([]flight.FlightState, error)
+	// LastKnown returns the most recent state of one aircraft, or
+	// ErrNotFound.
+	LastKnown(icao24 string) (flight.FlightState, error)
+	// Stats returns per-country hourly counts for [from, to), ordered by
+	// hour and country.
+	Stats(from, to time.Time, page Page) ([]CountryHourStats, error)
+	// Flights returns the flights of one aircraft overlapping [from, to),
+	// oldest first.
+	Flights(icao24 string, from, to time.Time, page Page) ([]flight.Flight, error)
 }
@@ -0,0 +1,42 @@
+// Package processortest provides mocks of the processor ports for the tests
+// of the packages serving them.
+package processortest
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/flight"
+	"github.com/dandyZicky/opensky-collector/internal/domain/processor"
+)
+
+// MockFlightRepository is a processor.FlightRepository.
+type MockFlightRepository struct {
+	mock.Mock
+}
+
+func (m *MockFlightRepository) Track(icao24 string, from, to time.Time, page processor.Page) ([]flight.FlightState, error) {
+	args := m.Called(icao24, from, to, page)
+	return args.Get(0).([]flight.FlightState), args.Error(1)
+}
+
+func (m *MockFlightRepository) Snapshot(box *flight.BoundingBox, at time.Time, maxAge time.Duration, page processor.Page) ([]flight.FlightState, error) {
+	args := m.Called(box, at, maxAge, page)
+	return args.Get(0).([]flight.FlightState), args.Error(1)
+}
+
+func (m *MockFlightRepository) LastKnown(icao24 string) (flight.FlightState, error) {
+	args := m.Called(icao24)
+	return args.Get(0).(flight.FlightState), args.Error(1)
+}
+
+func (m *MockFlightRepository) Stats(from, to time.Time, page processor.Page) ([]processor.CountryHourStats, error) {
+	args := m.Called(from, to, page)
+	return args.Get(0).([]processor.CountryHourStats), args.Error(1)
+}
+
+func (m *MockFlightRepository) Flights(icao24 string, from, to time.Time, page processor.Page) ([]flight.Flight, error) {
+	args := m.Called(icao24, from, to, page)
+	return args.Get(0).([]flight.Flight), args.Error(1)
+}
@@ -3,16 +3,41 @@ package processor
 
 import (
 	"context"
+	"fmt"
+	"log"
 
 	"github.com/dandyZicky/opensky-collector/internal/domain/flight"
 	"github.com/dandyZicky/opensky-collector/pkg/events"
 )
 
 type ProcessorService struct {
-	Inserter    Inserter
-	Consumer    Consumer
-	Ctx         context.Context
+	Inserter Inserter
+	Consumer Consumer
+	Ctx      context.Context
+	// Broadcaster receives a batch once every other step succeeded.
 	Broadcaster Broadcaster
+	// Validator, when set, drops invalid events before anything else sees
+	// them.
+	Validator Validator
+	// Store, when set, is updated before every broadcast so a snapshot
+	// taken by a joining client is never behind the stream.
+	Store StateStore
+	// Registry, when set, attaches aircraft metadata to every event before
+	// it is stored or broadcast.
+	Registry Registry
+	// Segmenter, when set, adds every stored batch to the flights.
+	Segmenter Segmenter
+	// Movements, when set, detects takeoffs and landings in every stored
+	// batch.
+	Movements MovementDetector
+	// Geofences, when set, evaluates every stored batch against the active
+	// geofences.
+	Geofences GeofenceEvaluator
+	// Alerts, when set, runs the alerting rules over every stored batch.
+	Alerts Alerter
+	// Enricher, when set, runs once a batch is stored. A failure fails the
+	// batch, so enriched events are published at least once.
+	Enricher Enricher
 }
 
 func (p *ProcessorService) NewSubscriberService() {
@@ -23,9 +48,19 @@ func (p *ProcessorService) NewSubscriberService() {
 func (p *ProcessorService) ProcessEvents(events []events.TelemetryRawEvent, batchSize int) error {
 	var states []flight.FlightState
 
-	// Broadcast events first
-	if err := p.Broadcaster.Broadcast(events); err != nil {
-		return err
+	if p.Validator != nil {
+		valid, err := p.Validator.Filter(events)
+		if err != nil {
+			return fmt.Errorf("validation: %w", err)
+		}
+		if len(valid) == 0 {
+			return nil
+		}
+		events = valid
+	}
+
+	if p.Registry != nil {
+		p.Registry.Attach(events)
 	}
 
 	// Convert events to domain models
@@ -34,5 +69,49 @@ func (p *ProcessorService) ProcessEvents(events []events.TelemetryRawEvent, batc
 	}
 
 	// Insert flight states
-	return p.Inserter.InsertBatch(states, batchSize)
+	result, err := p.Inserter.InsertBatch(states, batchSize)
+	if err != nil {
+		return err
+	}
+	if result.Duplicates > 0 || result.Skipped > 0 {
+		log.Printf("Stored %d state vectors, %d duplicates ignored, %d without position skipped",
+			result.Inserted, result.Duplicates, result.Skipped)
+	}
+
+	if p.Segmenter != nil {
+		if err := p.Segmenter.Segment(states); err != nil {
+			return fmt.Errorf("segmentation: %w", err)
+		}
+	}
+
+	if p.Movements != nil {
+		if err := p.Movements.Detect(states); err != nil {
+			return fmt.Errorf("movement detection: %w", err)
+		}
+	}
+
+	if p.Geofences != nil {
+		if err := p.Geofences.Evaluate(states); err != nil {
+			return fmt.Errorf("geofence evaluation: %w", err)
+		}
+	}
+
+	if p.Alerts != nil {
+		if err := p.Alerts.Evaluate(events); err != nil {
+			return fmt.Errorf("alerting: %w", err)
+		}
+	}
+
+	if p.Enricher != nil {
+		if err := p.Enricher.Enrich(events); err != nil {
+			return fmt.Errorf("enrichment: %w", err)
+		}
+	}
+
+	// Broadcast last, so live clients only see a batch once, however often
+	// the stages before fail and the batch is retried.
+	if p.Store != nil {
+		p.Store.Update(events)
+	}
+	return p.Broadcaster.Broadcast(events)
 }
@@ -16,9 +16,9 @@ type MockInserter struct {
 	mock.Mock
 }
 
-func (m *MockInserter) InsertBatch(states []flight.FlightState, batchSize int) error {
+func (m *MockInserter) InsertBatch(states []flight.FlightState, batchSize int) (InsertResult, error) {
 	args := m.Called(states, batchSize)
-	return args.Error(0)
+	return args.Get(0).(InsertResult), args.Error(1)
 }
 
 type MockBroadcaster struct {
@@ -30,6 +30,57 @@ func (m *MockBroadcaster) Broadcast(events []events.TelemetryRawEvent) error {
 	return args.Error(0)
 }
 
+// MockStateStage stands in for every stage that takes the flight states of
+// a batch.
+type MockStateStage struct {
+	mock.Mock
+}
+
+func (m *MockStateStage) Segment(states []flight.FlightState) error {
+	args := m.Called(states)
+	return args.Error(0)
+}
+
+func (m *MockStateStage) Detect(states []flight.FlightState) error {
+	args := m.Called(states)
+	return args.Error(0)
+}
+
+func (m *MockStateStage) Evaluate(states []flight.FlightState) error {
+	args := m.Called(states)
+	return args.Error(0)
+}
+
+// MockEventStage stands in for every stage that takes the raw events of a
+// batch.
+type MockEventStage struct {
+	mock.Mock
+}
+
+func (m *MockEventStage) Filter(raw []events.TelemetryRawEvent) ([]events.TelemetryRawEvent, error) {
+	args := m.Called(raw)
+	valid, _ := args.Get(0).([]events.TelemetryRawEvent)
+	return valid, args.Error(1)
+}
+
+func (m *MockEventStage) Attach(events []events.TelemetryRawEvent) {
+	m.Called(events)
+}
+
+func (m *MockEventStage) Update(events []events.TelemetryRawEvent) {
+	m.Called(events)
+}
+
+func (m *MockEventStage) Evaluate(events []events.TelemetryRawEvent) error {
+	args := m.Called(events)
+	return args.Error(0)
+}
+
+func (m *MockEventStage) Enrich(events []events.TelemetryRawEvent) error {
+	args := m.Called(events)
+	return args.Error(0)
+}
+
 type MockConsumer struct {
 	mock.Mock
 }
@@ -38,6 +89,81 @@ func (m *MockConsumer) Subscribe(ctx context.Context, eventProcessor EventProces
 	m.Called(ctx, eventProcessor)
 }
 
+// pipeline is a ProcessorService with every optional stage set.
+type pipeline struct {
+	inserter    *MockInserter
+	broadcaster *MockBroadcaster
+	states      *MockStateStage
+	events      *MockEventStage
+	service     *ProcessorService
+}
+
+func newPipeline() *pipeline {
+	p := &pipeline{
+		inserter:    &MockInserter{},
+		broadcaster: &MockBroadcaster{},
+		states:      &MockStateStage{},
+		events:      &MockEventStage{},
+	}
+	p.service = &ProcessorService{
+		Inserter:    p.inserter,
+		Broadcaster: p.broadcaster,
+		Validator:   p.events,
+		Registry:    p.events,
+		Store:       p.events,
+		Segmenter:   p.states,
+		Movements:   p.states,
+		Geofences:   p.states,
+		Alerts:      p.events,
+		Enricher:    p.events,
+	}
+	return p
+}
+
+// succeed lets every call not expected before succeed, with the validator
+// passing valid on.
+func (p *pipeline) succeed(valid []events.TelemetryRawEvent) {
+	p.events.On("Filter", mock.Anything).Return(valid, nil).Maybe()
+	p.inserter.On("InsertBatch", mock.Anything, mock.Anything).Return(InsertResult{Inserted: len(valid)}, nil).Maybe()
+	for _, method := range []string{"Segment", "Detect", "Evaluate"} {
+		p.states.On(method, mock.Anything).Return(nil).Maybe()
+	}
+	for _, method := range []string{"Attach", "Update"} {
+		p.events.On(method, mock.Anything).Return().Maybe()
+	}
+	for _, method := range []string{"Evaluate", "Enrich"} {
+		p.events.On(method, mock.Anything).Return(nil).Maybe()
+	}
+	p.broadcaster.On("Broadcast", mock.Anything).Return(nil).Maybe()
+}
+
+var errBroken = errors.New("broken")
+
+// stages are the steps of ProcessEvents that can fail, in order, with the
+// prefix each adds to the error.
+var stages = []struct {
+	name   string
+	prefix string
+	mock   func(p *pipeline) *mock.Mock
+	method string
+	args   []interface{}
+	fails  []interface{}
+}{
+	{"validation", "validation", eventStage, "Filter", []interface{}{mock.Anything}, []interface{}{nil, errBroken}},
+	{"insert", "", inserterStage, "InsertBatch", []interface{}{mock.Anything, mock.Anything}, []interface{}{InsertResult{}, errBroken}},
+	{"segmentation", "segmentation", stateStage, "Segment", []interface{}{mock.Anything}, []interface{}{errBroken}},
+	{"movements", "movement detection", stateStage, "Detect", []interface{}{mock.Anything}, []interface{}{errBroken}},
+	{"geofences", "geofence evaluation", stateStage, "Evaluate", []interface{}{mock.Anything}, []interface{}{errBroken}},
+	{"alerting", "alerting", eventStage, "Evaluate", []interface{}{mock.Anything}, []interface{}{errBroken}},
+	{"enrichment", "enrichment", eventStage, "Enrich", []interface{}{mock.Anything}, []interface{}{errBroken}},
+}
+
+func inserterStage(p *pipeline) *mock.Mock { return &p.inserter.Mock }
+
+func stateStage(p *pipeline) *mock.Mock { return &p.states.Mock }
+
+func eventStage(p *pipeline) *mock.Mock { return &p.events.Mock }
+
 func TestProcessorService_ProcessEvents_Success(t *testing.T) {
 	mockInserter := &MockInserter{}
 	mockBroadcaster := &MockBroadcaster{}
@@ -51,12 +177,12 @@ func TestProcessorService_ProcessEvents_Success(t *testing.T) {
 		{
 			Icao24:        "abc123",
 			OriginCountry: "DE",
-			Lat:           49.0,
-			Lon:           6.0,
-			Velocity:      200.0,
-			TimePosition:  1638360000,
-			BaroAltitude:  10000,
-			GeoAltitude:   10050,
+			Lat:           ptr(49.0),
+			Lon:           ptr(6.0),
+			Velocity:      ptr(200.0),
+			TimePosition:  ptr[int64](1638360000),
+			BaroAltitude:  ptr(10000.0),
+			GeoAltitude:   ptr(10050.0),
 			LastContact:   1638360000,
 		},
 	}
@@ -67,7 +193,7 @@ func TestProcessorService_ProcessEvents_Success(t *testing.T) {
 		return len(states) == 1 &&
 			states[0].Icao24 == "abc123" &&
 			states[0].OriginCountry == "DE"
-	}), batchSize).Return(nil)
+	}), batchSize).Return(InsertResult{}, nil)
 
 	err := processor.ProcessEvents(events, batchSize)
 
@@ -76,6 +202,76 @@ func TestProcessorService_ProcessEvents_Success(t *testing.T) {
 	mockBroadcaster.AssertExpectations(t)
 }
 
+func TestProcessorService_ProcessEvents_AttachesAircraft(t *testing.T) {
+	p := newPipeline()
+	batch := []events.TelemetryRawEvent{
+		{Icao24: "abc123", TimePosition: ptr[int64](1638360000), LastContact: 1638360000},
+	}
+	aircraft := &events.Aircraft{Icao24: "abc123", Registration: "D-AIBL"}
+
+	p.events.On("Attach", batch).Run(func(args mock.Arguments) {
+		args.Get(0).([]events.TelemetryRawEvent)[0].Aircraft = aircraft
+	}).Return().Once()
+	p.succeed(batch)
+
+	assert.NoError(t, p.service.ProcessEvents(batch, 10))
+	p.broadcaster.AssertCalled(t, "Broadcast", mock.MatchedBy(func(e []events.TelemetryRawEvent) bool {
+		return e[0].Aircraft == aircraft
+	}))
+	p.inserter.AssertCalled(t, "InsertBatch", mock.MatchedBy(func(states []flight.FlightState) bool {
+		return states[0].Aircraft == aircraft
+	}), 10)
+}
+
+func TestProcessorService_ProcessEvents_Validates(t *testing.T) {
+	p := newPipeline()
+	valid := events.TelemetryRawEvent{Icao24: "abc123", TimePosition: ptr[int64](1638360000), LastContact: 1638360000}
+	invalid := events.TelemetryRawEvent{Icao24: "", LastContact: 1638360000}
+	batch := []events.TelemetryRawEvent{valid, invalid}
+
+	p.events.On("Filter", batch).Return([]events.TelemetryRawEvent{valid}, nil).Once()
+	p.succeed([]events.TelemetryRawEvent{})
+
+	assert.NoError(t, p.service.ProcessEvents(batch, 10))
+	p.inserter.AssertCalled(t, "InsertBatch", mock.MatchedBy(func(states []flight.FlightState) bool {
+		return len(states) == 1 && states[0].Icao24 == "abc123"
+	}), 10)
+	p.broadcaster.AssertCalled(t, "Broadcast", []events.TelemetryRawEvent{valid})
+
+	// Nothing left to process.
+	assert.NoError(t, p.service.ProcessEvents([]events.TelemetryRawEvent{invalid}, 10))
+	p.inserter.AssertNumberOfCalls(t, "InsertBatch", 1)
+	p.broadcaster.AssertNumberOfCalls(t, "Broadcast", 1)
+}
+
+func TestProcessorService_ProcessEvents_StageFailure(t *testing.T) {
+	batch := []events.TelemetryRawEvent{
+		{Icao24: "abc123", TimePosition: ptr[int64](1638360000), LastContact: 1638360000},
+	}
+
+	for i, failing := range stages {
+		t.Run(failing.name, func(t *testing.T) {
+			p := newPipeline()
+			failing.mock(p).On(failing.method, failing.args...).Return(failing.fails...).Once()
+			p.succeed(batch)
+
+			err := p.service.ProcessEvents(batch, 10)
+			assert.ErrorIs(t, err, errBroken)
+			assert.ErrorContains(t, err, failing.prefix)
+			for _, later := range stages[i+1:] {
+				later.mock(p).AssertNumberOfCalls(t, later.method, 0)
+			}
+			p.events.AssertNotCalled(t, "Update", mock.Anything)
+			p.broadcaster.AssertNotCalled(t, "Broadcast", mock.Anything)
+
+			// The retried batch goes through and is broadcast once.
+			assert.NoError(t, p.service.ProcessEvents(batch, 10))
+			p.events.AssertNumberOfCalls(t, "Update", 1)
+			p.broadcaster.AssertNumberOfCalls(t, "Broadcast", 1)
+		})
+	}
+}
+
 func TestProcessorService_ProcessEvents_InserterError(t *testing.T) {
 	mockInserter := &MockInserter{}
 	mockBroadcaster := &MockBroadcaster{}
@@ -89,27 +285,26 @@ func TestProcessorService_ProcessEvents_InserterError(t *testing.T) {
 		{
 			Icao24:        "def456",
 			OriginCountry: "FR",
-			Lat:           48.0,
-			Lon:           2.0,
-			Velocity:      250.0,
-			TimePosition:  1638360000,
-			BaroAltitude:  12000,
-			GeoAltitude:   12050,
+			Lat:           ptr(48.0),
+			Lon:           ptr(2.0),
+			Velocity:      ptr(250.0),
+			TimePosition:  ptr[int64](1638360000),
+			BaroAltitude:  ptr(12000.0),
+			GeoAltitude:   ptr(12050.0),
 			LastContact:   1638360000,
 		},
 	}
 	batchSize := 5
 	expectedError := errors.New("database connection failed")
 
-	mockBroadcaster.On("Broadcast", events).Return(nil)
-	mockInserter.On("InsertBatch", mock.AnythingOfType("[]flight.FlightState"), batchSize).Return(expectedError)
+	mockInserter.On("InsertBatch", mock.AnythingOfType("[]flight.FlightState"), batchSize).Return(InsertResult{}, expectedError)
 
 	err := processor.ProcessEvents(events, batchSize)
 
 	assert.Error(t, err)
 	assert.Equal(t, expectedError, err)
 	mockInserter.AssertExpectations(t)
-	mockBroadcaster.AssertExpectations(t)
+	mockBroadcaster.AssertNotCalled(t, "Broadcast", mock.Anything)
 }
 
 func TestProcessorService_ProcessEvents_BroadcasterError(t *testing.T) {
@@ -125,18 +320,19 @@ func TestProcessorService_ProcessEvents_BroadcasterError(t *testing.T) {
 		{
 			Icao24:        "ghi789",
 			OriginCountry: "GB",
-			Lat:           51.0,
-			Lon:           0.0,
-			Velocity:      300.0,
-			TimePosition:  1638360000,
-			BaroAltitude:  15000,
-			GeoAltitude:   15050,
+			Lat:           ptr(51.0),
+			Lon:           ptr(0.0),
+			Velocity:      ptr(300.0),
+			TimePosition:  ptr[int64](1638360000),
+			BaroAltitude:  ptr(15000.0),
+			GeoAltitude:   ptr(15050.0),
 			LastContact:   1638360000,
 		},
 	}
 	batchSize := 8
 	expectedError := errors.New("SSE broadcast failed")
 
+	mockInserter.On("InsertBatch", mock.AnythingOfType("[]flight.FlightState"), batchSize).Return(InsertResult{}, nil)
 	mockBroadcaster.On("Broadcast", events).Return(expectedError)
 
 	err := processor.ProcessEvents(events, batchSize)
@@ -144,7 +340,7 @@ func TestProcessorService_ProcessEvents_BroadcasterError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, expectedError, err)
 	mockBroadcaster.AssertExpectations(t)
-	mockInserter.AssertNotCalled(t, "InsertBatch", mock.Anything, mock.Anything)
+	mockInserter.AssertExpectations(t)
 }
 
 func TestProcessorService_ProcessEvents_EmptyEvents(t *testing.T) {
@@ -162,7 +358,7 @@ func TestProcessorService_ProcessEvents_EmptyEvents(t *testing.T) {
 	mockBroadcaster.On("Broadcast", events).Return(nil)
 	mockInserter.On("InsertBatch", mock.MatchedBy(func(states []flight.FlightState) bool {
 		return len(states) == 0
-	}), batchSize).Return(nil)
+	}), batchSize).Return(InsertResult{}, nil)
 
 	err := processor.ProcessEvents(events, batchSize)
 
@@ -184,23 +380,23 @@ func TestProcessorService_ProcessEvents_MultipleEvents(t *testing.T) {
 		{
 			Icao24:        "multi1",
 			OriginCountry: "US",
-			Lat:           40.0,
-			Lon:           -74.0,
-			Velocity:      400.0,
-			TimePosition:  1638360000,
-			BaroAltitude:  20000,
-			GeoAltitude:   20050,
+			Lat:           ptr(40.0),
+			Lon:           ptr(-74.0),
+			Velocity:      ptr(400.0),
+			TimePosition:  ptr[int64](1638360000),
+			BaroAltitude:  ptr(20000.0),
+			GeoAltitude:   ptr(20050.0),
 			LastContact:   1638360000,
 		},
 		{
 			Icao24:        "multi2",
 			OriginCountry: "CA",
-			Lat:           45.0,
-			Lon:           -75.0,
-			Velocity:      350.0,
-			TimePosition:  1638360000,
-			BaroAltitude:  18000,
-			GeoAltitude:   18050,
+			Lat:           ptr(45.0),
+			Lon:           ptr(-75.0),
+			Velocity:      ptr(350.0),
+			TimePosition:  ptr[int64](1638360000),
+			BaroAltitude:  ptr(18000.0),
+			GeoAltitude:   ptr(18050.0),
 			LastContact:   1638360000,
 		},
 	}
@@ -211,7 +407,7 @@ func TestProcessorService_ProcessEvents_MultipleEvents(t *testing.T) {
 		return len(states) == 2 &&
 			states[0].Icao24 == "multi1" &&
 			states[1].Icao24 == "multi2"
-	}), batchSize).Return(nil)
+	}), batchSize).Return(InsertResult{}, nil)
 
 	err := processor.ProcessEvents(events, batchSize)
 
@@ -240,3 +436,7 @@ func TestProcessorService_NewSubscriberService(t *testing.T) {
 
 	mockConsumer.AssertExpectations(t)
 }
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,119 @@
+package registry
+
+import (
+	"context"
+	"expvar"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+// Published through expvar under "registry": aircraft in the cache, lookups,
+// lookups that found nothing and distinct unknown aircraft since the last
+// refresh.
+var metrics = expvar.NewMap("registry")
+
+// maxUnknown bounds the set of distinct unknown aircraft tracked between two
+// refreshes.
+const maxUnknown = 100000
+
+// Cache is an in-memory copy of the registry, reloaded from Source.
+type Cache struct {
+	Source Source
+
+	mu       sync.RWMutex
+	aircraft map[string]events.Aircraft
+
+	unknownMu sync.Mutex
+	unknown   map[string]struct{}
+}
+
+func NewCache(source Source) *Cache {
+	return &Cache{
+		Source:   source,
+		aircraft: make(map[string]events.Aircraft),
+		unknown:  make(map[string]struct{}),
+	}
+}
+
+// Refresh replaces the cache with the current contents of Source.
+func (c *Cache) Refresh() error {
+	all, err := c.Source.All()
+	if err != nil {
+		return err
+	}
+	aircraft := make(map[string]events.Aircraft, len(all))
+	for _, a := range all {
+		aircraft[a.Icao24] = a
+	}
+
+	c.mu.Lock()
+	c.aircraft = aircraft
+	c.mu.Unlock()
+
+	c.unknownMu.Lock()
+	c.unknown = make(map[string]struct{})
+	c.unknownMu.Unlock()
+
+	metrics.Set("aircraft", intVar(len(aircraft)))
+	metrics.Set("unknown_aircraft", intVar(0))
+	return nil
+}
+
+// Run refreshes the cache every interval until ctx is done. A failed refresh
+// keeps the previous contents.
+func (c *Cache) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Refresh(); err != nil {
+				log.Printf("Failed to refresh aircraft registry: %v", err)
+			}
+		}
+	}
+}
+
+func (c *Cache) Lookup(icao24 string) (events.Aircraft, bool) {
+	c.mu.RLock()
+	a, ok := c.aircraft[icao24]
+	c.mu.RUnlock()
+
+	metrics.Add("lookups", 1)
+	if !ok {
+		c.recordUnknown(icao24)
+	}
+	return a, ok
+}
+
+// Attach sets the registry metadata of every event whose aircraft is known.
+func (c *Cache) Attach(raw []events.TelemetryRawEvent) {
+	for i := range raw {
+		if a, ok := c.Lookup(raw[i].Icao24); ok {
+			raw[i].Aircraft = &a
+		}
+	}
+}
+
+func (c *Cache) recordUnknown(icao24 string) {
+	metrics.Add("unknown", 1)
+
+	c.unknownMu.Lock()
+	defer c.unknownMu.Unlock()
+	if _, ok := c.unknown[icao24]; ok || len(c.unknown) >= maxUnknown {
+		return
+	}
+	c.unknown[icao24] = struct{}{}
+	metrics.Add("unknown_aircraft", 1)
+}
+
+func intVar(v int) *expvar.Int {
+	i := new(expvar.Int)
+	i.Set(int64(v))
+	return i
+}
@@ -0,0 +1,85 @@
+package registry
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+// Columns read from the aircraft database, matched case-insensitively so
+// both the older lowercase and the newer camelCase headers work.
+const (
+	colIcao24       = "icao24"
+	colRegistration = "registration"
+	colTypeCode     = "typecode"
+	colManufacturer = "manufacturername"
+	colModel        = "model"
+	colOperator     = "operator"
+)
+
+// ParseCSV reads the OpenSky aircraft database and calls fn for every row
+// with an icao24. Values may be wrapped in single quotes, as in recent dumps.
+func ParseCSV(r io.Reader, fn func(events.Aircraft) error) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("reading header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(unquote(name))] = i
+	}
+	if _, ok := columns[colIcao24]; !ok {
+		return errors.New("header has no icao24 column")
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return unquote(record[i])
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		icao24 := strings.ToLower(field(record, colIcao24))
+		if icao24 == "" {
+			continue
+		}
+		err = fn(events.Aircraft{
+			Icao24:       icao24,
+			Registration: field(record, colRegistration),
+			TypeCode:     field(record, colTypeCode),
+			Manufacturer: field(record, colManufacturer),
+			Model:        field(record, colModel),
+			Operator:     field(record, colOperator),
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		s = s[1 : len(s)-1]
+	}
+	return strings.TrimSpace(s)
+}
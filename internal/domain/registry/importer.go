@@ -0,0 +1,50 @@
+package registry
+
+import (
+	"io"
+
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+// DefaultBatchSize is the number of aircraft upserted at once when
+// Importer.BatchSize is not positive.
+const DefaultBatchSize = 1000
+
+// Importer loads aircraft database dumps into the registry store.
+type Importer struct {
+	Store     Store
+	BatchSize int
+}
+
+// Import upserts every aircraft in the CSV and returns how many were read.
+// Aircraft missing from the dump are kept.
+func (i *Importer) Import(r io.Reader) (int, error) {
+	size := i.BatchSize
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+	var batch []events.Aircraft
+	read := 0
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		_, err := i.Store.Upsert(batch, size)
+		batch = batch[:0]
+		return err
+	}
+
+	err := ParseCSV(r, func(a events.Aircraft) error {
+		read++
+		batch = append(batch, a)
+		if len(batch) >= size {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return read, err
+	}
+	return read, flush()
+}
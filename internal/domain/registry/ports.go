@@ -0,0 +1,38 @@
+// Package registry attaches aircraft metadata from the OpenSky aircraft
+// database to telemetry.
+package registry
+
+import (
+	"time"
+
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+// Source loads the whole registry.
+type Source interface {
+	All() ([]events.Aircraft, error)
+}
+
+// Store is the registry table written by the importer.
+type Store interface {
+	Source
+	// Upsert inserts or replaces aircraft by icao24 and returns how many
+	// rows were written.
+	Upsert(aircraft []events.Aircraft, batchSize int) (int64, error)
+	// Unknown reports the aircraft seen since a time that the registry
+	// does not know, listing at most limit of them.
+	Unknown(since time.Time, limit int) (UnknownStats, error)
+}
+
+// UnknownStats compares the aircraft in stored telemetry with the registry.
+type UnknownStats struct {
+	Seen    int64
+	Unknown int64
+	// Top lists the unknown aircraft with the most state vectors first.
+	Top []UnknownAircraft
+}
+
+type UnknownAircraft struct {
+	Icao24 string
+	States int64
+}
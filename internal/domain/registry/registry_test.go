@@ -0,0 +1,105 @@
+package registry
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+type MockStore struct {
+	mock.Mock
+}
+
+func (m *MockStore) All() ([]events.Aircraft, error) {
+	args := m.Called()
+	return args.Get(0).([]events.Aircraft), args.Error(1)
+}
+
+func (m *MockStore) Upsert(aircraft []events.Aircraft, batchSize int) (int64, error) {
+	// Copy, the importer reuses its batch.
+	args := m.Called(append([]events.Aircraft(nil), aircraft...), batchSize)
+	return int64(len(aircraft)), args.Error(0)
+}
+
+func (m *MockStore) Unknown(since time.Time, limit int) (UnknownStats, error) {
+	args := m.Called(since, limit)
+	return args.Get(0).(UnknownStats), args.Error(1)
+}
+
+const legacyCSV = `"icao24","registration","manufacturericao","manufacturername","model","typecode","serialnumber","operator"
+"8a0001","PK-GMA","BOEING","Boeing","737-8U3","B738","29668","Garuda Indonesia"
+"","PK-XXX","","","","","",""
+"8A0002","PK-LQJ","AIRBUS","Airbus","A320-214","A320","4578","Lion Air"
+`
+
+const quotedCSV = `'icao24','timestamp','manufacturerName','model','operator','registration','typecode'
+'8a0003','2024-01-01 00:00:00','ATR','ATR 72-600','Wings Air','PK-WHT','AT76'
+`
+
+func TestParseCSV(t *testing.T) {
+	var got []events.Aircraft
+	collect := func(a events.Aircraft) error {
+		got = append(got, a)
+		return nil
+	}
+
+	require.NoError(t, ParseCSV(strings.NewReader(legacyCSV), collect))
+	require.NoError(t, ParseCSV(strings.NewReader(quotedCSV), collect))
+
+	assert.Equal(t, []events.Aircraft{
+		{Icao24: "8a0001", Registration: "PK-GMA", TypeCode: "B738", Manufacturer: "Boeing", Model: "737-8U3", Operator: "Garuda Indonesia"},
+		{Icao24: "8a0002", Registration: "PK-LQJ", TypeCode: "A320", Manufacturer: "Airbus", Model: "A320-214", Operator: "Lion Air"},
+		{Icao24: "8a0003", Registration: "PK-WHT", TypeCode: "AT76", Manufacturer: "ATR", Model: "ATR 72-600", Operator: "Wings Air"},
+	}, got)
+
+	assert.Error(t, ParseCSV(strings.NewReader("registration,model\nPK-GMA,737\n"), collect))
+}
+
+func TestImporter_Batches(t *testing.T) {
+	store := &MockStore{}
+	store.On("Upsert", mock.MatchedBy(func(a []events.Aircraft) bool { return len(a) == 1 }), 1).Return(nil).Twice()
+
+	read, err := (&Importer{Store: store, BatchSize: 1}).Import(strings.NewReader(legacyCSV))
+	require.NoError(t, err)
+	assert.Equal(t, 2, read)
+	store.AssertExpectations(t)
+
+	failing := &MockStore{}
+	failing.On("Upsert", mock.Anything, 10).Return(errors.New("connection refused"))
+	_, err = (&Importer{Store: failing, BatchSize: 10}).Import(strings.NewReader(legacyCSV))
+	assert.Error(t, err)
+
+	unset := &MockStore{}
+	unset.On("Upsert", mock.MatchedBy(func(a []events.Aircraft) bool { return len(a) == 2 }), DefaultBatchSize).Return(nil).Once()
+	_, err = (&Importer{Store: unset}).Import(strings.NewReader(legacyCSV))
+	require.NoError(t, err)
+	unset.AssertExpectations(t)
+}
+
+func TestCache_Attach(t *testing.T) {
+	store := &MockStore{}
+	store.On("All").Return([]events.Aircraft{{Icao24: "8a0001", Registration: "PK-GMA"}}, nil).Once()
+	store.On("All").Return([]events.Aircraft{}, errors.New("connection refused")).Once()
+
+	cache := NewCache(store)
+	require.NoError(t, cache.Refresh())
+
+	batch := []events.TelemetryRawEvent{{Icao24: "8a0001"}, {Icao24: "8a0099"}, {Icao24: "8a0099"}}
+	cache.Attach(batch)
+
+	require.NotNil(t, batch[0].Aircraft)
+	assert.Equal(t, "PK-GMA", batch[0].Aircraft.Registration)
+	assert.Nil(t, batch[1].Aircraft)
+	assert.Len(t, cache.unknown, 1)
+
+	assert.Error(t, cache.Refresh())
+	_, ok := cache.Lookup("8a0001")
+	assert.True(t, ok, "a failed refresh keeps the cache")
+}
@@ -0,0 +1,178 @@
+// Package segmentation groups the state vectors of each aircraft into
+// discrete flights.
+package segmentation
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/flight"
+)
+
+// Repository persists flights. Flights are identified by icao24 and start
+// time, so saving a flight again updates it.
+type Repository interface {
+	// Latest returns the most recent flight of each aircraft that ended at
+	// or after since.
+	Latest(icao24s []string, since time.Time) ([]flight.Flight, error)
+	Save(flights []flight.Flight) error
+}
+
+// Segmenter keeps the open flight of every aircraft. A point starts a new
+// flight when it is more than Gap after the previous one or when the
+// aircraft takes off; otherwise it extends the open flight.
+type Segmenter struct {
+	Repo Repository
+	Gap  time.Duration
+
+	mu   sync.Mutex
+	open map[string]flight.Flight
+}
+
+func NewSegmenter(repo Repository, gap time.Duration) *Segmenter {
+	return &Segmenter{
+		Repo: repo,
+		Gap:  gap,
+		open: make(map[string]flight.Flight),
+	}
+}
+
+// Segment adds a batch of states to the flights and saves every flight it
+// changed. States without a position or not newer than the open flight, such
+// as a redelivered batch, are ignored. Nothing is kept when Save fails, so
+// the batch can be retried.
+func (s *Segmenter) Segment(states []flight.FlightState) error {
+	points := flight.Positioned(states)
+	if len(points) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.resume(points); err != nil {
+		return err
+	}
+
+	// closed holds flights the batch both changed and ended.
+	changed := make(map[string]flight.Flight)
+	var order []string
+	var closed []flight.Flight
+	for _, p := range points {
+		current, ok := changed[p.Icao24]
+		if !ok {
+			current, ok = s.open[p.Icao24]
+		}
+
+		var next flight.Flight
+		switch {
+		case !ok || s.startsFlight(current, p):
+			next = startFlight(p)
+		case !p.TimePosition.After(current.EndTime):
+			continue
+		default:
+			next = extendFlight(current, p)
+		}
+
+		if _, seen := changed[p.Icao24]; !seen {
+			order = append(order, p.Icao24)
+		} else if !next.StartTime.Equal(current.StartTime) {
+			closed = append(closed, current)
+		}
+		changed[p.Icao24] = next
+	}
+
+	open := make([]flight.Flight, 0, len(order))
+	for _, icao24 := range order {
+		open = append(open, changed[icao24])
+	}
+	if err := s.Repo.Save(append(closed, open...)); err != nil {
+		return err
+	}
+
+	var newest time.Time
+	for _, f := range open {
+		s.open[f.Icao24] = f
+		if f.EndTime.After(newest) {
+			newest = f.EndTime
+		}
+	}
+	s.forgetBefore(newest.Add(-s.Gap))
+	return nil
+}
+
+// resume loads the latest stored flight of aircraft that have no open flight
+// in memory, so a restart continues flights instead of splitting them.
+func (s *Segmenter) resume(points []flight.FlightState) error {
+	var missing []string
+	seen := make(map[string]bool)
+	earliest := *points[0].TimePosition
+	for _, p := range points {
+		if _, ok := s.open[p.Icao24]; !ok && !seen[p.Icao24] {
+			missing = append(missing, p.Icao24)
+			seen[p.Icao24] = true
+		}
+		if p.TimePosition.Before(earliest) {
+			earliest = *p.TimePosition
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	latest, err := s.Repo.Latest(missing, earliest.Add(-s.Gap))
+	if err != nil {
+		return err
+	}
+	for _, f := range latest {
+		s.open[f.Icao24] = f
+	}
+	return nil
+}
+
+func (s *Segmenter) startsFlight(current flight.Flight, p flight.FlightState) bool {
+	if p.TimePosition.Sub(current.EndTime) > s.Gap {
+		return true
+	}
+	return current.EndOnGround && !p.OnGround && p.TimePosition.After(current.EndTime)
+}
+
+func (s *Segmenter) forgetBefore(t time.Time) {
+	for icao24, f := range s.open {
+		if f.EndTime.Before(t) {
+			delete(s.open, icao24)
+		}
+	}
+}
+
+func startFlight(p flight.FlightState) flight.Flight {
+	return flight.Flight{
+		Icao24:      p.Icao24,
+		Callsign:    p.Callsign,
+		StartTime:   *p.TimePosition,
+		EndTime:     *p.TimePosition,
+		StartLat:    *p.Lat,
+		StartLon:    *p.Lon,
+		EndLat:      *p.Lat,
+		EndLon:      *p.Lon,
+		MaxAltitude: flight.Altitude(p.BaroAltitude, p.GeoAltitude),
+		Points:      1,
+		EndOnGround: p.OnGround,
+	}
+}
+
+func extendFlight(f flight.Flight, p flight.FlightState) flight.Flight {
+	f.DistanceM += flight.Distance(f.EndLat, f.EndLon, *p.Lat, *p.Lon)
+	f.EndTime = *p.TimePosition
+	f.EndLat = *p.Lat
+	f.EndLon = *p.Lon
+	f.EndOnGround = p.OnGround
+	f.Points++
+	if f.Callsign == nil {
+		f.Callsign = p.Callsign
+	}
+	if alt := flight.Altitude(p.BaroAltitude, p.GeoAltitude); alt != nil && (f.MaxAltitude == nil || *alt > *f.MaxAltitude) {
+		f.MaxAltitude = alt
+	}
+	return f
+}
@@ -0,0 +1,148 @@
+package segmentation
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/flight"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// memoryRepository keys flights like the flights table: icao24 and start.
+type memoryRepository struct {
+	flights map[string]map[time.Time]flight.Flight
+	saveErr error
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{flights: make(map[string]map[time.Time]flight.Flight)}
+}
+
+func (r *memoryRepository) Latest(icao24s []string, since time.Time) ([]flight.Flight, error) {
+	var latest []flight.Flight
+	for _, icao24 := range icao24s {
+		var last *flight.Flight
+		for _, f := range r.flights[icao24] {
+			if !f.EndTime.Before(since) && (last == nil || f.StartTime.After(last.StartTime)) {
+				last = &f
+			}
+		}
+		if last != nil {
+			latest = append(latest, *last)
+		}
+	}
+	return latest, nil
+}
+
+func (r *memoryRepository) Save(flights []flight.Flight) error {
+	if r.saveErr != nil {
+		return r.saveErr
+	}
+	for _, f := range flights {
+		if r.flights[f.Icao24] == nil {
+			r.flights[f.Icao24] = make(map[time.Time]flight.Flight)
+		}
+		r.flights[f.Icao24][f.StartTime] = f
+	}
+	return nil
+}
+
+var base = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func point(icao24 string, minute int, lat float64, alt float64, onGround bool) flight.FlightState {
+	return flight.FlightState{
+		Icao24:       icao24,
+		Lat:          ptr(lat),
+		Lon:          ptr(106.0),
+		BaroAltitude: ptr(alt),
+		TimePosition: ptr(base.Add(time.Duration(minute) * time.Minute)),
+		LastContact:  base.Add(time.Duration(minute) * time.Minute),
+		OnGround:     onGround,
+	}
+}
+
+func TestSegmenter_Gap(t *testing.T) {
+	repo := newMemoryRepository()
+	s := NewSegmenter(repo, 15*time.Minute)
+
+	require.NoError(t, s.Segment([]flight.FlightState{
+		point("8a0001", 1, -6.01, 3000, false),
+		point("8a0001", 0, -6.00, 1000, false),
+		point("8a0001", 2, -6.02, 2000, false),
+	}))
+	require.NoError(t, s.Segment([]flight.FlightState{
+		point("8a0001", 40, -7.00, 9000, false),
+		{Icao24: "8a0001", TimePosition: ptr(base.Add(41 * time.Minute))},
+	}))
+
+	require.Len(t, repo.flights["8a0001"], 2)
+	first := repo.flights["8a0001"][base]
+	assert.Equal(t, base.Add(2*time.Minute), first.EndTime)
+	assert.Equal(t, -6.00, first.StartLat)
+	assert.Equal(t, -6.02, first.EndLat)
+	assert.Equal(t, 3000.0, *first.MaxAltitude)
+	assert.Equal(t, 3, first.Points)
+	assert.InDelta(t, 2224, first.DistanceM, 2)
+
+	second := repo.flights["8a0001"][base.Add(40*time.Minute)]
+	assert.Equal(t, 1, second.Points)
+	assert.Zero(t, second.DistanceM)
+}
+
+func TestSegmenter_Takeoff(t *testing.T) {
+	repo := newMemoryRepository()
+	s := NewSegmenter(repo, 15*time.Minute)
+
+	require.NoError(t, s.Segment([]flight.FlightState{
+		point("8a0001", 0, -6.00, 0, true),
+		point("8a0001", 1, -6.00, 0, true),
+		point("8a0001", 2, -6.01, 300, false),
+		point("8a0001", 3, -6.02, 900, false),
+	}))
+
+	require.Len(t, repo.flights["8a0001"], 2)
+	taxi := repo.flights["8a0001"][base]
+	assert.Equal(t, 2, taxi.Points)
+	assert.True(t, taxi.EndOnGround)
+	airborne := repo.flights["8a0001"][base.Add(2*time.Minute)]
+	assert.Equal(t, 2, airborne.Points)
+	assert.False(t, airborne.EndOnGround)
+}
+
+func TestSegmenter_RedeliveryAndRestart(t *testing.T) {
+	repo := newMemoryRepository()
+	batch := []flight.FlightState{
+		point("8a0001", 0, -6.00, 1000, false),
+		point("8a0001", 1, -6.01, 2000, false),
+	}
+	require.NoError(t, NewSegmenter(repo, 15*time.Minute).Segment(batch))
+
+	// A new segmenter resumes the stored flight, and points it already
+	// holds are ignored.
+	s := NewSegmenter(repo, 15*time.Minute)
+	require.NoError(t, s.Segment(append(batch, point("8a0001", 2, -6.02, 3000, false))))
+
+	require.Len(t, repo.flights["8a0001"], 1)
+	assert.Equal(t, 3, repo.flights["8a0001"][base].Points)
+}
+
+func TestSegmenter_SaveFailureKeepsNothing(t *testing.T) {
+	repo := newMemoryRepository()
+	s := NewSegmenter(repo, 15*time.Minute)
+	batch := []flight.FlightState{point("8a0001", 0, -6.00, 1000, false)}
+
+	repo.saveErr = errors.New("connection refused")
+	assert.Error(t, s.Segment(batch))
+	assert.Empty(t, s.open)
+
+	repo.saveErr = nil
+	require.NoError(t, s.Segment(batch))
+	assert.Len(t, repo.flights["8a0001"], 1)
+}
@@ -0,0 +1,98 @@
+// Package validation rejects implausible state vectors before they are
+// processed and quarantines them with a reason code.
+package validation
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"time"
+
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+// Reason codes, one per rule.
+const (
+	ReasonInvalidIcao24    = "invalid_icao24"
+	ReasonLatitudeRange    = "latitude_range"
+	ReasonLongitudeRange   = "longitude_range"
+	ReasonNegativeVelocity = "negative_velocity"
+	ReasonFutureContact    = "future_contact"
+)
+
+const DefaultMaxFuture = 5 * time.Minute
+
+var icao24 = regexp.MustCompile(`^[0-9a-fA-F]{6}$`)
+
+// Rule is one check. Check describes what is wrong with an event, or
+// returns "" when the event passes.
+type Rule struct {
+	Reason string
+	Check  func(ev events.TelemetryRawEvent, now time.Time) string
+}
+
+// DefaultRules returns every rule in the order they are checked. An event
+// whose last_contact is more than maxFuture ahead of the processor's clock
+// is in the future.
+func DefaultRules(maxFuture time.Duration) []Rule {
+	return []Rule{
+		{Reason: ReasonInvalidIcao24, Check: func(ev events.TelemetryRawEvent, _ time.Time) string {
+			if !icao24.MatchString(ev.Icao24) {
+				return fmt.Sprintf("icao24 %q is not 6 hex digits", ev.Icao24)
+			}
+			return ""
+		}},
+		{Reason: ReasonLatitudeRange, Check: func(ev events.TelemetryRawEvent, _ time.Time) string {
+			if ev.Lat != nil && !within(*ev.Lat, 90) {
+				return fmt.Sprintf("latitude %v outside ±90", *ev.Lat)
+			}
+			return ""
+		}},
+		{Reason: ReasonLongitudeRange, Check: func(ev events.TelemetryRawEvent, _ time.Time) string {
+			if ev.Lon != nil && !within(*ev.Lon, 180) {
+				return fmt.Sprintf("longitude %v outside ±180", *ev.Lon)
+			}
+			return ""
+		}},
+		{Reason: ReasonNegativeVelocity, Check: func(ev events.TelemetryRawEvent, _ time.Time) string {
+			if ev.Velocity != nil && (*ev.Velocity < 0 || math.IsNaN(*ev.Velocity)) {
+				return fmt.Sprintf("velocity %v is negative", *ev.Velocity)
+			}
+			return ""
+		}},
+		{Reason: ReasonFutureContact, Check: func(ev events.TelemetryRawEvent, now time.Time) string {
+			if at := time.Unix(ev.LastContact, 0); at.After(now.Add(maxFuture)) {
+				return fmt.Sprintf("last_contact %d is %s ahead", ev.LastContact, at.Sub(now).Round(time.Second))
+			}
+			return ""
+		}},
+	}
+}
+
+// SelectRules returns the default rules whose reasons are listed, in their
+// default order, or all of them when reasons is empty.
+func SelectRules(reasons []string, maxFuture time.Duration) ([]Rule, error) {
+	all := DefaultRules(maxFuture)
+	if len(reasons) == 0 {
+		return all, nil
+	}
+	enabled := make(map[string]bool, len(reasons))
+	for _, r := range reasons {
+		enabled[r] = true
+	}
+	var rules []Rule
+	for _, r := range all {
+		if enabled[r.Reason] {
+			rules = append(rules, r)
+			delete(enabled, r.Reason)
+		}
+	}
+	for r := range enabled {
+		return nil, fmt.Errorf("unknown validation rule %q", r)
+	}
+	return rules, nil
+}
+
+func within(v, limit float64) bool {
+	return v >= -limit && v <= limit
+}
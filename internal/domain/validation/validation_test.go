@@ -0,0 +1,147 @@
+package validation
+
+import (
+	"errors"
+	"expvar"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+type MockQuarantine struct {
+	mock.Mock
+}
+
+func (m *MockQuarantine) Save(rejected []Rejection) ([]Rejection, error) {
+	args := m.Called(rejected)
+	saved, _ := args.Get(0).([]Rejection)
+	return saved, args.Error(1)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+var now = time.Unix(1704110400, 0)
+
+func newTestValidator(rules []Rule) *Validator {
+	v := NewValidator(rules)
+	v.Now = func() time.Time { return now }
+	return v
+}
+
+func valid() events.TelemetryRawEvent {
+	return events.TelemetryRawEvent{Icao24: "8a0001", Lat: ptr(-6.1), Lon: ptr(106.6), Velocity: ptr(120.0), LastContact: now.Unix()}
+}
+
+func TestValidator_Check(t *testing.T) {
+	v := newTestValidator(DefaultRules(DefaultMaxFuture))
+
+	_, ok := v.Check(valid())
+	assert.True(t, ok)
+
+	unpositioned := valid()
+	unpositioned.Lat, unpositioned.Lon, unpositioned.Velocity = nil, nil, nil
+	_, ok = v.Check(unpositioned)
+	assert.True(t, ok, "missing values are not invalid")
+
+	for reason, broken := range map[string]func(*events.TelemetryRawEvent){
+		ReasonInvalidIcao24:    func(ev *events.TelemetryRawEvent) { ev.Icao24 = "" },
+		ReasonLatitudeRange:    func(ev *events.TelemetryRawEvent) { ev.Lat = ptr(91.0) },
+		ReasonLongitudeRange:   func(ev *events.TelemetryRawEvent) { ev.Lon = ptr(math.NaN()) },
+		ReasonNegativeVelocity: func(ev *events.TelemetryRawEvent) { ev.Velocity = ptr(-1.0) },
+		ReasonFutureContact:    func(ev *events.TelemetryRawEvent) { ev.LastContact = now.Add(time.Hour).Unix() },
+	} {
+		ev := valid()
+		broken(&ev)
+		r, ok := v.Check(ev)
+		assert.False(t, ok, reason)
+		assert.Equal(t, reason, r.Reason)
+		assert.NotEmpty(t, r.Detail)
+	}
+
+	// The first failing rule wins.
+	ev := valid()
+	ev.Icao24 = "xyz"
+	ev.Lat = ptr(-100.0)
+	r, _ := v.Check(ev)
+	assert.Equal(t, ReasonInvalidIcao24, r.Reason)
+}
+
+func TestSelectRules(t *testing.T) {
+	rules, err := SelectRules(nil, DefaultMaxFuture)
+	require.NoError(t, err)
+	assert.Len(t, rules, 5)
+
+	rules, err = SelectRules([]string{ReasonFutureContact, ReasonLatitudeRange}, DefaultMaxFuture)
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	assert.Equal(t, ReasonLatitudeRange, rules[0].Reason)
+
+	_, ok := newTestValidator(rules).Check(events.TelemetryRawEvent{LastContact: now.Unix()})
+	assert.True(t, ok, "icao24 is not checked")
+
+	_, err = SelectRules([]string{"altitude"}, DefaultMaxFuture)
+	assert.Error(t, err)
+}
+
+func TestStage_Filter(t *testing.T) {
+	quarantine := &MockQuarantine{}
+	stage := &Stage{Validator: newTestValidator(DefaultRules(DefaultMaxFuture)), Quarantine: quarantine}
+
+	bad := valid()
+	bad.Velocity = ptr(-5.0)
+	quarantine.On("Save", mock.MatchedBy(func(rejected []Rejection) bool {
+		return len(rejected) == 1 && rejected[0].Reason == ReasonNegativeVelocity && rejected[0].Event.Icao24 == "8a0001"
+	})).Return(nil, errors.New("db down")).Once()
+
+	before := counter(metrics.Get(ReasonNegativeVelocity))
+	_, err := stage.Filter([]events.TelemetryRawEvent{valid(), bad})
+	assert.Error(t, err)
+
+	quarantine.On("Save", mock.Anything).Return([]Rejection{{Event: bad, Reason: ReasonNegativeVelocity}}, nil).Once()
+	passed, err := stage.Filter([]events.TelemetryRawEvent{valid(), bad})
+	require.NoError(t, err)
+	assert.Equal(t, []events.TelemetryRawEvent{valid()}, passed)
+	assert.Equal(t, before+1, counter(metrics.Get(ReasonNegativeVelocity)))
+
+	// A redelivered rejection is already quarantined and not counted again.
+	quarantine.On("Save", mock.Anything).Return(nil, nil).Once()
+	_, err = stage.Filter([]events.TelemetryRawEvent{bad})
+	require.NoError(t, err)
+	assert.Equal(t, before+1, counter(metrics.Get(ReasonNegativeVelocity)))
+
+	passed, err = stage.Filter([]events.TelemetryRawEvent{valid()})
+	require.NoError(t, err)
+	assert.Len(t, passed, 1)
+	quarantine.AssertExpectations(t)
+}
+
+func TestStage_FilterNaN(t *testing.T) {
+	quarantine := &MockQuarantine{}
+	stage := &Stage{Validator: newTestValidator(DefaultRules(DefaultMaxFuture)), Quarantine: quarantine}
+
+	bad := valid()
+	bad.Lon = ptr(math.NaN())
+	quarantine.On("Save", mock.MatchedBy(func(rejected []Rejection) bool {
+		return len(rejected) == 1 && rejected[0].Reason == ReasonLongitudeRange && rejected[0].Detail == "longitude NaN outside ±180"
+	})).Return(nil, nil).Once()
+
+	passed, err := stage.Filter([]events.TelemetryRawEvent{valid(), bad, valid()})
+	require.NoError(t, err)
+	assert.Len(t, passed, 2, "valid events pass alongside the rejected one")
+	quarantine.AssertExpectations(t)
+}
+
+func counter(v expvar.Var) int64 {
+	if v == nil {
+		return 0
+	}
+	return v.(*expvar.Int).Value()
+}
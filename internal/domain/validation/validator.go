@@ -0,0 +1,84 @@
+package validation
+
+import (
+	"expvar"
+	"time"
+
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+// Published through expvar under "validation": events checked, events
+// quarantined and quarantined events per reason code. Checks are counted on
+// every attempt at a batch, quarantined events only once.
+var metrics = expvar.NewMap("validation")
+
+// Rejection is an event that failed a rule. Only the first failing rule is
+// reported.
+type Rejection struct {
+	Event  events.TelemetryRawEvent
+	Reason string
+	Detail string
+}
+
+// Quarantine keeps rejected events for inspection. Save returns the
+// rejections it stored; storing one again is a no-op.
+type Quarantine interface {
+	Save(rejected []Rejection) ([]Rejection, error)
+}
+
+// Validator applies its rules in order.
+type Validator struct {
+	Rules []Rule
+	// Now is the clock future timestamps are judged by.
+	Now func() time.Time
+}
+
+func NewValidator(rules []Rule) *Validator {
+	return &Validator{Rules: rules, Now: time.Now}
+}
+
+// Check returns the rejection of the first rule ev fails.
+func (v *Validator) Check(ev events.TelemetryRawEvent) (Rejection, bool) {
+	now := v.Now()
+	for _, r := range v.Rules {
+		if detail := r.Check(ev, now); detail != "" {
+			return Rejection{Event: ev, Reason: r.Reason, Detail: detail}, false
+		}
+	}
+	return Rejection{}, true
+}
+
+// Stage is the validation step of the processor: it passes valid events on
+// and quarantines the others.
+type Stage struct {
+	Validator  *Validator
+	Quarantine Quarantine
+}
+
+// Filter returns the valid events of a batch. It fails when the rejected
+// ones cannot be quarantined, so the batch is retried rather than losing
+// them.
+func (s *Stage) Filter(raw []events.TelemetryRawEvent) ([]events.TelemetryRawEvent, error) {
+	valid := make([]events.TelemetryRawEvent, 0, len(raw))
+	var rejected []Rejection
+	for _, ev := range raw {
+		if r, ok := s.Validator.Check(ev); ok {
+			valid = append(valid, ev)
+		} else {
+			rejected = append(rejected, r)
+		}
+	}
+
+	metrics.Add("checked", int64(len(raw)))
+	if len(rejected) > 0 {
+		saved, err := s.Quarantine.Save(rejected)
+		if err != nil {
+			return nil, err
+		}
+		metrics.Add("rejected", int64(len(saved)))
+		for _, r := range saved {
+			metrics.Add(r.Reason, 1)
+		}
+	}
+	return valid, nil
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/geofence"
+)
+
+// maxGeofenceBody bounds the request body of a geofence, GeoJSON included.
+const maxGeofenceBody = 1 << 20
+
+// Reloader picks up geofence changes, such as the processor's evaluator.
+type Reloader interface {
+	Reload() error
+}
+
+// geofenceRequest is the body of POST and PUT /geofences. Active defaults to
+// true.
+type geofenceRequest struct {
+	Name     string          `json:"name"`
+	Active   *bool           `json:"active"`
+	Geometry json.RawMessage `json:"geometry"`
+}
+
+func (h *Handler) registerGeofences(mux Mux) {
+	mux.Handle("GET /geofences", http.HandlerFunc(h.listGeofences))
+	mux.Handle("POST /geofences", http.HandlerFunc(h.createGeofence))
+	mux.Handle("GET /geofences/{id}", http.HandlerFunc(h.getGeofence))
+	mux.Handle("PUT /geofences/{id}", http.HandlerFunc(h.updateGeofence))
+	mux.Handle("DELETE /geofences/{id}", http.HandlerFunc(h.deleteGeofence))
+}
+
+// listGeofences handles GET /geofences, active or not, ordered by ID.
+func (h *Handler) listGeofences(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePage(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	fences, err := h.Geofences.List(page.Limit, page.Offset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newPage(fences, page))
+}
+
+// createGeofence handles POST /geofences.
+func (h *Handler) createGeofence(w http.ResponseWriter, r *http.Request) {
+	f, err := readGeofence(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	created, err := h.Geofences.Create(f)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	h.reloadGeofences()
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// getGeofence handles GET /geofences/{id}.
+func (h *Handler) getGeofence(w http.ResponseWriter, r *http.Request) {
+	id, err := geofenceID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	f, err := h.Geofences.Get(id)
+	if err != nil {
+		writeGeofenceError(w, id, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, f)
+}
+
+// updateGeofence handles PUT /geofences/{id}, replacing the fence.
+func (h *Handler) updateGeofence(w http.ResponseWriter, r *http.Request) {
+	id, err := geofenceID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	f, err := readGeofence(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	f.ID = id
+	updated, err := h.Geofences.Update(f)
+	if err != nil {
+		writeGeofenceError(w, id, err)
+		return
+	}
+	h.reloadGeofences()
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// deleteGeofence handles DELETE /geofences/{id}.
+func (h *Handler) deleteGeofence(w http.ResponseWriter, r *http.Request) {
+	id, err := geofenceID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := h.Geofences.Delete(id); err != nil {
+		writeGeofenceError(w, id, err)
+		return
+	}
+	h.reloadGeofences()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// reloadGeofences applies a change right away. The change is stored, so a
+// failure only delays it until the next periodic reload.
+func (h *Handler) reloadGeofences() {
+	if h.Fences == nil {
+		return
+	}
+	if err := h.Fences.Reload(); err != nil {
+		log.Printf("Failed to reload geofences: %v", err)
+	}
+}
+
+func readGeofence(w http.ResponseWriter, r *http.Request) (geofence.Fence, error) {
+	var req geofenceRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxGeofenceBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		return geofence.Fence{}, fmt.Errorf("invalid body: %w", err)
+	}
+
+	f := geofence.Fence{Name: req.Name, Active: true, Geometry: req.Geometry}
+	if req.Active != nil {
+		f.Active = *req.Active
+	}
+	if err := f.Validate(); err != nil {
+		return f, err
+	}
+	return f, nil
+}
+
+func geofenceID(r *http.Request) (int64, error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("geofence id must be a positive integer")
+	}
+	return id, nil
+}
+
+func writeGeofenceError(w http.ResponseWriter, id int64, err error) {
+	if errors.Is(err, geofence.ErrNotFound) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("geofence %d not found", id))
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err)
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/geofence"
+	"github.com/dandyZicky/opensky-collector/internal/domain/processor/processortest"
+)
+
+const square = `{"type":"Polygon","coordinates":[[[106.5,-6.5],[107,-6.5],[107,-6],[106.5,-6],[106.5,-6.5]]]}`
+
+type MockGeofenceRepository struct {
+	mock.Mock
+}
+
+func (m *MockGeofenceRepository) List(limit, offset int) ([]geofence.Fence, error) {
+	args := m.Called(limit, offset)
+	return args.Get(0).([]geofence.Fence), args.Error(1)
+}
+
+func (m *MockGeofenceRepository) Active() ([]geofence.Fence, error) {
+	args := m.Called()
+	return args.Get(0).([]geofence.Fence), args.Error(1)
+}
+
+func (m *MockGeofenceRepository) Get(id int64) (geofence.Fence, error) {
+	args := m.Called(id)
+	return args.Get(0).(geofence.Fence), args.Error(1)
+}
+
+func (m *MockGeofenceRepository) Create(f geofence.Fence) (geofence.Fence, error) {
+	args := m.Called(f)
+	return args.Get(0).(geofence.Fence), args.Error(1)
+}
+
+func (m *MockGeofenceRepository) Update(f geofence.Fence) (geofence.Fence, error) {
+	args := m.Called(f)
+	return args.Get(0).(geofence.Fence), args.Error(1)
+}
+
+func (m *MockGeofenceRepository) Delete(id int64) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+type MockReloader struct {
+	mock.Mock
+}
+
+func (m *MockReloader) Reload() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func serveGeofences(t *testing.T, repo geofence.Repository, reloader Reloader, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	mux := http.NewServeMux()
+	h := NewHandler(&processortest.MockFlightRepository{})
+	h.Geofences = repo
+	h.Fences = reloader
+	h.Register(mux)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return rec
+}
+
+func TestHandler_CreateGeofence(t *testing.T) {
+	repo := &MockGeofenceRepository{}
+	reloader := &MockReloader{}
+	repo.On("Create", geofence.Fence{Name: "Jakarta", Active: true, Geometry: json.RawMessage(square)}).
+		Return(geofence.Fence{ID: 7, Name: "Jakarta", Active: true, Geometry: json.RawMessage(square)}, nil)
+	reloader.On("Reload").Return(nil).Once()
+
+	rec := serveGeofences(t, repo, reloader, http.MethodPost, "/geofences", `{"name":"Jakarta","geometry":`+square+`}`)
+
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var f geofence.Fence
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &f))
+	assert.Equal(t, int64(7), f.ID)
+	reloader.AssertExpectations(t)
+}
+
+func TestHandler_CreateGeofence_Invalid(t *testing.T) {
+	repo := &MockGeofenceRepository{}
+	for _, body := range []string{
+		`{"geometry":` + square + `}`,
+		`{"name":"Point","geometry":{"type":"Point","coordinates":[106.6,-6.1]}}`,
+		`{"name":"Jakarta","geometry":` + square + `,"color":"red"}`,
+		`not json`,
+	} {
+		rec := serveGeofences(t, repo, nil, http.MethodPost, "/geofences", body)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+	repo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestHandler_UpdateGeofence(t *testing.T) {
+	repo := &MockGeofenceRepository{}
+	reloader := &MockReloader{}
+	repo.On("Update", geofence.Fence{ID: 7, Name: "Jakarta", Active: false, Geometry: json.RawMessage(square)}).
+		Return(geofence.Fence{ID: 7, Name: "Jakarta"}, nil)
+	repo.On("Update", mock.MatchedBy(func(f geofence.Fence) bool { return f.ID == 8 })).
+		Return(geofence.Fence{}, geofence.ErrNotFound)
+	reloader.On("Reload").Return(nil).Once()
+
+	body := `{"name":"Jakarta","active":false,"geometry":` + square + `}`
+	rec := serveGeofences(t, repo, reloader, http.MethodPut, "/geofences/7", body)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serveGeofences(t, repo, reloader, http.MethodPut, "/geofences/8", body)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	reloader.AssertExpectations(t)
+}
+
+func TestHandler_GetAndDeleteGeofence(t *testing.T) {
+	repo := &MockGeofenceRepository{}
+	repo.On("Get", int64(7)).Return(geofence.Fence{ID: 7, Name: "Jakarta"}, nil)
+	repo.On("Get", int64(8)).Return(geofence.Fence{}, geofence.ErrNotFound)
+	repo.On("Delete", int64(7)).Return(nil)
+	repo.On("List", 100, 0).Return([]geofence.Fence{{ID: 7}}, nil)
+
+	assert.Equal(t, http.StatusOK, serveGeofences(t, repo, nil, http.MethodGet, "/geofences/7", "").Code)
+	assert.Equal(t, http.StatusNotFound, serveGeofences(t, repo, nil, http.MethodGet, "/geofences/8", "").Code)
+	assert.Equal(t, http.StatusBadRequest, serveGeofences(t, repo, nil, http.MethodGet, "/geofences/abc", "").Code)
+	assert.Equal(t, http.StatusNoContent, serveGeofences(t, repo, nil, http.MethodDelete, "/geofences/7", "").Code)
+
+	rec := serveGeofences(t, repo, nil, http.MethodGet, "/geofences", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var page Page[geofence.Fence]
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+	assert.Len(t, page.Items, 1)
+}
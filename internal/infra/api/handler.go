@@ -0,0 +1,281 @@
+// Package api serves the stored flight history over HTTP.
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/flight"
+	"github.com/dandyZicky/opensky-collector/internal/domain/geofence"
+	"github.com/dandyZicky/opensky-collector/internal/domain/processor"
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+const (
+	// Queries without from default to this much history before to.
+	defaultRange = 24 * time.Hour
+	// A snapshot includes aircraft whose latest position is at most this old,
+	// unless max_age says otherwise.
+	defaultMaxAge = 5 * time.Minute
+)
+
+// Mux is satisfied by *http.ServeMux and by servers that mount handlers.
+type Mux interface {
+	Handle(pattern string, handler http.Handler)
+}
+
+// Snapshotter provides the current state of every live aircraft.
+type Snapshotter interface {
+	Snapshot() []events.TelemetryRawEvent
+}
+
+type Handler struct {
+	Flights processor.FlightRepository
+	// Live, when set, serves GET /flights/live.
+	Live Snapshotter
+	// Geofences, when set, serves the /geofences endpoints. Fences, when
+	// set, is reloaded after every change.
+	Geofences geofence.Repository
+	Fences    Reloader
+}
+
+func NewHandler(flights processor.FlightRepository) *Handler {
+	return &Handler{Flights: flights}
+}
+
+// Page is the envelope of every list response. NextOffset is set when the
+// page is full and more results may follow.
+type Page[T any] struct {
+	Items      []T  `json:"items"`
+	Limit      int  `json:"limit"`
+	Offset     int  `json:"offset"`
+	NextOffset *int `json:"next_offset,omitempty"`
+}
+
+func (h *Handler) Register(mux Mux) {
+	mux.Handle("GET /flights", http.HandlerFunc(h.snapshot))
+	mux.Handle("GET /flights/{icao24}/track", http.HandlerFunc(h.track))
+	mux.Handle("GET /aircraft/{icao24}", http.HandlerFunc(h.aircraft))
+	mux.Handle("GET /aircraft/{icao24}/flights", http.HandlerFunc(h.flights))
+	mux.Handle("GET /stats", http.HandlerFunc(h.stats))
+	if h.Live != nil {
+		mux.Handle("GET /flights/live", http.HandlerFunc(h.live))
+	}
+	if h.Geofences != nil {
+		h.registerGeofences(mux)
+	}
+}
+
+// live handles GET /flights/live with every aircraft currently reporting.
+// It is not paginated: the snapshot is already bounded by the polled
+// regions.
+func (h *Handler) live(w http.ResponseWriter, r *http.Request) {
+	states := h.Live.Snapshot()
+	writeJSON(w, http.StatusOK, map[string]any{
+		"timestamp": time.Now().Unix(),
+		"count":     len(states),
+		"states":    states,
+	})
+}
+
+// track handles GET /flights/{icao24}/track?from&to.
+func (h *Handler) track(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, err := parsePage(q)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	from, to, err := parseRange(q)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	states, err := h.Flights.Track(strings.ToLower(r.PathValue("icao24")), from, to, page)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newPage(states, page))
+}
+
+// snapshot handles GET /flights?bbox&at&max_age.
+func (h *Handler) snapshot(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, err := parsePage(q)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	box, err := flight.ParseBoundingBox(q.Get("bbox"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	at := time.Now()
+	if v := q.Get("at"); v != "" {
+		if at, err = parseTime(v); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("at: %w", err))
+			return
+		}
+	}
+	maxAge := defaultMaxAge
+	if v := q.Get("max_age"); v != "" {
+		if maxAge, err = time.ParseDuration(v); err != nil || maxAge <= 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("max_age must be a positive duration such as 5m"))
+			return
+		}
+	}
+
+	states, err := h.Flights.Snapshot(box, at, maxAge, page)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newPage(states, page))
+}
+
+// aircraft handles GET /aircraft/{icao24}.
+func (h *Handler) aircraft(w http.ResponseWriter, r *http.Request) {
+	state, err := h.Flights.LastKnown(strings.ToLower(r.PathValue("icao24")))
+	if errors.Is(err, processor.ErrNotFound) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("aircraft %s not found", r.PathValue("icao24")))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, state)
+}
+
+// flights handles GET /aircraft/{icao24}/flights?from&to.
+func (h *Handler) flights(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, err := parsePage(q)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	from, to, err := parseRange(q)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	flights, err := h.Flights.Flights(strings.ToLower(r.PathValue("icao24")), from, to, page)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newPage(flights, page))
+}
+
+// stats handles GET /stats?from&to.
+func (h *Handler) stats(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, err := parsePage(q)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	from, to, err := parseRange(q)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	stats, err := h.Flights.Stats(from, to, page)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newPage(stats, page))
+}
+
+func newPage[T any](items []T, page processor.Page) Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	p := Page[T]{Items: items, Limit: page.Limit, Offset: page.Offset}
+	if len(items) == page.Limit {
+		next := page.Offset + page.Limit
+		p.NextOffset = &next
+	}
+	return p
+}
+
+func parsePage(q url.Values) (processor.Page, error) {
+	page := processor.Page{Limit: processor.DefaultPageLimit}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > processor.MaxPageLimit {
+			return page, fmt.Errorf("limit must be between 1 and %d", processor.MaxPageLimit)
+		}
+		page.Limit = limit
+	}
+	if v := q.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return page, fmt.Errorf("offset must be a non-negative integer")
+		}
+		page.Offset = offset
+	}
+	return page, nil
+}
+
+// parseRange reads from and to, defaulting to the defaultRange before now.
+func parseRange(q url.Values) (from, to time.Time, err error) {
+	to = time.Now()
+	if v := q.Get("to"); v != "" {
+		if to, err = parseTime(v); err != nil {
+			return from, to, fmt.Errorf("to: %w", err)
+		}
+	}
+	from = to.Add(-defaultRange)
+	if v := q.Get("from"); v != "" {
+		if from, err = parseTime(v); err != nil {
+			return from, to, fmt.Errorf("from: %w", err)
+		}
+	}
+	if !from.Before(to) {
+		return from, to, fmt.Errorf("from must be before to")
+	}
+	return from, to, nil
+}
+
+// parseTime accepts unix seconds, like the rest of the pipeline, or RFC 3339.
+func parseTime(v string) (time.Time, error) {
+	if sec, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(sec, 0), nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return t, fmt.Errorf("%q is neither unix seconds nor RFC 3339", v)
+	}
+	return t, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	if status >= http.StatusInternalServerError {
+		log.Printf("API error: %v", err)
+		err = errors.New(http.StatusText(status))
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
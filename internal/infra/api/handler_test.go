@@ -0,0 +1,157 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/flight"
+	"github.com/dandyZicky/opensky-collector/internal/domain/processor"
+	"github.com/dandyZicky/opensky-collector/internal/domain/processor/processortest"
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+type MockSnapshotter struct {
+	mock.Mock
+}
+
+func (m *MockSnapshotter) Snapshot() []events.TelemetryRawEvent {
+	args := m.Called()
+	return args.Get(0).([]events.TelemetryRawEvent)
+}
+
+func serve(t *testing.T, repo processor.FlightRepository, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	mux := http.NewServeMux()
+	NewHandler(repo).Register(mux)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+func TestHandler_Track(t *testing.T) {
+	repo := &processortest.MockFlightRepository{}
+	from, to := time.Unix(1700000000, 0), time.Unix(1700003600, 0)
+	repo.On("Track", "8a0001", from, to, processor.Page{Limit: 2, Offset: 4}).Return([]flight.FlightState{
+		{Icao24: "8a0001"}, {Icao24: "8a0001"},
+	}, nil)
+
+	rec := serve(t, repo, "/flights/8A0001/track?from=1700000000&to=1700003600&limit=2&offset=4")
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var page Page[flight.FlightState]
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+	assert.Len(t, page.Items, 2)
+	require.NotNil(t, page.NextOffset)
+	assert.Equal(t, 6, *page.NextOffset)
+	repo.AssertExpectations(t)
+}
+
+func TestHandler_Track_InvalidRange(t *testing.T) {
+	rec := serve(t, &processortest.MockFlightRepository{}, "/flights/8a0001/track?from=1700003600&to=1700000000")
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestHandler_Snapshot(t *testing.T) {
+	repo := &processortest.MockFlightRepository{}
+	box := &flight.BoundingBox{LaMin: -9, LoMin: 105, LaMax: -5, LoMax: 115}
+	at := time.Unix(1700000000, 0)
+	repo.On("Snapshot", box, at, 10*time.Minute, processor.Page{Limit: processor.DefaultPageLimit}).Return([]flight.FlightState{
+		{Icao24: "8a0001"},
+	}, nil)
+
+	rec := serve(t, repo, "/flights?bbox=-9,105,-5,115&at=1700000000&max_age=10m")
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var page Page[flight.FlightState]
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+	assert.Len(t, page.Items, 1)
+	assert.Nil(t, page.NextOffset)
+	repo.AssertExpectations(t)
+}
+
+func TestHandler_Snapshot_InvalidParams(t *testing.T) {
+	for _, target := range []string{
+		"/flights?bbox=1,2,3",
+		"/flights?bbox=5,0,-5,10",
+		"/flights?at=yesterday",
+		"/flights?limit=0",
+		"/flights?limit=5000",
+		"/flights?offset=-1",
+	} {
+		rec := serve(t, &processortest.MockFlightRepository{}, target)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, target)
+	}
+}
+
+func TestHandler_Aircraft(t *testing.T) {
+	repo := &processortest.MockFlightRepository{}
+	repo.On("LastKnown", "8a0001").Return(flight.FlightState{Icao24: "8a0001", OriginCountry: "Indonesia"}, nil)
+	repo.On("LastKnown", "ffffff").Return(flight.FlightState{}, processor.ErrNotFound)
+
+	rec := serve(t, repo, "/aircraft/8a0001")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var state flight.FlightState
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &state))
+	assert.Equal(t, "Indonesia", state.OriginCountry)
+
+	rec = serve(t, repo, "/aircraft/ffffff")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestHandler_Flights(t *testing.T) {
+	repo := &processortest.MockFlightRepository{}
+	from, to := time.Unix(1700000000, 0), time.Unix(1700086400, 0)
+	repo.On("Flights", "8a0001", from, to, processor.Page{Limit: processor.DefaultPageLimit}).Return([]flight.Flight{
+		{Icao24: "8a0001", StartTime: from, EndTime: from.Add(time.Hour), DistanceM: 650000},
+	}, nil)
+
+	rec := serve(t, repo, "/aircraft/8A0001/flights?from=1700000000&to=1700086400")
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var page Page[flight.Flight]
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, 650000.0, page.Items[0].DistanceM)
+	repo.AssertExpectations(t)
+}
+
+func TestHandler_Stats_RepositoryError(t *testing.T) {
+	repo := &processortest.MockFlightRepository{}
+	repo.On("Stats", mock.Anything, mock.Anything, processor.Page{Limit: processor.DefaultPageLimit}).
+		Return([]processor.CountryHourStats(nil), errors.New("connection refused"))
+
+	rec := serve(t, repo, "/stats")
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "connection refused")
+}
+
+func TestHandler_Live(t *testing.T) {
+	live := &MockSnapshotter{}
+	live.On("Snapshot").Return([]events.TelemetryRawEvent{{Icao24: "8a0001"}, {Icao24: "8a0002"}})
+
+	mux := http.NewServeMux()
+	h := NewHandler(&processortest.MockFlightRepository{})
+	h.Live = live
+	h.Register(mux)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/flights/live", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body struct {
+		Count  int                        `json:"count"`
+		States []events.TelemetryRawEvent `json:"states"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, 2, body.Count)
+	assert.Equal(t, "8a0002", body.States[1].Icao24)
+}
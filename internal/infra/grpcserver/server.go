@@ -0,0 +1,153 @@
+// Package grpcserver exposes live and stored flight states over gRPC.
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/flight"
+	"github.com/dandyZicky/opensky-collector/internal/domain/processor"
+	"github.com/dandyZicky/opensky-collector/internal/infra/sse"
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+	openskyv1 "github.com/dandyZicky/opensky-collector/pkg/pb/opensky/v1"
+)
+
+// GetTrack without from covers this much history before to.
+const defaultTrackRange = 24 * time.Hour
+
+// Streamer feeds StreamFlights; *sse.SSEBroadcaster implements it.
+type Streamer interface {
+	Subscribe(ctx context.Context, filter sse.Filter, lastEventID uint64, send func(sse.Update) error) error
+}
+
+type Server struct {
+	openskyv1.UnimplementedFlightServiceServer
+
+	Flights processor.FlightRepository
+	Stream  Streamer
+}
+
+func NewServer(flights processor.FlightRepository, stream Streamer) *Server {
+	return &Server{Flights: flights, Stream: stream}
+}
+
+func (s *Server) Register(gs *grpc.Server) {
+	openskyv1.RegisterFlightServiceServer(gs, s)
+}
+
+func (s *Server) StreamFlights(req *openskyv1.Filter, stream grpc.ServerStreamingServer[openskyv1.FlightUpdate]) error {
+	filter, err := toFilter(req)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	err = s.Stream.Subscribe(stream.Context(), filter, req.GetLastEventId(), func(u sse.Update) error {
+		return stream.Send(toFlightUpdate(u))
+	})
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case errors.Is(err, sse.ErrStreamClosed):
+		// The client fell behind or the server is stopping; it can
+		// resume with the last id it received.
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return err
+}
+
+func (s *Server) GetAircraft(ctx context.Context, req *openskyv1.GetAircraftRequest) (*openskyv1.FlightState, error) {
+	if req.GetIcao24() == "" {
+		return nil, status.Error(codes.InvalidArgument, "icao24 is required")
+	}
+
+	state, err := s.Flights.LastKnown(strings.ToLower(req.GetIcao24()))
+	if errors.Is(err, processor.ErrNotFound) {
+		return nil, status.Errorf(codes.NotFound, "aircraft %s not found", req.GetIcao24())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return events.TelemetryRawEventToProto(flight.FlightStateToEvent(state)), nil
+}
+
+func (s *Server) GetTrack(ctx context.Context, req *openskyv1.GetTrackRequest) (*openskyv1.GetTrackResponse, error) {
+	if req.GetIcao24() == "" {
+		return nil, status.Error(codes.InvalidArgument, "icao24 is required")
+	}
+
+	page := processor.Page{Limit: int(req.GetLimit()), Offset: int(req.GetOffset())}
+	if page.Limit == 0 {
+		page.Limit = processor.DefaultPageLimit
+	}
+	if page.Limit < 0 || page.Limit > processor.MaxPageLimit || page.Offset < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "limit must be between 1 and %d and offset non-negative", processor.MaxPageLimit)
+	}
+
+	to := time.Now()
+	if req.GetTo() != 0 {
+		to = time.Unix(req.GetTo(), 0)
+	}
+	from := to.Add(-defaultTrackRange)
+	if req.GetFrom() != 0 {
+		from = time.Unix(req.GetFrom(), 0)
+	}
+	if !from.Before(to) {
+		return nil, status.Error(codes.InvalidArgument, "from must be before to")
+	}
+
+	states, err := s.Flights.Track(strings.ToLower(req.GetIcao24()), from, to, page)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &openskyv1.GetTrackResponse{States: make([]*openskyv1.FlightState, 0, len(states))}
+	for _, state := range states {
+		resp.States = append(resp.States, events.TelemetryRawEventToProto(flight.FlightStateToEvent(state)))
+	}
+	return resp, nil
+}
+
+// toFilter goes through sse.FilterSpec so gRPC filters are validated exactly
+// like SSE and WebSocket ones.
+func toFilter(req *openskyv1.Filter) (sse.Filter, error) {
+	spec := sse.FilterSpec{
+		Icao24:        req.GetIcao24(),
+		OriginCountry: req.GetOriginCountry(),
+		MinAltitude:   req.MinAltitude,
+		MaxAltitude:   req.MaxAltitude,
+		OnGround:      req.OnGround,
+		Callsign:      req.GetCallsignPrefix(),
+	}
+	if box := req.GetBbox(); box != nil {
+		spec.BBox = []float64{box.GetLamin(), box.GetLomin(), box.GetLamax(), box.GetLomax()}
+	}
+	return spec.Filter()
+}
+
+func toFlightUpdate(u sse.Update) *openskyv1.FlightUpdate {
+	if u.Geofence != nil {
+		return &openskyv1.FlightUpdate{
+			Payload: &openskyv1.FlightUpdate_Geofence{Geofence: events.GeofenceEventToProto(*u.Geofence)},
+		}
+	}
+	if !u.Snapshot {
+		return &openskyv1.FlightUpdate{
+			Id:      u.ID,
+			Payload: &openskyv1.FlightUpdate_State{State: events.TelemetryRawEventToProto(u.State)},
+		}
+	}
+	snapshot := &openskyv1.Snapshot{States: make([]*openskyv1.FlightState, 0, len(u.States))}
+	for _, state := range u.States {
+		snapshot.States = append(snapshot.States, events.TelemetryRawEventToProto(state))
+	}
+	return &openskyv1.FlightUpdate{
+		Id:      u.ID,
+		Payload: &openskyv1.FlightUpdate_Snapshot{Snapshot: snapshot},
+	}
+}
@@ -0,0 +1,153 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/flight"
+	"github.com/dandyZicky/opensky-collector/internal/domain/processor"
+	"github.com/dandyZicky/opensky-collector/internal/domain/processor/processortest"
+	"github.com/dandyZicky/opensky-collector/internal/infra/sse"
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+	openskyv1 "github.com/dandyZicky/opensky-collector/pkg/pb/opensky/v1"
+)
+
+type staticSnapshot []events.TelemetryRawEvent
+
+func (s staticSnapshot) Snapshot() []events.TelemetryRawEvent {
+	return s
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// newTestClient serves a Server over an in-process bufconn listener.
+func newTestClient(t *testing.T, repo processor.FlightRepository) (openskyv1.FlightServiceClient, *sse.SSEBroadcaster) {
+	ctx, cancel := context.WithCancel(context.Background())
+	broadcaster := sse.NewSSEBroadcaster(ctx, sse.Config{ReplayBuffer: 10, ClientBuffer: 4, MaxDrops: 2})
+	broadcaster.Live = staticSnapshot{{Icao24: "8a0001", OnGround: true}, {Icao24: "8a0002"}}
+	go broadcaster.Run()
+
+	lis := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	NewServer(repo, broadcaster).Register(gs)
+	go gs.Serve(lis)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		conn.Close()
+		cancel()
+		gs.Stop()
+	})
+	return openskyv1.NewFlightServiceClient(conn), broadcaster
+}
+
+func TestServer_StreamFlights(t *testing.T) {
+	client, broadcaster := newTestClient(t, &processortest.MockFlightRepository{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.StreamFlights(ctx, &openskyv1.Filter{OnGround: ptr(false)})
+	require.NoError(t, err)
+
+	first, err := stream.Recv()
+	require.NoError(t, err)
+	snapshot := first.GetSnapshot()
+	require.NotNil(t, snapshot)
+	require.Len(t, snapshot.States, 1)
+	assert.Equal(t, "8a0002", snapshot.States[0].Icao24)
+
+	require.NoError(t, broadcaster.Broadcast([]events.TelemetryRawEvent{
+		{Icao24: "8a0003", OnGround: true},
+		{Icao24: "8a0004", Lat: ptr(-6.1), BaroAltitude: ptr(9000.0)},
+	}))
+
+	next, err := stream.Recv()
+	require.NoError(t, err)
+	state := next.GetState()
+	require.NotNil(t, state)
+	assert.Equal(t, "8a0004", state.Icao24)
+	assert.Equal(t, -6.1, state.GetLat())
+	assert.Nil(t, state.Lon)
+	assert.Equal(t, first.Id+2, next.Id)
+
+	require.NoError(t, broadcaster.BroadcastGeofenceEvents([]events.GeofenceEvent{
+		{Type: events.GeofenceEnter, Icao24: "8a0004", Callsign: ptr("GIA402"), FenceID: 1, FenceName: "Jakarta TMA", Time: 1704110420, Lat: -6.3, Lon: 106.6},
+	}))
+
+	entered, err := stream.Recv()
+	require.NoError(t, err)
+	fence := entered.GetGeofence()
+	require.NotNil(t, fence)
+	assert.Zero(t, entered.Id)
+	assert.Equal(t, events.GeofenceEnter, fence.Type)
+	assert.Equal(t, "GIA402", fence.GetCallsign())
+	assert.Equal(t, int64(1), fence.FenceId)
+	assert.Equal(t, "Jakarta TMA", fence.FenceName)
+}
+
+func TestServer_StreamFlights_InvalidFilter(t *testing.T) {
+	client, _ := newTestClient(t, &processortest.MockFlightRepository{})
+
+	stream, err := client.StreamFlights(context.Background(), &openskyv1.Filter{
+		Bbox: &openskyv1.BoundingBox{Lamin: 10, Lamax: -10},
+	})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServer_GetAircraft(t *testing.T) {
+	repo := &processortest.MockFlightRepository{}
+	client, _ := newTestClient(t, repo)
+	at := time.Unix(1700000000, 0)
+	repo.On("LastKnown", "8a0001").Return(flight.FlightState{
+		Icao24:       "8a0001",
+		Callsign:     ptr("GIA402"),
+		TimePosition: &at,
+		LastContact:  at,
+	}, nil)
+	repo.On("LastKnown", "ffffff").Return(flight.FlightState{}, processor.ErrNotFound)
+
+	state, err := client.GetAircraft(context.Background(), &openskyv1.GetAircraftRequest{Icao24: "8A0001"})
+	require.NoError(t, err)
+	assert.Equal(t, "GIA402", state.GetCallsign())
+	assert.Equal(t, int64(1700000000), state.GetTimePosition())
+
+	_, err = client.GetAircraft(context.Background(), &openskyv1.GetAircraftRequest{Icao24: "ffffff"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestServer_GetTrack(t *testing.T) {
+	repo := &processortest.MockFlightRepository{}
+	client, _ := newTestClient(t, repo)
+	from, to := time.Unix(1700000000, 0), time.Unix(1700003600, 0)
+	repo.On("Track", "8a0001", from, to, processor.Page{Limit: processor.DefaultPageLimit}).Return([]flight.FlightState{
+		{Icao24: "8a0001", LastContact: from},
+		{Icao24: "8a0001", LastContact: to},
+	}, nil)
+
+	resp, err := client.GetTrack(context.Background(), &openskyv1.GetTrackRequest{Icao24: "8a0001", From: from.Unix(), To: to.Unix()})
+	require.NoError(t, err)
+	require.Len(t, resp.States, 2)
+	assert.Equal(t, to.Unix(), resp.States[1].LastContact)
+
+	_, err = client.GetTrack(context.Background(), &openskyv1.GetTrackRequest{Icao24: "8a0001", Limit: 5000})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
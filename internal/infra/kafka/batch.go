@@ -0,0 +1,95 @@
+package kafka
+
+import (
+	"sort"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+// batch holds the events polled since the last flush together with the
+// messages they came from, so offsets can be stored or rewound per partition
+// once the batch has been handled. Messages that could not be decoded are
+// kept as dead letters and still count towards the offsets.
+type batch struct {
+	events   []events.TelemetryRawEvent
+	sources  []*kafka.Message // sources[i] is the message events[i] came from
+	dead     []events.DeadLetter
+	messages []*kafka.Message
+	started  time.Time
+}
+
+type partitionKey struct {
+	topic     string
+	partition int32
+}
+
+func (b *batch) add(msg *kafka.Message, event events.TelemetryRawEvent) {
+	b.start()
+	b.messages = append(b.messages, msg)
+	b.sources = append(b.sources, msg)
+	b.events = append(b.events, event)
+}
+
+func (b *batch) addDead(msg *kafka.Message, reason string, cause error) {
+	b.start()
+	b.messages = append(b.messages, msg)
+	b.dead = append(b.dead, NewDeadLetter(msg, reason, cause))
+}
+
+// deadLetterEvents moves every decoded event of the batch to the dead
+// letters, for a batch that could not be persisted.
+func (b *batch) deadLetterEvents(cause error) {
+	for _, msg := range b.sources {
+		b.dead = append(b.dead, NewDeadLetter(msg, events.DeadLetterPersist, cause))
+	}
+	b.events = nil
+	b.sources = nil
+}
+
+func (b *batch) len() int {
+	return len(b.messages)
+}
+
+func (b *batch) start() {
+	if len(b.messages) == 0 {
+		b.started = time.Now()
+	}
+}
+
+// nextOffsets returns, per partition, the offset following the last message
+// in the batch. That is the offset to commit once the batch is persisted.
+func (b *batch) nextOffsets() []kafka.TopicPartition {
+	return b.offsets(func(current, candidate kafka.Offset) bool { return candidate > current }, 1)
+}
+
+// firstOffsets returns, per partition, the offset of the first message in
+// the batch. That is where to seek back to when the batch must be retried.
+func (b *batch) firstOffsets() []kafka.TopicPartition {
+	return b.offsets(func(current, candidate kafka.Offset) bool { return candidate < current }, 0)
+}
+
+func (b *batch) offsets(better func(current, candidate kafka.Offset) bool, delta kafka.Offset) []kafka.TopicPartition {
+	byPartition := make(map[partitionKey]kafka.TopicPartition)
+	for _, msg := range b.messages {
+		tp := msg.TopicPartition
+		key := partitionKey{topic: *tp.Topic, partition: tp.Partition}
+		if current, ok := byPartition[key]; !ok || better(current.Offset, tp.Offset) {
+			byPartition[key] = kafka.TopicPartition{Topic: tp.Topic, Partition: tp.Partition, Offset: tp.Offset}
+		}
+	}
+
+	offsets := make([]kafka.TopicPartition, 0, len(byPartition))
+	for _, tp := range byPartition {
+		tp.Offset += delta
+		offsets = append(offsets, tp)
+	}
+	sort.Slice(offsets, func(i, j int) bool {
+		if *offsets[i].Topic != *offsets[j].Topic {
+			return *offsets[i].Topic < *offsets[j].Topic
+		}
+		return offsets[i].Partition < offsets[j].Partition
+	})
+	return offsets
+}
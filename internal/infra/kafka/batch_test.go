@@ -0,0 +1,69 @@
+package kafka
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+func message(topic *string, partition int32, offset kafka.Offset) *kafka.Message {
+	return &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: topic, Partition: partition, Offset: offset},
+	}
+}
+
+func TestBatch_Offsets(t *testing.T) {
+	topic := "telemetry.raw"
+	var b batch
+	b.add(message(&topic, 1, 40), events.TelemetryRawEvent{})
+	b.add(message(&topic, 0, 10), events.TelemetryRawEvent{})
+	b.add(message(&topic, 1, 41), events.TelemetryRawEvent{})
+	b.add(message(&topic, 0, 12), events.TelemetryRawEvent{})
+	b.add(message(&topic, 0, 11), events.TelemetryRawEvent{})
+
+	next := b.nextOffsets()
+	assert.Len(t, next, 2)
+	assert.Equal(t, int32(0), next[0].Partition)
+	assert.Equal(t, kafka.Offset(13), next[0].Offset)
+	assert.Equal(t, int32(1), next[1].Partition)
+	assert.Equal(t, kafka.Offset(42), next[1].Offset)
+
+	first := b.firstOffsets()
+	assert.Len(t, first, 2)
+	assert.Equal(t, kafka.Offset(10), first[0].Offset)
+	assert.Equal(t, kafka.Offset(40), first[1].Offset)
+}
+
+func TestBatch_Empty(t *testing.T) {
+	var b batch
+	assert.Equal(t, 0, b.len())
+	assert.Empty(t, b.nextOffsets())
+	assert.Empty(t, b.firstOffsets())
+}
+
+func TestBatch_DeadLetters(t *testing.T) {
+	topic := "telemetry.raw"
+	var b batch
+	b.add(message(&topic, 0, 5), events.TelemetryRawEvent{Icao24: "8a0377"})
+	b.addDead(message(&topic, 0, 6), events.DeadLetterDecode, errors.New("invalid character"))
+	b.add(message(&topic, 0, 7), events.TelemetryRawEvent{Icao24: "8a0378"})
+
+	assert.Equal(t, 3, b.len())
+	assert.Len(t, b.events, 2)
+	assert.Len(t, b.dead, 1)
+	assert.Equal(t, int64(6), b.dead[0].Offset)
+	assert.Equal(t, kafka.Offset(8), b.nextOffsets()[0].Offset)
+
+	b.deadLetterEvents(errors.New("database connection failed"))
+
+	assert.Empty(t, b.events)
+	assert.Len(t, b.dead, 3)
+	assert.Equal(t, events.DeadLetterPersist, b.dead[1].Reason)
+	assert.Equal(t, "telemetry.raw", b.dead[1].Topic)
+	assert.Equal(t, int64(5), b.dead[1].Offset)
+	assert.Equal(t, kafka.Offset(8), b.nextOffsets()[0].Offset)
+}
@@ -2,25 +2,69 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"log"
+	"time"
 
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/dandyZicky/opensky-collector/internal/domain/processor"
 	"github.com/dandyZicky/opensky-collector/pkg/events"
+	"github.com/dandyZicky/opensky-collector/pkg/retry"
 )
 
 const (
-	ConnTimeoutMs = 5000
-	SubTimeoutMs  = 5000
-	batchSize     = 100
+	// A batch that still fails after processAttempts is rewound and its
+	// partitions are paused for pauseDuration before it is retried, unless
+	// the error says it never will succeed.
+	processAttempts = 3
+	processBackoff  = time.Second
+	pauseDuration   = 30 * time.Second
 )
 
+// ConsumerConfig bounds batches: a batch is flushed once it holds BatchSize
+// messages or its first message is MaxLingerMs old, whichever comes first.
+// SubTimeoutMs is the Poll timeout and so also the worst-case overshoot of
+// the linger time on an idle topic.
+type ConsumerConfig struct {
+	BatchSize     int
+	MaxLingerMs   int
+	SubTimeoutMs  int
+	ConnTimeoutMs int
+}
+
+// DeadLetterPublisher sends messages that cannot be handled to the
+// dead-letter topic. The consumer commits their offsets as soon as it
+// returns, so a letter it loses is gone for good.
+type DeadLetterPublisher interface {
+	PublishDeadLetters(letters []events.DeadLetter, topic events.Topic) error
+}
+
+// KafkaConsumer consumes with at-least-once semantics: offsets are stored and
+// committed only after a batch has been processed successfully, or after its
+// messages have been handed to the dead-letter topic. A batch that fails for
+// any other reason than processor.ErrUnprocessable, such as the database
+// being down, is rewound and waits, however long that takes.
 type KafkaConsumer struct {
 	Client *kafka.Consumer
 	Topic  events.Topic
+	Config ConsumerConfig
+
+	// DeadLetters receives undecodable messages and unprocessable batches.
+	// When nil, every failed batch is rewound and retried instead.
+	DeadLetters     DeadLetterPublisher
+	DeadLetterTopic events.Topic
+
+	pending  batch
+	paused   []kafka.TopicPartition
+	resumeAt time.Time
 }
 
-func NewKafkaConsumer(conf *kafka.ConfigMap, topic events.Topic) *KafkaConsumer {
+func NewKafkaConsumer(conf *kafka.ConfigMap, topic events.Topic, config ConsumerConfig) *KafkaConsumer {
+	// Offsets are managed by Subscribe; never let librdkafka move them on
+	// behalf of messages that have not been persisted.
+	_ = conf.SetKey("enable.auto.commit", false)
+	_ = conf.SetKey("enable.auto.offset.store", false)
+
 	c, err := kafka.NewConsumer(conf)
 	if err != nil {
 		log.Panicf("Failed to init kafka consumer client: %s", err.Error())
@@ -33,7 +77,7 @@ func NewKafkaConsumer(conf *kafka.ConfigMap, topic events.Topic) *KafkaConsumer
 		}
 	}()
 
-	md, err := c.GetMetadata(nil, true, ConnTimeoutMs)
+	md, err := c.GetMetadata(nil, true, config.ConnTimeoutMs)
 	if err != nil {
 		log.Panicf("Kafka brokers unreachable: %v", err)
 	}
@@ -46,38 +90,173 @@ func NewKafkaConsumer(conf *kafka.ConfigMap, topic events.Topic) *KafkaConsumer
 	return &KafkaConsumer{
 		Client: c,
 		Topic:  topic,
+		Config: config,
 	}
 }
 
 func (k *KafkaConsumer) Subscribe(ctx context.Context, processor processor.EventProcessor) {
-	err := k.Client.Subscribe(k.Topic.String(), nil)
+	err := k.Client.Subscribe(k.Topic.String(), k.rebalance(processor))
 	if err != nil {
 		log.Panicf("Subscribing error to kafka topic %s: %s", k.Topic, err.Error())
 	}
 
 	run := true
-	var batchInputs []events.TelemetryRawEvent
 	for run {
 		select {
 		case <-ctx.Done():
 			run = false
 		default:
-			ev := k.Client.Poll(SubTimeoutMs)
+			k.resumeIfDue()
+			ev := k.Client.Poll(k.Config.SubTimeoutMs)
 			switch e := ev.(type) {
 			case *kafka.Message:
-				event := events.RawMessageToTelemetryRawEvent(e.Value)
-				batchInputs = append(batchInputs, event)
+				event, err := MessageToEvent(e)
+				if err != nil {
+					k.pending.addDead(e, events.DeadLetterDecode, err)
+				} else {
+					k.pending.add(e, event)
+				}
 			case kafka.Error:
 				log.Panicf("Consumer error: %v\n", e)
-			default:
-				if len(batchInputs) > 0 {
-					processor.ProcessEvents(batchInputs, batchSize)
-					batchInputs = []events.TelemetryRawEvent{}
-				}
+			}
+			if reason := k.flushReason(time.Now()); reason != "" {
+				k.flush(processor, reason)
 			}
 		}
 	}
 
+	k.flush(processor, "shutdown")
+	if _, err := k.Client.Commit(); err != nil && !isNoOffset(err) {
+		log.Printf("Final offset commit failed: %v", err)
+	}
+
 	log.Println("Closing consumer...")
 	k.Client.Close()
 }
+
+// flushReason reports why the pending batch is due, or "" when it is not.
+func (k *KafkaConsumer) flushReason(now time.Time) string {
+	switch {
+	case k.pending.len() == 0:
+		return ""
+	case k.pending.len() >= k.Config.BatchSize:
+		return "size"
+	case now.Sub(k.pending.started) >= time.Duration(k.Config.MaxLingerMs)*time.Millisecond:
+		return "linger"
+	}
+	return ""
+}
+
+// flush hands the pending batch to the processor and commits its offsets
+// once every message is either persisted or dead-lettered. Anything else
+// rewinds the batch so it is consumed again.
+func (k *KafkaConsumer) flush(processor processor.EventProcessor, reason string) {
+	if k.pending.len() == 0 {
+		return
+	}
+	b := k.pending
+	k.pending = batch{}
+
+	start := time.Now()
+	defer func() { recordFlush(reason, b.len(), time.Since(start)) }()
+
+	if len(b.events) > 0 {
+		err := retry.Do(
+			func() error { return processor.ProcessEvents(b.events, k.Config.BatchSize) },
+			retry.WithAttempts(processAttempts),
+			retry.WithBackoff(processBackoff, 2.0),
+		)
+		if err != nil {
+			log.Printf("Failed to process batch of %d events after %d attempts: %v", len(b.events), processAttempts, err)
+			if !k.deadLetterable(err) {
+				k.rewind(b)
+				return
+			}
+			b.deadLetterEvents(err)
+		}
+	}
+
+	if len(b.dead) > 0 {
+		if k.DeadLetters == nil {
+			log.Printf("Dropping %d undecodable messages: no dead-letter topic configured", len(b.dead))
+		} else {
+			if err := k.DeadLetters.PublishDeadLetters(b.dead, k.DeadLetterTopic); err != nil {
+				log.Printf("Failed to publish %d dead letters: %v", len(b.dead), err)
+				k.rewind(b)
+				return
+			}
+			log.Printf("Sent %d messages to dead-letter topic %s", len(b.dead), k.DeadLetterTopic)
+		}
+	}
+
+	k.commit(b)
+}
+
+// deadLetterable reports whether a batch that failed with err goes to the
+// dead-letter topic rather than being retried.
+func (k *KafkaConsumer) deadLetterable(err error) bool {
+	return k.DeadLetters != nil && errors.Is(err, processor.ErrUnprocessable)
+}
+
+func (k *KafkaConsumer) commit(b batch) {
+	offsets := b.nextOffsets()
+	if _, err := k.Client.StoreOffsets(offsets); err != nil {
+		log.Printf("Failed to store offsets %v: %v", offsets, err)
+		return
+	}
+	// A failed commit only means the batch may be redelivered, which the
+	// idempotent inserter tolerates.
+	if _, err := k.Client.Commit(); err != nil && !isNoOffset(err) {
+		log.Printf("Failed to commit offsets %v: %v", offsets, err)
+	}
+}
+
+// rewind seeks every partition in the batch back to its first message and
+// pauses those partitions, so the batch is retried after pauseDuration
+// instead of being skipped.
+func (k *KafkaConsumer) rewind(b batch) {
+	offsets := b.firstOffsets()
+	if err := k.Client.Pause(offsets); err != nil {
+		log.Printf("Failed to pause partitions %v: %v", offsets, err)
+	}
+	for _, tp := range offsets {
+		if err := k.Client.Seek(tp, 0); err != nil {
+			log.Printf("Failed to seek %s[%d] to %v: %v", *tp.Topic, tp.Partition, tp.Offset, err)
+		}
+	}
+	k.paused = append(k.paused, offsets...)
+	k.resumeAt = time.Now().Add(pauseDuration)
+	log.Printf("Paused %d partition(s) for %s", len(offsets), pauseDuration)
+}
+
+func (k *KafkaConsumer) resumeIfDue() {
+	if len(k.paused) == 0 || time.Now().Before(k.resumeAt) {
+		return
+	}
+	if err := k.Client.Resume(k.paused); err != nil {
+		log.Printf("Failed to resume partitions %v: %v", k.paused, err)
+		return
+	}
+	log.Printf("Resumed %d partition(s)", len(k.paused))
+	k.paused = nil
+}
+
+// rebalance flushes the pending batch before partitions are revoked so its
+// offsets are committed while this consumer still owns them.
+func (k *KafkaConsumer) rebalance(processor processor.EventProcessor) kafka.RebalanceCb {
+	return func(c *kafka.Consumer, ev kafka.Event) error {
+		if _, ok := ev.(kafka.RevokedPartitions); ok {
+			if !c.AssignmentLost() {
+				k.flush(processor, "revoke")
+			}
+			k.pending = batch{}
+			k.paused = nil
+		}
+		return nil
+	}
+}
+
+func isNoOffset(err error) bool {
+	kerr, ok := err.(kafka.Error)
+	return ok && kerr.Code() == kafka.ErrNoOffset
+}
@@ -0,0 +1,44 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/processor"
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+func TestKafkaConsumer_FlushReason(t *testing.T) {
+	topic := "telemetry.raw"
+	k := &KafkaConsumer{Config: ConsumerConfig{BatchSize: 3, MaxLingerMs: 1000}}
+
+	assert.Equal(t, "", k.flushReason(time.Now()), "empty batch is never due")
+
+	k.pending.add(message(&topic, 0, 1), events.TelemetryRawEvent{})
+	started := k.pending.started
+	assert.Equal(t, "", k.flushReason(started.Add(999*time.Millisecond)))
+	assert.Equal(t, "linger", k.flushReason(started.Add(time.Second)))
+
+	k.pending.add(message(&topic, 0, 2), events.TelemetryRawEvent{})
+	k.pending.add(message(&topic, 0, 3), events.TelemetryRawEvent{})
+	assert.Equal(t, "size", k.flushReason(started), "a full batch is due before it lingers")
+}
+
+type discardDeadLetters struct{}
+
+func (discardDeadLetters) PublishDeadLetters([]events.DeadLetter, events.Topic) error { return nil }
+
+func TestKafkaConsumer_DeadLetterable(t *testing.T) {
+	k := &KafkaConsumer{}
+	rejected := fmt.Errorf("insert: %w", processor.ErrUnprocessable)
+	assert.False(t, k.deadLetterable(rejected), "without a dead-letter topic every batch is retried")
+
+	k.DeadLetters = discardDeadLetters{}
+	assert.True(t, k.deadLetterable(rejected))
+	assert.True(t, k.deadLetterable(fmt.Errorf("enrichment: %w", rejected)))
+	assert.False(t, k.deadLetterable(errors.New("dial tcp: connection refused")), "an outage waits instead")
+}
@@ -2,13 +2,21 @@ package kafka
 
 import (
 	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/dandyZicky/opensky-collector/pkg/events"
 )
 
-func EventToMessage(e events.TelemetryRawEvent, topic string) (*kafka.Message, error) {
-	val, err := json.Marshal(e)
+// EventToMessage encodes e with codec, or JSON when codec is nil, and records
+// the format and schema version in the message headers.
+func EventToMessage(e events.TelemetryRawEvent, topic string, codec events.Codec) (*kafka.Message, error) {
+	if codec == nil {
+		codec = events.JSONCodec{}
+	}
+	val, err := codec.Encode(e)
 	if err != nil {
 		return nil, err
 	}
@@ -20,5 +28,94 @@ func EventToMessage(e events.TelemetryRawEvent, topic string) (*kafka.Message, e
 		},
 		Key:   []byte(e.Icao24),
 		Value: val,
+		Headers: []kafka.Header{
+			{Key: events.HeaderFormat, Value: []byte(codec.Format())},
+			{Key: events.HeaderSchemaVersion, Value: []byte(strconv.Itoa(events.SchemaVersion))},
+		},
+	}, nil
+}
+
+// MessageToEvent decodes msg with the codec named in its headers. Messages
+// without headers are JSON at schema version 1.
+func MessageToEvent(msg *kafka.Message) (events.TelemetryRawEvent, error) {
+	var format string
+	var version int
+	for _, h := range msg.Headers {
+		switch h.Key {
+		case events.HeaderFormat:
+			format = string(h.Value)
+		case events.HeaderSchemaVersion:
+			v, err := strconv.Atoi(string(h.Value))
+			if err != nil {
+				return events.TelemetryRawEvent{}, fmt.Errorf("invalid schema version header %q", h.Value)
+			}
+			version = v
+		}
+	}
+	return events.Decode(msg.Value, format, version)
+}
+
+// jsonMessage encodes v as JSON under key, with the envelope headers of
+// schema version. Keying by icao24 keeps the messages of one aircraft in
+// order.
+func jsonMessage(topic, key string, v any, version int) (*kafka.Message, error) {
+	val, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &kafka.Message{
+		TopicPartition: kafka.TopicPartition{
+			Topic:     &topic,
+			Partition: kafka.PartitionAny,
+		},
+		Key:   []byte(key),
+		Value: val,
+		Headers: []kafka.Header{
+			{Key: events.HeaderFormat, Value: []byte(events.FormatJSON)},
+			{Key: events.HeaderSchemaVersion, Value: []byte(strconv.Itoa(version))},
+		},
 	}, nil
 }
+
+// DeadLetterToMessage encodes dl as JSON under the key of the message it
+// wraps. It has no envelope headers; those of the original message are kept
+// inside dl for replay.
+func DeadLetterToMessage(dl events.DeadLetter, topic string) (*kafka.Message, error) {
+	val, err := events.SerializeDeadLetter(dl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &kafka.Message{
+		TopicPartition: kafka.TopicPartition{
+			Topic:     &topic,
+			Partition: kafka.PartitionAny,
+		},
+		Key:   dl.Key,
+		Value: val,
+	}, nil
+}
+
+// NewDeadLetter wraps a consumed message that could not be handled.
+func NewDeadLetter(msg *kafka.Message, reason string, cause error) events.DeadLetter {
+	dl := events.DeadLetter{
+		Reason:    reason,
+		Error:     cause.Error(),
+		Partition: msg.TopicPartition.Partition,
+		Offset:    int64(msg.TopicPartition.Offset),
+		Key:       msg.Key,
+		Payload:   msg.Value,
+		Timestamp: time.Now().Unix(),
+	}
+	if len(msg.Headers) > 0 {
+		dl.Headers = make(map[string]string, len(msg.Headers))
+		for _, h := range msg.Headers {
+			dl.Headers[h.Key] = string(h.Value)
+		}
+	}
+	if msg.TopicPartition.Topic != nil {
+		dl.Topic = *msg.TopicPartition.Topic
+	}
+	return dl
+}
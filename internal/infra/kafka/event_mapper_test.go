@@ -0,0 +1,85 @@
+package kafka
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+func TestEventToMessage_RoundTrip(t *testing.T) {
+	event := events.TelemetryRawEvent{Icao24: "8a0377", OriginCountry: "Indonesia", LastContact: 1700000000}
+
+	for _, codec := range []events.Codec{nil, events.ProtobufCodec{}} {
+		msg, err := EventToMessage(event, "telemetry.raw", codec)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("8a0377"), msg.Key)
+		assert.Len(t, msg.Headers, 2)
+
+		decoded, err := MessageToEvent(msg)
+		require.NoError(t, err)
+		assert.Equal(t, event, decoded)
+	}
+}
+
+func TestMessageToEvent_Envelope(t *testing.T) {
+	legacy := &kafka.Message{Value: []byte(`{"icao24":"8a0377","last_contact":1700000000}`)}
+	event, err := MessageToEvent(legacy)
+	require.NoError(t, err)
+	assert.Equal(t, "8a0377", event.Icao24)
+
+	future := &kafka.Message{
+		Value: legacy.Value,
+		Headers: []kafka.Header{
+			{Key: events.HeaderFormat, Value: []byte(events.FormatJSON)},
+			{Key: events.HeaderSchemaVersion, Value: []byte("99")},
+		},
+	}
+	_, err = MessageToEvent(future)
+	assert.Error(t, err)
+
+	future.Headers[1].Value = []byte("v1")
+	_, err = MessageToEvent(future)
+	assert.Error(t, err)
+}
+
+func TestJSONMessage(t *testing.T) {
+	level := 350
+	event := events.TelemetryEnrichedEvent{
+		TelemetryRawEvent: events.TelemetryRawEvent{Icao24: "8a0377", LastContact: 1700000000},
+		FlightLevel:       &level,
+		Phase:             events.PhaseCruise,
+	}
+
+	msg, err := jsonMessage("telemetry.enriched", event.Icao24, event, events.EnrichedSchemaVersion)
+	require.NoError(t, err)
+	assert.Equal(t, "telemetry.enriched", *msg.TopicPartition.Topic)
+	assert.Equal(t, []byte("8a0377"), msg.Key)
+	assert.Contains(t, string(msg.Value), `"icao24":"8a0377"`)
+	assert.Contains(t, string(msg.Value), `"flight_level":350`)
+	assert.Equal(t, []kafka.Header{
+		{Key: events.HeaderFormat, Value: []byte(events.FormatJSON)},
+		{Key: events.HeaderSchemaVersion, Value: []byte(strconv.Itoa(events.EnrichedSchemaVersion))},
+	}, msg.Headers)
+
+	decoded, err := events.DeserializeTelemetryEnrichedEvent(msg.Value)
+	require.NoError(t, err)
+	assert.Equal(t, event, decoded)
+
+	_, err = jsonMessage("alerts", "8a0377", func() {}, events.AlertSchemaVersion)
+	assert.Error(t, err)
+}
+
+func TestNewDeadLetter_KeepsHeaders(t *testing.T) {
+	topic := "telemetry.raw"
+	msg := message(&topic, 0, 3)
+	msg.Headers = []kafka.Header{{Key: events.HeaderFormat, Value: []byte(events.FormatProtobuf)}}
+
+	dl := NewDeadLetter(msg, events.DeadLetterDecode, errors.New("truncated"))
+	assert.Equal(t, map[string]string{events.HeaderFormat: events.FormatProtobuf}, dl.Headers)
+}
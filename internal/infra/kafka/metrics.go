@@ -0,0 +1,52 @@
+package kafka
+
+import (
+	"expvar"
+	"fmt"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// Consumer metrics, published through expvar under "kafka_consumer".
+var (
+	consumerMetrics    = expvar.NewMap("kafka_consumer")
+	lastBatchSize      = new(expvar.Int)
+	lastFlushLatencyMs = new(expvar.Float)
+)
+
+func init() {
+	consumerMetrics.Set("last_batch_size", lastBatchSize)
+	consumerMetrics.Set("last_flush_latency_ms", lastFlushLatencyMs)
+}
+
+// recordFlush counts a flushed batch, the reason it was flushed for and how
+// long handling it took.
+func recordFlush(reason string, size int, latency time.Duration) {
+	ms := float64(latency) / float64(time.Millisecond)
+
+	consumerMetrics.Add("flushes_total", 1)
+	consumerMetrics.Add("flushes_"+reason, 1)
+	consumerMetrics.Add("messages_total", int64(size))
+	consumerMetrics.AddFloat("flush_latency_ms_total", ms)
+	lastBatchSize.Set(int64(size))
+	lastFlushLatencyMs.Set(ms)
+}
+
+// Producer delivery reports per topic, published through expvar under
+// "kafka_producer" as "<topic>.delivered" and "<topic>.failed".
+var producerMetrics = expvar.NewMap("kafka_producer")
+
+// recordDelivery counts a delivery report and returns its error, if any.
+func recordDelivery(m *kafka.Message) error {
+	topic := ""
+	if m.TopicPartition.Topic != nil {
+		topic = *m.TopicPartition.Topic
+	}
+	if m.TopicPartition.Error != nil {
+		producerMetrics.Add(topic+".failed", 1)
+		return fmt.Errorf("delivery to %s failed: %w", topic, m.TopicPartition.Error)
+	}
+	producerMetrics.Add(topic+".delivered", 1)
+	return nil
+}
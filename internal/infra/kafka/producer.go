@@ -2,18 +2,35 @@
 package kafka
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"sync/atomic"
 
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/dandyZicky/opensky-collector/pkg/events"
 )
 
+// failureBuffer is how many delivery failures are kept between two calls to
+// Flush; any beyond that are only counted.
+const failureBuffer = 256
+
+// KafkaProducer publishes asynchronously. A background goroutine reads the
+// delivery reports, counts them per topic and keeps failures until the next
+// Flush returns them.
 type KafkaProducer struct {
-	Producer *kafka.Producer
-	Topic    string
+	Producer       *kafka.Producer
+	Topic          string
+	FlushTimeoutMs int
+	// Codec encodes published events; nil means JSON.
+	Codec events.Codec
+
+	failures chan error
+	overflow atomic.Int64
+	done     chan struct{}
 }
 
-func NewKafkaProducer(conf *kafka.ConfigMap) *kafka.Producer {
+func NewKafkaProducer(conf *kafka.ConfigMap, flushTimeoutMs int) *KafkaProducer {
 	log.Println("Initializing kafka producer")
 	p, err := kafka.NewProducer(conf)
 	if err != nil {
@@ -31,11 +48,19 @@ func NewKafkaProducer(conf *kafka.ConfigMap) *kafka.Producer {
 	}
 
 	log.Printf("Connected to Kafka cluster with %d brokers\n", len(md.Brokers))
-	return p
+
+	k := &KafkaProducer{
+		Producer:       p,
+		FlushTimeoutMs: flushTimeoutMs,
+		failures:       make(chan error, failureBuffer),
+		done:           make(chan struct{}),
+	}
+	go k.handleDeliveries()
+	return k
 }
 
 func (k *KafkaProducer) Publish(event events.TelemetryRawEvent, topic events.Topic) error {
-	msg, err := EventToMessage(event, topic.String())
+	msg, err := EventToMessage(event, topic.String(), k.Codec)
 	if err != nil {
 		return err
 
@@ -46,3 +71,147 @@ func (k *KafkaProducer) Publish(event events.TelemetryRawEvent, topic events.Top
 	}
 	return nil
 }
+
+// Flush waits up to FlushTimeoutMs for queued messages to be delivered and
+// returns the delivery failures reported since the previous call.
+func (k *KafkaProducer) Flush() error {
+	var errs []error
+	if remaining := k.Producer.Flush(k.FlushTimeoutMs); remaining > 0 {
+		errs = append(errs, fmt.Errorf("%d messages still queued after %dms", remaining, k.FlushTimeoutMs))
+	}
+
+	failed := int(k.overflow.Swap(0))
+	var first error
+	for drained := false; !drained; {
+		select {
+		case err := <-k.failures:
+			failed++
+			if first == nil {
+				first = err
+			}
+		default:
+			drained = true
+		}
+	}
+	if failed > 0 {
+		errs = append(errs, fmt.Errorf("%d deliveries failed, first: %w", failed, first))
+	}
+	return errors.Join(errs...)
+}
+
+// Close flushes for up to FlushTimeoutMs, closes the producer and waits for
+// the delivery report goroutine to finish.
+func (k *KafkaProducer) Close() {
+	if remaining := k.Producer.Flush(k.FlushTimeoutMs); remaining > 0 {
+		log.Printf("Closing producer with %d undelivered messages after %dms", remaining, k.FlushTimeoutMs)
+	}
+	k.Producer.Close()
+	<-k.done
+}
+
+func (k *KafkaProducer) handleDeliveries() {
+	defer close(k.done)
+	for ev := range k.Producer.Events() {
+		switch e := ev.(type) {
+		case *kafka.Message:
+			err := recordDelivery(e)
+			if err == nil {
+				continue
+			}
+			select {
+			case k.failures <- err:
+			default:
+				k.overflow.Add(1)
+			}
+		case kafka.Error:
+			log.Printf("Producer error: %v", e)
+		}
+	}
+}
+
+// PublishDeadLetters produces every letter and waits for its delivery report.
+// It returns an error unless all of them reached the broker, so callers can
+// safely commit the source offsets afterwards.
+func (k *KafkaProducer) PublishDeadLetters(letters []events.DeadLetter, topic events.Topic) error {
+	msgs := make([]*kafka.Message, 0, len(letters))
+	for _, dl := range letters {
+		msg, err := DeadLetterToMessage(dl, topic.String())
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, msg)
+	}
+	return k.produceAndWait(msgs, "dead letters")
+}
+
+// PublishEnriched produces every enriched event, keyed by icao24, and waits
+// until the broker has acknowledged all of them.
+func (k *KafkaProducer) PublishEnriched(enriched []events.TelemetryEnrichedEvent, topic events.Topic) error {
+	return publishJSON(k, enriched, topic, events.EnrichedSchemaVersion, "enriched events",
+		func(e events.TelemetryEnrichedEvent) string { return e.Icao24 })
+}
+
+// PublishFlightEvents produces every takeoff and landing. It fails if any of
+// them was not delivered, leaving the processed batch uncommitted.
+func (k *KafkaProducer) PublishFlightEvents(flightEvents []events.FlightEvent, topic events.Topic) error {
+	return publishJSON(k, flightEvents, topic, events.FlightEventSchemaVersion, "flight events",
+		func(e events.FlightEvent) string { return e.Icao24 })
+}
+
+// PublishGeofenceEvents produces every geofence entry and exit and returns
+// once each has a delivery report.
+func (k *KafkaProducer) PublishGeofenceEvents(geofenceEvents []events.GeofenceEvent, topic events.Topic) error {
+	return publishJSON(k, geofenceEvents, topic, events.GeofenceEventSchemaVersion, "geofence events",
+		func(e events.GeofenceEvent) string { return e.Icao24 })
+}
+
+// PublishAlerts produces every alert for the Kafka alert sink. Its error
+// counts the undelivered alerts and wraps the last delivery failure.
+func (k *KafkaProducer) PublishAlerts(alerts []events.Alert, topic events.Topic) error {
+	return publishJSON(k, alerts, topic, events.AlertSchemaVersion, "alerts",
+		func(a events.Alert) string { return a.Icao24 })
+}
+
+// publishJSON produces every item as a JSON message at schema version under
+// the key it maps to, and waits for all of them to be delivered.
+func publishJSON[T any](k *KafkaProducer, items []T, topic events.Topic, version int, what string, key func(T) string) error {
+	msgs := make([]*kafka.Message, 0, len(items))
+	for _, item := range items {
+		msg, err := jsonMessage(topic.String(), key(item), item, version)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, msg)
+	}
+	return k.produceAndWait(msgs, what)
+}
+
+// produceAndWait produces msgs on a private delivery channel and returns an
+// error unless every one of them was delivered.
+func (k *KafkaProducer) produceAndWait(msgs []*kafka.Message, what string) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	deliveries := make(chan kafka.Event, len(msgs))
+	for _, msg := range msgs {
+		if err := k.Producer.Produce(msg, deliveries); err != nil {
+			return err
+		}
+	}
+
+	var failed int
+	var lastErr error
+	for range msgs {
+		if m, ok := (<-deliveries).(*kafka.Message); ok {
+			if err := recordDelivery(m); err != nil {
+				failed++
+				lastErr = err
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d %s not delivered: %w", failed, len(msgs), what, lastErr)
+	}
+	return nil
+}
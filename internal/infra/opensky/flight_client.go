@@ -7,10 +7,12 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/dandyZicky/opensky-collector/internal/domain/flight"
 	"github.com/dandyZicky/opensky-collector/internal/dto"
 	"github.com/dandyZicky/opensky-collector/pkg/retry"
 )
@@ -45,18 +47,21 @@ func (c *FlightClient) ensureAuthenticated() error {
 	return c.authenticate()
 }
 
-func (c *FlightClient) requestAuthorizedStateVectors() (*dto.StatesResponse, error) {
+func (c *FlightClient) requestAuthorizedStateVectors(box *flight.BoundingBox) (*dto.StatesResponse, error) {
 	req, err := http.NewRequest("GET", c.URL+"/states/all", nil)
 	if err != nil {
 		return nil, err
 	}
 
-	q := req.URL.Query()
-	q.Set("lamin", "-11.00")
-	q.Set("lomin", "95.00")
-	q.Set("lamax", "6.00")
-	q.Set("lomax", "141.00")
-	req.URL.RawQuery = q.Encode()
+	// A nil box asks OpenSky for every state vector worldwide.
+	if box != nil {
+		q := req.URL.Query()
+		q.Set("lamin", formatCoord(box.LaMin))
+		q.Set("lomin", formatCoord(box.LoMin))
+		q.Set("lamax", formatCoord(box.LaMax))
+		q.Set("lomax", formatCoord(box.LoMax))
+		req.URL.RawQuery = q.Encode()
+	}
 
 	c.Mutex.Lock()
 	req.Header.Set("Authorization", "Bearer "+c.accessToken)
@@ -87,7 +92,9 @@ func (c *FlightClient) requestAuthorizedStateVectors() (*dto.StatesResponse, err
 
 	states := dto.StatesResponse{}
 	states.Time = int64(result["time"].(float64))
-	for _, res := range result["states"].([]any) {
+	// OpenSky returns "states": null for a box with no aircraft in it.
+	rawStates, _ := result["states"].([]any)
+	for _, res := range rawStates {
 		state, err := (*dto.DefaultMapper).ToState(nil, res.([]any))
 		if err != nil {
 			log.Printf("Error parsing state vector, skipping: %v", err)
@@ -99,12 +106,12 @@ func (c *FlightClient) requestAuthorizedStateVectors() (*dto.StatesResponse, err
 	return &states, nil
 }
 
-func (c *FlightClient) GetAllStateVectors() (*dto.StatesResponse, error) {
+func (c *FlightClient) GetAllStateVectors(box *flight.BoundingBox) (*dto.StatesResponse, error) {
 	if err := c.ensureAuthenticated(); err != nil {
 		return nil, fmt.Errorf("initial authentication failed: %w", err)
 	}
 
-	states, err := c.requestAuthorizedStateVectors()
+	states, err := c.requestAuthorizedStateVectors(box)
 	if err != nil {
 		if err.Error() == "unauthorized" {
 			log.Println("Attempting to re-authenticate and retry the request...")
@@ -119,7 +126,7 @@ func (c *FlightClient) GetAllStateVectors() (*dto.StatesResponse, error) {
 			}
 
 			log.Println("Re-authentication successful. Retrying the API request one last time.")
-			return c.requestAuthorizedStateVectors()
+			return c.requestAuthorizedStateVectors(box)
 		}
 		return nil, err
 	}
@@ -165,6 +172,10 @@ func (c *FlightClient) authenticate() error {
 	return fmt.Errorf("access_token not found in response")
 }
 
+func formatCoord(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
 func ReadCredentials(filePath string) (*Credentials, error) {
 	jsonFile, err := os.Open(filePath)
 	if err != nil {
@@ -0,0 +1,114 @@
+package pg
+
+import (
+	"time"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/registry"
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AircraftRecord is a row of the aircraft registry table.
+type AircraftRecord struct {
+	Icao24       string `gorm:"primaryKey"`
+	Registration string `gorm:"not null;default:''"`
+	TypeCode     string `gorm:"column:typecode;not null;default:''"`
+	Manufacturer string `gorm:"not null;default:''"`
+	Model        string `gorm:"not null;default:''"`
+	Operator     string `gorm:"not null;default:''"`
+	UpdatedAt    time.Time
+}
+
+func (AircraftRecord) TableName() string {
+	return "aircraft"
+}
+
+// PgAircraftRepository stores the aircraft registry.
+type PgAircraftRepository struct {
+	DB *gorm.DB
+}
+
+func (r *PgAircraftRepository) All() ([]events.Aircraft, error) {
+	var rows []AircraftRecord
+	if err := r.DB.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	aircraft := make([]events.Aircraft, 0, len(rows))
+	for _, row := range rows {
+		aircraft = append(aircraft, events.Aircraft{
+			Icao24:       row.Icao24,
+			Registration: row.Registration,
+			TypeCode:     row.TypeCode,
+			Manufacturer: row.Manufacturer,
+			Model:        row.Model,
+			Operator:     row.Operator,
+		})
+	}
+	return aircraft, nil
+}
+
+// Upsert inserts or updates the aircraft. Dumps list some icao24s more than
+// once; the last entry wins, as Postgres refuses to update a row twice in
+// one statement.
+func (r *PgAircraftRepository) Upsert(aircraft []events.Aircraft, batchSize int) (int64, error) {
+	if len(aircraft) == 0 {
+		return 0, nil
+	}
+	now := time.Now().UTC()
+	rows := make([]AircraftRecord, 0, len(aircraft))
+	index := make(map[string]int, len(aircraft))
+	for _, a := range aircraft {
+		row := AircraftRecord{
+			Icao24:       a.Icao24,
+			Registration: a.Registration,
+			TypeCode:     a.TypeCode,
+			Manufacturer: a.Manufacturer,
+			Model:        a.Model,
+			Operator:     a.Operator,
+			UpdatedAt:    now,
+		}
+		if i, ok := index[a.Icao24]; ok {
+			rows[i] = row
+			continue
+		}
+		index[a.Icao24] = len(rows)
+		rows = append(rows, row)
+	}
+
+	res := r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "icao24"}},
+		DoUpdates: clause.AssignmentColumns([]string{"registration", "typecode", "manufacturer", "model", "operator", "updated_at"}),
+	}).CreateInBatches(&rows, batchSize)
+	return res.RowsAffected, res.Error
+}
+
+func (r *PgAircraftRepository) Unknown(since time.Time, limit int) (registry.UnknownStats, error) {
+	var stats registry.UnknownStats
+
+	err := r.DB.Model(&FlightStateVector{}).
+		Where("time_position >= ?", since).
+		Distinct("icao24").
+		Count(&stats.Seen).Error
+	if err != nil {
+		return stats, err
+	}
+
+	unknown := func() *gorm.DB {
+		return r.DB.Table("flight_state_vectors AS f").
+			Joins("LEFT JOIN aircraft AS a ON a.icao24 = f.icao24").
+			Where("a.icao24 IS NULL AND f.time_position >= ?", since)
+	}
+
+	if err := unknown().Distinct("f.icao24").Count(&stats.Unknown).Error; err != nil {
+		return stats, err
+	}
+
+	err = unknown().
+		Select("f.icao24 AS icao24, COUNT(*) AS states").
+		Group("f.icao24").
+		Order("states DESC, f.icao24").
+		Limit(limit).
+		Scan(&stats.Top).Error
+	return stats, err
+}
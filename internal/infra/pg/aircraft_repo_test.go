@@ -0,0 +1,49 @@
+package pg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/registry"
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+func TestPgAircraftRepository(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	flights := newTestRepository(t,
+		stateAt("8a0001", base, -6.0, 106.5),
+		stateAt("8a0002", base, -6.0, 106.5),
+		stateAt("8a0002", base.Add(time.Minute), -6.1, 106.6),
+		stateAt("8a0003", base, -6.0, 106.5),
+		stateAt("8a0004", base.Add(-48*time.Hour), -6.0, 106.5),
+	)
+	require.NoError(t, flights.DB.AutoMigrate(&AircraftRecord{}))
+	repo := &PgAircraftRepository{DB: flights.DB}
+
+	_, err := repo.Upsert([]events.Aircraft{
+		{Icao24: "8a0001", Registration: "PK-GMA"},
+		{Icao24: "8a0005", Registration: "PK-LQJ"},
+	}, 10)
+	require.NoError(t, err)
+	// Duplicates within a batch, the last one wins.
+	n, err := repo.Upsert([]events.Aircraft{
+		{Icao24: "8a0001", Registration: "PK-GMX"},
+		{Icao24: "8a0001", Registration: "PK-GMB", Operator: "Garuda Indonesia"},
+	}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	all, err := repo.All()
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Contains(t, all, events.Aircraft{Icao24: "8a0001", Registration: "PK-GMB", Operator: "Garuda Indonesia"})
+
+	stats, err := repo.Unknown(base.Add(-time.Hour), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), stats.Seen)
+	assert.Equal(t, int64(2), stats.Unknown)
+	assert.Equal(t, []registry.UnknownAircraft{{Icao24: "8a0002", States: 2}}, stats.Top)
+}
@@ -0,0 +1,157 @@
+package pg
+
+import (
+	"time"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/airport"
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AirportRecord struct {
+	Ident       string  `gorm:"primaryKey"`
+	Code        string  `gorm:"not null"`
+	Name        string  `gorm:"not null;default:''"`
+	Type        string  `gorm:"not null;default:''"`
+	Lat         float64 `gorm:"not null"`
+	Lon         float64 `gorm:"not null"`
+	ElevationFt *float64
+	Country     string `gorm:"not null;default:''"`
+}
+
+func (AirportRecord) TableName() string {
+	return "airports"
+}
+
+type RunwayRecord struct {
+	AirportIdent string  `gorm:"primaryKey"`
+	Ident        string  `gorm:"primaryKey"`
+	HeadingDeg   float64 `gorm:"not null"`
+}
+
+func (RunwayRecord) TableName() string {
+	return "runways"
+}
+
+// FlightEventRecord is a stored takeoff or landing, unique per aircraft,
+// type and time.
+type FlightEventRecord struct {
+	ID       int64  `gorm:"primaryKey"`
+	Icao24   string `gorm:"not null;uniqueIndex:uq_flight_events_natural_key"`
+	Callsign *string
+	Type     string    `gorm:"not null;uniqueIndex:uq_flight_events_natural_key"`
+	Airport  string    `gorm:"not null"`
+	Runway   string    `gorm:"not null;default:''"`
+	Time     time.Time `gorm:"type:timestamp not null;uniqueIndex:uq_flight_events_natural_key"`
+	Lat      float64   `gorm:"not null"`
+	Lon      float64   `gorm:"not null"`
+}
+
+func (FlightEventRecord) TableName() string {
+	return "flight_events"
+}
+
+// PgAirportRepository stores the airport database and the flight events
+// detected at its airports.
+type PgAirportRepository struct {
+	DB *gorm.DB
+}
+
+func (r *PgAirportRepository) All() ([]airport.Airport, error) {
+	var rows []AirportRecord
+	if err := r.DB.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	var runways []RunwayRecord
+	if err := r.DB.Order("airport_ident, ident").Find(&runways).Error; err != nil {
+		return nil, err
+	}
+	byAirport := make(map[string][]airport.Runway)
+	for _, rw := range runways {
+		byAirport[rw.AirportIdent] = append(byAirport[rw.AirportIdent], airport.Runway{Ident: rw.Ident, HeadingDeg: rw.HeadingDeg})
+	}
+
+	airports := make([]airport.Airport, 0, len(rows))
+	for _, row := range rows {
+		airports = append(airports, airport.Airport{
+			Ident:       row.Ident,
+			Code:        row.Code,
+			Name:        row.Name,
+			Type:        row.Type,
+			Lat:         row.Lat,
+			Lon:         row.Lon,
+			ElevationFt: row.ElevationFt,
+			Country:     row.Country,
+			Runways:     byAirport[row.Ident],
+		})
+	}
+	return airports, nil
+}
+
+// Upsert replaces the airports and their runways in one transaction.
+func (r *PgAirportRepository) Upsert(airports []airport.Airport, batchSize int) error {
+	if len(airports) == 0 {
+		return nil
+	}
+	rows := make([]AirportRecord, 0, len(airports))
+	idents := make([]string, 0, len(airports))
+	var runways []RunwayRecord
+	for _, a := range airports {
+		rows = append(rows, AirportRecord{
+			Ident:       a.Ident,
+			Code:        a.Code,
+			Name:        a.Name,
+			Type:        a.Type,
+			Lat:         a.Lat,
+			Lon:         a.Lon,
+			ElevationFt: a.ElevationFt,
+			Country:     a.Country,
+		})
+		idents = append(idents, a.Ident)
+		for _, rw := range a.Runways {
+			runways = append(runways, RunwayRecord{AirportIdent: a.Ident, Ident: rw.Ident, HeadingDeg: rw.HeadingDeg})
+		}
+	}
+
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "ident"}},
+			DoUpdates: clause.AssignmentColumns([]string{"code", "name", "type", "lat", "lon", "elevation_ft", "country"}),
+		}).CreateInBatches(&rows, batchSize).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("airport_ident IN ?", idents).Delete(&RunwayRecord{}).Error; err != nil {
+			return err
+		}
+		if len(runways) == 0 {
+			return nil
+		}
+		// A runway listed twice in the dump keeps its first heading.
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&runways, batchSize).Error
+	})
+}
+
+func (r *PgAirportRepository) SaveEvents(flightEvents []events.FlightEvent) error {
+	if len(flightEvents) == 0 {
+		return nil
+	}
+	rows := make([]FlightEventRecord, 0, len(flightEvents))
+	for _, e := range flightEvents {
+		rows = append(rows, FlightEventRecord{
+			Icao24:   e.Icao24,
+			Callsign: e.Callsign,
+			Type:     e.Type,
+			Airport:  e.Airport,
+			Runway:   e.Runway,
+			Time:     time.Unix(e.Time, 0).UTC(),
+			Lat:      e.Lat,
+			Lon:      e.Lon,
+		})
+	}
+	return r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "icao24"}, {Name: "type"}, {Name: "time"}},
+		DoNothing: true,
+	}).Create(&rows).Error
+}
@@ -0,0 +1,45 @@
+package pg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/airport"
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+func TestPgAirportRepository(t *testing.T) {
+	flights := newTestRepository(t)
+	require.NoError(t, flights.DB.AutoMigrate(&AirportRecord{}, &RunwayRecord{}, &FlightEventRecord{}))
+	repo := &PgAirportRepository{DB: flights.DB}
+
+	wiii := airport.Airport{
+		Ident: "WIII", Code: "WIII", Name: "Soekarno-Hatta", Lat: -6.1256, Lon: 106.656, ElevationFt: ptr(34.0),
+		Runways: []airport.Runway{{Ident: "07R", HeadingDeg: 70.6}, {Ident: "25L", HeadingDeg: 250.6}},
+	}
+	require.NoError(t, repo.Upsert([]airport.Airport{wiii, {Ident: "WIHH", Code: "WIHH", Lat: -6.27, Lon: 106.89}}, 10))
+
+	wiii.Name = "Soekarno-Hatta International"
+	wiii.Runways = []airport.Runway{{Ident: "07L", HeadingDeg: 70}, {Ident: "07L", HeadingDeg: 71}}
+	require.NoError(t, repo.Upsert([]airport.Airport{wiii}, 10))
+
+	all, err := repo.All()
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	for _, a := range all {
+		if a.Ident == "WIII" {
+			assert.Equal(t, "Soekarno-Hatta International", a.Name)
+			assert.Equal(t, []airport.Runway{{Ident: "07L", HeadingDeg: 70}}, a.Runways)
+		}
+	}
+
+	takeoff := events.FlightEvent{Type: events.FlightEventTakeoff, Icao24: "8a0001", Airport: "WIII", Runway: "25L", Time: 1704110420, Lat: -6.12, Lon: 106.63}
+	require.NoError(t, repo.SaveEvents([]events.FlightEvent{takeoff}))
+	require.NoError(t, repo.SaveEvents([]events.FlightEvent{takeoff}))
+
+	var count int64
+	require.NoError(t, flights.DB.Model(&FlightEventRecord{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
@@ -1,15 +1,19 @@
 package pg
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
 	"github.com/dandyZicky/opensky-collector/internal/domain/flight"
+	"github.com/dandyZicky/opensky-collector/internal/domain/processor"
 )
 
 func TestPgInserter_InsertBatch(t *testing.T) {
@@ -25,28 +29,28 @@ func TestPgInserter_InsertBatch(t *testing.T) {
 		{
 			Icao24:        "test123",
 			OriginCountry: "DE",
-			Lat:           49.0,
-			Lon:           6.0,
-			Velocity:      200.0,
-			TimePosition:  time.Now(),
-			BaroAltitude:  10000,
-			GeoAltitude:   10050,
+			Lat:           ptr(49.0),
+			Lon:           ptr(6.0),
+			Velocity:      ptr(200.0),
+			TimePosition:  ptr(time.Now()),
+			BaroAltitude:  ptr(10000.0),
+			GeoAltitude:   ptr(10050.0),
 			LastContact:   time.Now(),
 		},
 		{
 			Icao24:        "test456",
 			OriginCountry: "FR",
-			Lat:           48.0,
-			Lon:           2.0,
-			Velocity:      250.0,
-			TimePosition:  time.Now(),
-			BaroAltitude:  12000,
-			GeoAltitude:   12050,
+			Lat:           ptr(48.0),
+			Lon:           ptr(2.0),
+			Velocity:      ptr(250.0),
+			TimePosition:  ptr(time.Now()),
+			BaroAltitude:  ptr(12000.0),
+			GeoAltitude:   ptr(12050.0),
 			LastContact:   time.Now(),
 		},
 	}
 
-	err = inserter.InsertBatch(states, 10)
+	_, err = inserter.InsertBatch(states, 10)
 
 	assert.NoError(t, err)
 
@@ -57,15 +61,15 @@ func TestPgInserter_InsertBatch(t *testing.T) {
 
 	assert.Equal(t, "test123", inserted[0].Icao24)
 	assert.Equal(t, "DE", inserted[0].OriginCountry)
-	assert.Equal(t, 49.0, inserted[0].Lat)
-	assert.Equal(t, 6.0, inserted[0].Lon)
-	assert.Equal(t, 200.0, inserted[0].Velocity)
+	assert.Equal(t, ptr(49.0), inserted[0].Lat)
+	assert.Equal(t, ptr(6.0), inserted[0].Lon)
+	assert.Equal(t, ptr(200.0), inserted[0].Velocity)
 
 	assert.Equal(t, "test456", inserted[1].Icao24)
 	assert.Equal(t, "FR", inserted[1].OriginCountry)
-	assert.Equal(t, 48.0, inserted[1].Lat)
-	assert.Equal(t, 2.0, inserted[1].Lon)
-	assert.Equal(t, 250.0, inserted[1].Velocity)
+	assert.Equal(t, ptr(48.0), inserted[1].Lat)
+	assert.Equal(t, ptr(2.0), inserted[1].Lon)
+	assert.Equal(t, ptr(250.0), inserted[1].Velocity)
 }
 
 func TestPgInserter_InsertBatch_EmptySlice(t *testing.T) {
@@ -77,7 +81,7 @@ func TestPgInserter_InsertBatch_EmptySlice(t *testing.T) {
 
 	inserter := &PgInserter{DB: db}
 
-	err = inserter.InsertBatch([]flight.FlightState{}, 10)
+	_, err = inserter.InsertBatch([]flight.FlightState{}, 10)
 
 	assert.NoError(t, err)
 
@@ -101,17 +105,17 @@ func TestPgInserter_InsertBatch_LargeBatch(t *testing.T) {
 		states[i] = flight.FlightState{
 			Icao24:        "large" + string(rune(i)),
 			OriginCountry: "TEST",
-			Lat:           float64(i),
-			Lon:           float64(i * 2),
-			Velocity:      float64(i * 10),
-			TimePosition:  time.Now(),
-			BaroAltitude:  float64(i * 100),
-			GeoAltitude:   float64(i*100 + 50),
+			Lat:           ptr(float64(i)),
+			Lon:           ptr(float64(i * 2)),
+			Velocity:      ptr(float64(i * 10)),
+			TimePosition:  ptr(time.Now()),
+			BaroAltitude:  ptr(float64(i * 100)),
+			GeoAltitude:   ptr(float64(i*100 + 50)),
 			LastContact:   time.Now(),
 		}
 	}
 
-	err = inserter.InsertBatch(states, 25)
+	_, err = inserter.InsertBatch(states, 25)
 
 	assert.NoError(t, err)
 
@@ -128,17 +132,198 @@ func TestPgInserter_InsertBatch_DatabaseError(t *testing.T) {
 		{
 			Icao24:        "error123",
 			OriginCountry: "DE",
-			Lat:           49.0,
-			Lon:           6.0,
-			Velocity:      200.0,
-			TimePosition:  time.Now(),
-			BaroAltitude:  10000,
-			GeoAltitude:   10050,
+			Lat:           ptr(49.0),
+			Lon:           ptr(6.0),
+			Velocity:      ptr(200.0),
+			TimePosition:  ptr(time.Now()),
+			BaroAltitude:  ptr(10000.0),
+			GeoAltitude:   ptr(10050.0),
 			LastContact:   time.Now(),
 		},
 	}
 
-	err := inserter.InsertBatch(states, 10)
+	_, err := inserter.InsertBatch(states, 10)
 
 	assert.Error(t, err)
 }
+
+func TestUnprocessable(t *testing.T) {
+	for code, want := range map[string]bool{
+		"22003": true,  // numeric_value_out_of_range
+		"23502": true,  // not_null_violation
+		"08006": false, // connection_failure
+		"57P01": false, // admin_shutdown
+	} {
+		err := unprocessable(fmt.Errorf("insert: %w", &pgconn.PgError{Code: code}))
+		assert.Equal(t, want, errors.Is(err, processor.ErrUnprocessable), code)
+		var pgErr *pgconn.PgError
+		assert.ErrorAs(t, err, &pgErr, "the cause stays inspectable")
+	}
+	assert.False(t, errors.Is(unprocessable(errors.New("dial tcp: refused")), processor.ErrUnprocessable))
+}
+
+func TestPgInserter_InsertBatch_FullStateVector(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	err = db.AutoMigrate(&FlightStateVector{})
+	require.NoError(t, err)
+
+	inserter := &PgInserter{DB: db}
+
+	states := []flight.FlightState{
+		{
+			Icao24:         "8a0377",
+			Callsign:       ptr("GIA402"),
+			OriginCountry:  "Indonesia",
+			Lat:            ptr(-6.12),
+			Lon:            ptr(106.65),
+			Velocity:       ptr(231.5),
+			TimePosition:   ptr(time.Now()),
+			BaroAltitude:   ptr(10363.2),
+			GeoAltitude:    ptr(10660.38),
+			LastContact:    time.Now(),
+			OnGround:       false,
+			TrueTrack:      ptr(87.3),
+			VerticalRate:   ptr(-4.55),
+			Sensors:        []int{1408, 1520},
+			Squawk:         ptr("2047"),
+			Spi:            true,
+			PositionSource: 0,
+			Category:       4,
+		},
+	}
+
+	_, err = inserter.InsertBatch(states, 10)
+	assert.NoError(t, err)
+
+	var inserted []FlightStateVector
+	err = db.Find(&inserted).Error
+	assert.NoError(t, err)
+	require.Len(t, inserted, 1)
+
+	assert.Equal(t, ptr("GIA402"), inserted[0].Callsign)
+	assert.False(t, inserted[0].OnGround)
+	assert.Equal(t, ptr(87.3), inserted[0].TrueTrack)
+	assert.Equal(t, ptr(-4.55), inserted[0].VerticalRate)
+	assert.Equal(t, []int{1408, 1520}, inserted[0].Sensors)
+	assert.Equal(t, ptr("2047"), inserted[0].Squawk)
+	assert.True(t, inserted[0].Spi)
+	assert.Equal(t, 4, inserted[0].Category)
+}
+
+func TestPgInserter_InsertBatch_NullableFields(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	err = db.AutoMigrate(&FlightStateVector{})
+	require.NoError(t, err)
+
+	inserter := &PgInserter{DB: db}
+
+	states := []flight.FlightState{
+		{
+			Icao24:        "nofix1",
+			OriginCountry: "Indonesia",
+			TimePosition:  ptr(time.Now()),
+			LastContact:   time.Now(),
+		},
+	}
+
+	_, err = inserter.InsertBatch(states, 10)
+	assert.NoError(t, err)
+
+	var missing int64
+	err = db.Model(&FlightStateVector{}).
+		Where("lat IS NULL AND lon IS NULL AND baro_altitude IS NULL AND squawk IS NULL").
+		Count(&missing).Error
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), missing)
+}
+
+func TestPgInserter_InsertBatch_SkipsMissingTimePosition(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	err = db.AutoMigrate(&FlightStateVector{})
+	require.NoError(t, err)
+
+	inserter := &PgInserter{DB: db}
+
+	states := []flight.FlightState{
+		{
+			Icao24:        "nopos1",
+			OriginCountry: "Indonesia",
+			LastContact:   time.Now(),
+		},
+		{
+			Icao24:        "withpos",
+			OriginCountry: "Indonesia",
+			TimePosition:  ptr(time.Now()),
+			LastContact:   time.Now(),
+		},
+	}
+
+	result, err := inserter.InsertBatch(states, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Inserted)
+	assert.Equal(t, 1, result.Skipped)
+
+	var inserted []FlightStateVector
+	err = db.Find(&inserted).Error
+	assert.NoError(t, err)
+	require.Len(t, inserted, 1)
+	assert.Equal(t, "withpos", inserted[0].Icao24)
+}
+
+func TestPgInserter_InsertBatch_IgnoresDuplicates(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	err = db.AutoMigrate(&FlightStateVector{})
+	require.NoError(t, err)
+
+	inserter := &PgInserter{DB: db}
+
+	seen := time.Unix(1757394006, 0)
+	states := []flight.FlightState{
+		{
+			Icao24:        "4520cf",
+			OriginCountry: "Bulgaria",
+			TimePosition:  ptr(seen),
+			LastContact:   seen,
+		},
+		{
+			Icao24:        "4400f3",
+			OriginCountry: "Austria",
+			TimePosition:  ptr(seen),
+			LastContact:   seen,
+		},
+	}
+
+	result, err := inserter.InsertBatch(states, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Inserted)
+	assert.Equal(t, 0, result.Duplicates)
+
+	// A redelivery of the same batch plus one new state.
+	redelivered := append(states, flight.FlightState{
+		Icao24:        "4520cf",
+		OriginCountry: "Bulgaria",
+		TimePosition:  ptr(seen.Add(10 * time.Second)),
+		LastContact:   seen.Add(10 * time.Second),
+	})
+	result, err = inserter.InsertBatch(redelivered, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Inserted)
+	assert.Equal(t, 2, result.Duplicates)
+
+	var count int64
+	err = db.Model(&FlightStateVector{}).Count(&count).Error
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -3,17 +3,24 @@ package pg
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/dandyZicky/opensky-collector/internal/domain/flight"
+	"github.com/dandyZicky/opensky-collector/internal/domain/processor"
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PgInserter struct {
 	DB *gorm.DB
 }
 
+// NewDB opens the database. The schema is managed by the migrations package
+// and is not touched here.
 func NewDB(config Config) (*gorm.DB, error) {
 	dsn := "host=" + config.Host + " user=" + config.User + " password=" + config.Password + " dbname=" + config.Dbname + " port=" + config.Port + " sslmode=disable"
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
@@ -21,27 +28,61 @@ func NewDB(config Config) (*gorm.DB, error) {
 		return nil, err
 	}
 
-	if err := db.AutoMigrate(&FlightStateVector{}); err != nil {
-		return nil, err
-	}
 	return db, nil
 }
 
-func (p *PgInserter) InsertBatch(flightState []flight.FlightState, batchSize int) error {
+// InsertBatch writes the states, silently skipping any whose natural key is
+// already stored, so redelivered batches are safe to insert again.
+func (p *PgInserter) InsertBatch(flightState []flight.FlightState, batchSize int) (processor.InsertResult, error) {
+	var result processor.InsertResult
 	if p.DB == nil {
-		return fmt.Errorf("database connection is nil")
+		return result, fmt.Errorf("database connection is nil")
 	}
-	tx := p.DB.Begin(&sql.TxOptions{})
 
 	var flightStateVectors []FlightStateVector
 	for _, state := range flightState {
+		// The table is partitioned on time_position; a state without a
+		// position timestamp has no place on the time axis.
+		if state.TimePosition == nil {
+			result.Skipped++
+			continue
+		}
 		flightStateVectors = append(flightStateVectors, ToFlightStateVector(state))
 	}
+	if len(flightStateVectors) == 0 {
+		return result, nil
+	}
 
-	err := tx.CreateInBatches(&flightStateVectors, batchSize).Error
-	if err != nil {
-		return fmt.Errorf("%s", err)
+	tx := p.DB.Begin(&sql.TxOptions{})
+
+	res := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{
+			{Name: "icao24"},
+			{Name: "time_position"},
+			{Name: "last_contact"},
+		},
+		DoNothing: true,
+	}).CreateInBatches(&flightStateVectors, batchSize)
+	if res.Error != nil {
+		tx.Rollback()
+		return result, unprocessable(res.Error)
+	}
+	if err := tx.Commit().Error; err != nil {
+		return result, err
+	}
+
+	result.Inserted = int(res.RowsAffected)
+	result.Duplicates = len(flightStateVectors) - result.Inserted
+	return result, nil
+}
+
+// unprocessable marks data exceptions and constraint violations as
+// processor.ErrUnprocessable: the same rows fail the same way on every
+// retry. Connection and other server errors are left as they are.
+func unprocessable(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && (strings.HasPrefix(pgErr.Code, "22") || strings.HasPrefix(pgErr.Code, "23")) {
+		return fmt.Errorf("%w: %w", processor.ErrUnprocessable, err)
 	}
-	tx.Commit()
-	return nil
+	return err
 }
@@ -11,8 +11,10 @@ import (
 	_ "embed"
 
 	"github.com/dandyZicky/opensky-collector/internal/domain/flight"
+	"github.com/dandyZicky/opensky-collector/internal/infra/pg/migrations"
 	"github.com/dandyZicky/opensky-collector/pkg/events"
 	"github.com/joho/godotenv"
+	"gorm.io/gorm"
 )
 
 //go:embed testdata/.env.db.test
@@ -31,7 +33,34 @@ func loadConfigFromTestEnv() Config {
 		Password: envMap["POSTGRES_PASSWORD"],
 		Port:     envMap["POSTGRES_PORT"],
 		Dbname:   envMap["POSTGRES_DB"],
+
+		ChunkInterval:   "1 day",
+		CompressAfter:   "7 days",
+		RetentionPeriod: "90 days",
+	}
+}
+
+func newMigratedDB(config Config) (*gorm.DB, error) {
+	db, err := NewDB(config)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	migrator, err := migrations.New(sqlDB)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrator.Up(); err != nil {
+		return nil, err
+	}
+	if err := ApplyTimescalePolicies(db, config); err != nil {
+		return nil, err
 	}
+	return db, nil
 }
 
 func loadRawTelemetryData(filepath string) []events.TelemetryRawEvent {
@@ -52,7 +81,7 @@ func loadRawTelemetryData(filepath string) []events.TelemetryRawEvent {
 }
 
 func TestInsertBatchFlightStateVector(t *testing.T) {
-	db, err := NewDB(loadConfigFromTestEnv())
+	db, err := newMigratedDB(loadConfigFromTestEnv())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -66,7 +95,7 @@ func TestInsertBatchFlightStateVector(t *testing.T) {
 		msgs = append(msgs, flight.EventToFlightState(v))
 	}
 
-	err = p.InsertBatch(msgs, batchSize)
+	_, err = p.InsertBatch(msgs, batchSize)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -75,7 +104,7 @@ func TestInsertBatchFlightStateVector(t *testing.T) {
 }
 
 func BenchmarkInsertBatchFlightStateVector(b *testing.B) {
-	db, err := NewDB(loadConfigFromTestEnv())
+	db, err := newMigratedDB(loadConfigFromTestEnv())
 	if err != nil {
 		b.Fatal(err)
 	}
@@ -90,7 +119,7 @@ func BenchmarkInsertBatchFlightStateVector(b *testing.B) {
 	}
 
 	for b.Loop() {
-		err = p.InsertBatch(msgs, batchSize)
+		_, err = p.InsertBatch(msgs, batchSize)
 		if err != nil {
 			b.Fatal(err)
 		}
@@ -98,7 +127,7 @@ func BenchmarkInsertBatchFlightStateVector(b *testing.B) {
 }
 
 func BenchmarkInsertFlightStateVector(b *testing.B) {
-	db, err := NewDB(loadConfigFromTestEnv())
+	db, err := newMigratedDB(loadConfigFromTestEnv())
 	if err != nil {
 		b.Fatal(err)
 	}
@@ -14,4 +14,9 @@ type Config struct {
 	User     string
 	Password string
 	Dbname   string
+
+	// TimescaleDB intervals, in PostgreSQL interval syntax (e.g. "7 days").
+	ChunkInterval   string
+	CompressAfter   string
+	RetentionPeriod string
 }
@@ -0,0 +1,106 @@
+package pg
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/flight"
+	"github.com/dandyZicky/opensky-collector/internal/domain/processor"
+	"gorm.io/gorm"
+)
+
+// PgFlightRepository answers read queries over flight_state_vectors.
+type PgFlightRepository struct {
+	DB *gorm.DB
+}
+
+func (r *PgFlightRepository) Track(icao24 string, from, to time.Time, page processor.Page) ([]flight.FlightState, error) {
+	var rows []FlightStateVector
+	err := r.DB.
+		Where("icao24 = ? AND time_position >= ? AND time_position < ?", icao24, from, to).
+		Order("time_position, last_contact").
+		Limit(page.Limit).
+		Offset(page.Offset).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return toFlightStates(rows), nil
+}
+
+// Snapshot picks the latest state of every aircraft in the window, by
+// position time and then last contact, and only then applies the box, so an
+// aircraft that has since left the box is not reported at an older position
+// inside it. Rows sharing a position time yield one state per aircraft.
+func (r *PgFlightRepository) Snapshot(box *flight.BoundingBox, at time.Time, maxAge time.Duration, page processor.Page) ([]flight.FlightState, error) {
+	ranked := r.DB.Model(&FlightStateVector{}).
+		Select("*, ROW_NUMBER() OVER (PARTITION BY icao24 ORDER BY time_position DESC, last_contact DESC) AS recency").
+		Where("time_position > ? AND time_position <= ?", at.Add(-maxAge), at)
+
+	q := r.DB.Table("(?) AS f", ranked).
+		Select("f.*").
+		Where("f.recency = 1")
+	if box != nil {
+		q = q.Where("f.lat BETWEEN ? AND ? AND f.lon BETWEEN ? AND ?", box.LaMin, box.LaMax, box.LoMin, box.LoMax)
+	}
+
+	var rows []FlightStateVector
+	err := q.Order("f.icao24, f.last_contact").
+		Limit(page.Limit).
+		Offset(page.Offset).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return toFlightStates(rows), nil
+}
+
+func (r *PgFlightRepository) LastKnown(icao24 string) (flight.FlightState, error) {
+	var row FlightStateVector
+	err := r.DB.
+		Where("icao24 = ?", icao24).
+		Order("time_position DESC, last_contact DESC").
+		Take(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return flight.FlightState{}, processor.ErrNotFound
+	}
+	if err != nil {
+		return flight.FlightState{}, err
+	}
+	return row.ToFlightState(), nil
+}
+
+func (r *PgFlightRepository) Stats(from, to time.Time, page processor.Page) ([]processor.CountryHourStats, error) {
+	var stats []processor.CountryHourStats
+	err := r.DB.Model(&FlightStateVector{}).
+		Select("origin_country AS country, date_trunc('hour', time_position) AS hour, COUNT(*) AS states, COUNT(DISTINCT icao24) AS aircraft").
+		Where("time_position >= ? AND time_position < ?", from, to).
+		Group("country, hour").
+		Order("hour, country").
+		Limit(page.Limit).
+		Offset(page.Offset).
+		Scan(&stats).Error
+	return stats, err
+}
+
+func (r *PgFlightRepository) Flights(icao24 string, from, to time.Time, page processor.Page) ([]flight.Flight, error) {
+	var rows []FlightRecord
+	err := r.DB.
+		Where("icao24 = ? AND start_time < ? AND end_time >= ?", icao24, to, from).
+		Order("start_time").
+		Limit(page.Limit).
+		Offset(page.Offset).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return toFlights(rows), nil
+}
+
+func toFlightStates(rows []FlightStateVector) []flight.FlightState {
+	states := make([]flight.FlightState, 0, len(rows))
+	for _, row := range rows {
+		states = append(states, row.ToFlightState())
+	}
+	return states
+}
@@ -0,0 +1,116 @@
+package pg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/flight"
+	"github.com/dandyZicky/opensky-collector/internal/domain/processor"
+)
+
+func newTestRepository(t *testing.T, states ...flight.FlightState) *PgFlightRepository {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&FlightStateVector{}))
+
+	_, err = (&PgInserter{DB: db}).InsertBatch(states, 10)
+	require.NoError(t, err)
+	return &PgFlightRepository{DB: db}
+}
+
+func stateAt(icao24 string, at time.Time, lat, lon float64) flight.FlightState {
+	return flight.FlightState{
+		Icao24:        icao24,
+		OriginCountry: "Indonesia",
+		Lat:           ptr(lat),
+		Lon:           ptr(lon),
+		TimePosition:  ptr(at),
+		LastContact:   at,
+	}
+}
+
+func TestPgFlightRepository_Track(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	repo := newTestRepository(t,
+		stateAt("8a0001", base.Add(2*time.Minute), -6.2, 106.8),
+		stateAt("8a0001", base, -6.0, 106.5),
+		stateAt("8a0001", base.Add(time.Minute), -6.1, 106.6),
+		stateAt("8a0002", base, -8.7, 115.2),
+	)
+
+	track, err := repo.Track("8a0001", base, base.Add(2*time.Minute), processor.Page{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, track, 2)
+	assert.Equal(t, ptr(-6.0), track[0].Lat)
+	assert.Equal(t, ptr(-6.1), track[1].Lat)
+
+	track, err = repo.Track("8a0001", base, base.Add(time.Hour), processor.Page{Limit: 1, Offset: 2})
+	require.NoError(t, err)
+	require.Len(t, track, 1)
+	assert.Equal(t, ptr(-6.2), track[0].Lat)
+}
+
+func TestPgFlightRepository_Snapshot(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	java := &flight.BoundingBox{LaMin: -9, LoMin: 105, LaMax: -5, LoMax: 115}
+	repo := newTestRepository(t,
+		// Inside the box, then left it: must not be reported.
+		stateAt("8a0001", base.Add(-2*time.Minute), -6.0, 106.0),
+		stateAt("8a0001", base.Add(-time.Minute), 1.0, 104.0),
+		// Latest position inside the box.
+		stateAt("8a0002", base.Add(-3*time.Minute), -7.0, 110.0),
+		stateAt("8a0002", base.Add(-time.Minute), -7.1, 110.2),
+		// Too old for the window.
+		stateAt("8a0003", base.Add(-time.Hour), -7.0, 110.0),
+		// After the snapshot time.
+		stateAt("8a0004", base.Add(time.Minute), -7.0, 110.0),
+	)
+
+	states, err := repo.Snapshot(java, base, 5*time.Minute, processor.Page{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, states, 1)
+	assert.Equal(t, "8a0002", states[0].Icao24)
+	assert.Equal(t, ptr(-7.1), states[0].Lat)
+
+	states, err = repo.Snapshot(nil, base, 5*time.Minute, processor.Page{Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, states, 2)
+}
+
+func TestPgFlightRepository_SnapshotSamePositionTime(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	first := stateAt("8a0001", base.Add(-time.Minute), -6.0, 106.0)
+	// Reported again without a new position fix.
+	again := first
+	again.LastContact = base
+	again.Velocity = ptr(210.0)
+	repo := newTestRepository(t, first, again)
+
+	states, err := repo.Snapshot(nil, base, 5*time.Minute, processor.Page{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, states, 1)
+	assert.True(t, states[0].LastContact.Equal(base))
+	assert.Equal(t, ptr(210.0), states[0].Velocity)
+}
+
+func TestPgFlightRepository_LastKnown(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	repo := newTestRepository(t,
+		stateAt("8a0001", base, -6.0, 106.0),
+		stateAt("8a0001", base.Add(time.Minute), -6.1, 106.1),
+	)
+
+	state, err := repo.LastKnown("8a0001")
+	require.NoError(t, err)
+	assert.Equal(t, ptr(-6.1), state.Lat)
+	require.NotNil(t, state.TimePosition)
+	assert.True(t, state.TimePosition.Equal(base.Add(time.Minute)))
+
+	_, err = repo.LastKnown("ffffff")
+	assert.ErrorIs(t, err, processor.ErrNotFound)
+}
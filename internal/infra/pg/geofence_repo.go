@@ -0,0 +1,118 @@
+package pg
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/geofence"
+	"gorm.io/gorm"
+)
+
+// GeofenceRecord is a stored geofence with its GeoJSON geometry.
+type GeofenceRecord struct {
+	ID        int64     `gorm:"primaryKey"`
+	Name      string    `gorm:"not null"`
+	Active    bool      `gorm:"not null"`
+	Geometry  string    `gorm:"type:jsonb not null"`
+	CreatedAt time.Time `gorm:"type:timestamp not null"`
+	UpdatedAt time.Time `gorm:"type:timestamp not null"`
+}
+
+func (GeofenceRecord) TableName() string {
+	return "geofences"
+}
+
+func (g GeofenceRecord) ToFence() geofence.Fence {
+	return geofence.Fence{
+		ID:        g.ID,
+		Name:      g.Name,
+		Active:    g.Active,
+		Geometry:  []byte(g.Geometry),
+		CreatedAt: g.CreatedAt,
+		UpdatedAt: g.UpdatedAt,
+	}
+}
+
+type PgGeofenceRepository struct {
+	DB *gorm.DB
+}
+
+func (r *PgGeofenceRepository) List(limit, offset int) ([]geofence.Fence, error) {
+	var rows []GeofenceRecord
+	if err := r.DB.Order("id").Limit(limit).Offset(offset).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return toFences(rows), nil
+}
+
+func (r *PgGeofenceRepository) Active() ([]geofence.Fence, error) {
+	var rows []GeofenceRecord
+	if err := r.DB.Where("active").Order("id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return toFences(rows), nil
+}
+
+func (r *PgGeofenceRepository) Get(id int64) (geofence.Fence, error) {
+	var row GeofenceRecord
+	err := r.DB.Take(&row, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return geofence.Fence{}, geofence.ErrNotFound
+	}
+	if err != nil {
+		return geofence.Fence{}, err
+	}
+	return row.ToFence(), nil
+}
+
+func (r *PgGeofenceRepository) Create(f geofence.Fence) (geofence.Fence, error) {
+	now := time.Now().UTC()
+	row := GeofenceRecord{
+		Name:      f.Name,
+		Active:    f.Active,
+		Geometry:  string(f.Geometry),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := r.DB.Create(&row).Error; err != nil {
+		return geofence.Fence{}, err
+	}
+	return row.ToFence(), nil
+}
+
+func (r *PgGeofenceRepository) Update(f geofence.Fence) (geofence.Fence, error) {
+	result := r.DB.Model(&GeofenceRecord{ID: f.ID}).
+		Select("name", "active", "geometry", "updated_at").
+		Updates(GeofenceRecord{
+			Name:      f.Name,
+			Active:    f.Active,
+			Geometry:  string(f.Geometry),
+			UpdatedAt: time.Now().UTC(),
+		})
+	if result.Error != nil {
+		return geofence.Fence{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return geofence.Fence{}, geofence.ErrNotFound
+	}
+	return r.Get(f.ID)
+}
+
+func (r *PgGeofenceRepository) Delete(id int64) error {
+	result := r.DB.Delete(&GeofenceRecord{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return geofence.ErrNotFound
+	}
+	return nil
+}
+
+func toFences(rows []GeofenceRecord) []geofence.Fence {
+	fences := make([]geofence.Fence, 0, len(rows))
+	for _, row := range rows {
+		fences = append(fences, row.ToFence())
+	}
+	return fences
+}
@@ -0,0 +1,49 @@
+package pg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/geofence"
+)
+
+func TestPgGeofenceRepository(t *testing.T) {
+	flights := newTestRepository(t)
+	require.NoError(t, flights.DB.AutoMigrate(&GeofenceRecord{}))
+	repo := &PgGeofenceRepository{DB: flights.DB}
+
+	square := []byte(`{"type":"Polygon","coordinates":[[[106.5,-6.5],[107,-6.5],[107,-6],[106.5,-6],[106.5,-6.5]]]}`)
+	jakarta, err := repo.Create(geofence.Fence{Name: "Jakarta", Active: true, Geometry: square})
+	require.NoError(t, err)
+	assert.NotZero(t, jakarta.ID)
+	assert.False(t, jakarta.CreatedAt.IsZero())
+
+	draft, err := repo.Create(geofence.Fence{Name: "Draft", Active: false, Geometry: square})
+	require.NoError(t, err)
+
+	active, err := repo.Active()
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, "Jakarta", active[0].Name)
+	assert.JSONEq(t, string(square), string(active[0].Geometry))
+
+	draft.Active = true
+	draft.Name = "Bandung"
+	updated, err := repo.Update(draft)
+	require.NoError(t, err)
+	assert.Equal(t, "Bandung", updated.Name)
+	assert.True(t, updated.Active)
+
+	all, err := repo.List(10, 0)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	require.NoError(t, repo.Delete(jakarta.ID))
+	_, err = repo.Get(jakarta.ID)
+	assert.ErrorIs(t, err, geofence.ErrNotFound)
+	assert.ErrorIs(t, repo.Delete(jakarta.ID), geofence.ErrNotFound)
+	_, err = repo.Update(jakarta)
+	assert.ErrorIs(t, err, geofence.ErrNotFound)
+}
@@ -0,0 +1,308 @@
+// Package migrations contains the versioned SQL schema of the processor
+// database and a migrator that applies it.
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+//go:embed sql/*.sql
+var embedded embed.FS
+
+const createSchemaMigrations = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version    BIGINT    PRIMARY KEY,
+	name       TEXT      NOT NULL,
+	applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is one schema version with the SQL to apply and revert it.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status reports whether a migration has been applied and when.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New returns a migrator for the SQL files embedded in this package.
+func New(db *sql.DB) (*Migrator, error) {
+	sub, err := fs.Sub(embedded, "sql")
+	if err != nil {
+		return nil, err
+	}
+	return NewFromFS(db, sub)
+}
+
+// NewFromFS returns a migrator for the <version>_<name>.{up,down}.sql files
+// at the root of fsys. Every version needs both an up and a down file.
+func NewFromFS(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		m := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || m == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		}
+		if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both up and down files", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Migrations returns every known migration in version order.
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Latest returns the highest known version, or 0 when there are none.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the highest applied version, or 0 on an empty database.
+func (m *Migrator) Version() (int64, error) {
+	var version sql.NullInt64
+	err := m.db.QueryRow("SELECT MAX(version) FROM schema_migrations").Scan(&version)
+	if missingTable(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return version.Int64, nil
+}
+
+// Status lists every known migration and whether it has been applied.
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		at, ok := applied[mig.Version]
+		statuses = append(statuses, Status{Migration: mig, Applied: ok, AppliedAt: at})
+	}
+	return statuses, nil
+}
+
+// Pending returns the migrations that have not been applied yet.
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; !ok {
+			pending = append(pending, mig)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies every pending migration in version order.
+func (m *Migrator) Up() error {
+	return m.To(m.Latest())
+}
+
+// Down reverts the most recently applied migration.
+func (m *Migrator) Down() error {
+	if err := m.ensureTable(); err != nil {
+		return err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		if _, ok := applied[m.migrations[i].Version]; ok {
+			return m.revert(m.migrations[i])
+		}
+	}
+	return nil
+}
+
+// To applies or reverts migrations until exactly those up to and including
+// version are applied. Version 0 reverts everything.
+func (m *Migrator) To(version int64) error {
+	if version != 0 && !m.known(version) {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+	if err := m.ensureTable(); err != nil {
+		return err
+	}
+
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		mig := m.migrations[i]
+		if _, ok := applied[mig.Version]; ok && mig.Version > version {
+			if err := m.revert(mig); err != nil {
+				return err
+			}
+		}
+	}
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; !ok && mig.Version <= version {
+			if err := m.apply(mig); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) apply(mig Migration) error {
+	return m.inTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(mig.Up); err != nil {
+			return fmt.Errorf("applying migration %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		_, err := tx.Exec("INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", mig.Version, mig.Name)
+		return err
+	})
+}
+
+func (m *Migrator) revert(mig Migration) error {
+	return m.inTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(mig.Down); err != nil {
+			return fmt.Errorf("reverting migration %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		_, err := tx.Exec("DELETE FROM schema_migrations WHERE version = $1", mig.Version)
+		return err
+	})
+}
+
+func (m *Migrator) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// applied reads the applied versions without creating schema_migrations, so
+// Version, Status and Pending are safe on a database nobody has migrated.
+func (m *Migrator) applied() (map[int64]time.Time, error) {
+	rows, err := m.db.Query("SELECT version, applied_at FROM schema_migrations")
+	if missingTable(err) {
+		return map[int64]time.Time{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+// ensureTable creates schema_migrations for the methods that change the
+// schema.
+func (m *Migrator) ensureTable() error {
+	_, err := m.db.Exec(createSchemaMigrations)
+	return err
+}
+
+// missingTable reports whether err is Postgres (undefined_table) or SQLite
+// saying schema_migrations does not exist.
+func missingTable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "42P01"
+	}
+	return strings.Contains(err.Error(), "no such table")
+}
+
+func (m *Migrator) known(version int64) bool {
+	for _, mig := range m.migrations {
+		if mig.Version == version {
+			return true
+		}
+	}
+	return false
+}
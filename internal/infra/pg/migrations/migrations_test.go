@@ -0,0 +1,163 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"0001_create_a.up.sql":   {Data: []byte("CREATE TABLE a (id INTEGER)")},
+		"0001_create_a.down.sql": {Data: []byte("DROP TABLE a")},
+		"0002_create_b.up.sql":   {Data: []byte("CREATE TABLE b (id INTEGER)")},
+		"0002_create_b.down.sql": {Data: []byte("DROP TABLE b")},
+		"0003_create_c.up.sql":   {Data: []byte("CREATE TABLE c (id INTEGER)")},
+		"0003_create_c.down.sql": {Data: []byte("DROP TABLE c")},
+		"README.md":              {Data: []byte("ignored")},
+	}
+}
+
+func openTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", "file::memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func tableExists(t *testing.T, db *sql.DB, name string) bool {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = $1", name).Scan(&count)
+	require.NoError(t, err)
+	return count == 1
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	m, err := New(openTestDB(t))
+	require.NoError(t, err)
+
+	migrations := m.Migrations()
+	require.NotEmpty(t, migrations)
+	for i, mig := range migrations {
+		assert.Equal(t, int64(i+1), mig.Version, "migration versions must be contiguous")
+		assert.NotEmpty(t, mig.Up)
+		assert.NotEmpty(t, mig.Down)
+	}
+}
+
+func TestMigrator_UpAndDown(t *testing.T) {
+	db := openTestDB(t)
+	m, err := NewFromFS(db, testFS())
+	require.NoError(t, err)
+
+	pending, err := m.Pending()
+	require.NoError(t, err)
+	assert.Len(t, pending, 3)
+
+	require.NoError(t, m.Up())
+
+	version, err := m.Version()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), version)
+	assert.True(t, tableExists(t, db, "c"))
+
+	pending, err = m.Pending()
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	require.NoError(t, m.Down())
+
+	version, err = m.Version()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), version)
+	assert.False(t, tableExists(t, db, "c"))
+	assert.True(t, tableExists(t, db, "b"))
+}
+
+func TestMigrator_ReadsWithoutCreatingTable(t *testing.T) {
+	db := openTestDB(t)
+	m, err := NewFromFS(db, testFS())
+	require.NoError(t, err)
+
+	pending, err := m.Pending()
+	require.NoError(t, err)
+	assert.Len(t, pending, 3)
+	statuses, err := m.Status()
+	require.NoError(t, err)
+	require.Len(t, statuses, 3)
+	assert.False(t, statuses[0].Applied)
+	version, err := m.Version()
+	require.NoError(t, err)
+	assert.Zero(t, version)
+	assert.False(t, tableExists(t, db, "schema_migrations"), "checking a database does not migrate it")
+
+	require.NoError(t, m.Down())
+	assert.True(t, tableExists(t, db, "schema_migrations"))
+
+	db.Close()
+	_, err = m.Pending()
+	assert.Error(t, err, "other errors are not taken for a missing table")
+}
+
+func TestMissingTable(t *testing.T) {
+	assert.True(t, missingTable(fmt.Errorf("query: %w", &pgconn.PgError{Code: "42P01"})))
+	assert.False(t, missingTable(&pgconn.PgError{Code: "42501"}), "insufficient_privilege")
+	assert.False(t, missingTable(nil))
+}
+
+func TestMigrator_To(t *testing.T) {
+	db := openTestDB(t)
+	m, err := NewFromFS(db, testFS())
+	require.NoError(t, err)
+
+	require.NoError(t, m.To(2))
+	assert.True(t, tableExists(t, db, "b"))
+	assert.False(t, tableExists(t, db, "c"))
+
+	require.NoError(t, m.To(3))
+	assert.True(t, tableExists(t, db, "c"))
+
+	require.NoError(t, m.To(1))
+	assert.True(t, tableExists(t, db, "a"))
+	assert.False(t, tableExists(t, db, "b"))
+
+	statuses, err := m.Status()
+	require.NoError(t, err)
+	require.Len(t, statuses, 3)
+	assert.True(t, statuses[0].Applied)
+	assert.False(t, statuses[1].Applied)
+	assert.False(t, statuses[2].Applied)
+
+	assert.Error(t, m.To(42))
+}
+
+func TestMigrator_FailedMigrationRollsBack(t *testing.T) {
+	db := openTestDB(t)
+	fsys := testFS()
+	fsys["0002_create_b.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE b (id INTEGER); NOT SQL")}
+
+	m, err := NewFromFS(db, fsys)
+	require.NoError(t, err)
+
+	assert.Error(t, m.Up())
+
+	version, err := m.Version()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), version)
+	assert.False(t, tableExists(t, db, "b"))
+}
+
+func TestNewFromFS_MissingDown(t *testing.T) {
+	fsys := testFS()
+	delete(fsys, "0003_create_c.down.sql")
+
+	_, err := NewFromFS(openTestDB(t), fsys)
+	assert.Error(t, err)
+}
@@ -0,0 +1,2 @@
+-- flight_state_vectors_unpositioned, if the upgrade created it, is kept.
+DROP TABLE IF EXISTS flight_state_vectors;
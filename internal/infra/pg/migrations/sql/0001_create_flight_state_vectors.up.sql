@@ -0,0 +1,51 @@
+CREATE TABLE IF NOT EXISTS flight_state_vectors (
+    icao24          TEXT             NOT NULL,
+    callsign        TEXT,
+    origin_country  TEXT             NOT NULL,
+    lat             DOUBLE PRECISION,
+    lon             DOUBLE PRECISION,
+    velocity        DOUBLE PRECISION,
+    time_position   TIMESTAMP        NOT NULL,
+    baro_altitude   DOUBLE PRECISION,
+    geo_altitude    DOUBLE PRECISION,
+    last_contact    TIMESTAMP        NOT NULL,
+    on_ground       BOOLEAN          NOT NULL DEFAULT FALSE,
+    true_track      DOUBLE PRECISION,
+    vertical_rate   DOUBLE PRECISION,
+    sensors         JSONB,
+    squawk          TEXT,
+    spi             BOOLEAN          NOT NULL DEFAULT FALSE,
+    position_source BIGINT           NOT NULL DEFAULT 0,
+    category        BIGINT           NOT NULL DEFAULT 0,
+    region          TEXT             NOT NULL DEFAULT ''
+);
+
+-- Tables created by GORM AutoMigrate in earlier releases may still carry the
+-- surrogate id key, lack the newer columns, or hold rows without a position
+-- timestamp. Bring them in line with the definition above; rows without a
+-- position timestamp are moved to flight_state_vectors_unpositioned rather
+-- than deleted, for the operator to inspect or drop.
+ALTER TABLE flight_state_vectors DROP CONSTRAINT IF EXISTS flight_state_vectors_pkey;
+ALTER TABLE flight_state_vectors DROP COLUMN IF EXISTS id;
+ALTER TABLE flight_state_vectors ADD COLUMN IF NOT EXISTS callsign TEXT;
+ALTER TABLE flight_state_vectors ADD COLUMN IF NOT EXISTS on_ground BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE flight_state_vectors ADD COLUMN IF NOT EXISTS true_track DOUBLE PRECISION;
+ALTER TABLE flight_state_vectors ADD COLUMN IF NOT EXISTS vertical_rate DOUBLE PRECISION;
+ALTER TABLE flight_state_vectors ADD COLUMN IF NOT EXISTS sensors JSONB;
+ALTER TABLE flight_state_vectors ADD COLUMN IF NOT EXISTS squawk TEXT;
+ALTER TABLE flight_state_vectors ADD COLUMN IF NOT EXISTS spi BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE flight_state_vectors ADD COLUMN IF NOT EXISTS position_source BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE flight_state_vectors ADD COLUMN IF NOT EXISTS category BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE flight_state_vectors ADD COLUMN IF NOT EXISTS region TEXT NOT NULL DEFAULT '';
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM flight_state_vectors WHERE time_position IS NULL) THEN
+        CREATE TABLE IF NOT EXISTS flight_state_vectors_unpositioned
+            (LIKE flight_state_vectors);
+        INSERT INTO flight_state_vectors_unpositioned
+            SELECT * FROM flight_state_vectors WHERE time_position IS NULL;
+        DELETE FROM flight_state_vectors WHERE time_position IS NULL;
+        RAISE NOTICE 'Moved state vectors without time_position to flight_state_vectors_unpositioned';
+    END IF;
+END $$;
+ALTER TABLE flight_state_vectors ALTER COLUMN time_position SET NOT NULL;
@@ -0,0 +1,9 @@
+SELECT remove_retention_policy('flight_state_vectors', if_exists => TRUE);
+SELECT remove_compression_policy('flight_state_vectors', if_exists => TRUE);
+
+-- A hypertable cannot be converted back in place, so copy the rows into a
+-- plain table and swap it in.
+CREATE TABLE flight_state_vectors_plain (LIKE flight_state_vectors INCLUDING DEFAULTS);
+INSERT INTO flight_state_vectors_plain SELECT * FROM flight_state_vectors;
+DROP TABLE flight_state_vectors;
+ALTER TABLE flight_state_vectors_plain RENAME TO flight_state_vectors;
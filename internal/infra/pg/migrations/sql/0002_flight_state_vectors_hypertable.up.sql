@@ -0,0 +1,27 @@
+CREATE EXTENSION IF NOT EXISTS timescaledb;
+
+-- Chunk interval, compression and retention policies are runtime settings
+-- applied by the processor from config.Database; see pg.ApplyTimescalePolicies.
+SELECT create_hypertable(
+    'flight_state_vectors',
+    by_range('time_position'),
+    if_not_exists => TRUE,
+    migrate_data => TRUE
+);
+
+CREATE INDEX IF NOT EXISTS idx_flight_state_vectors_icao24_time
+    ON flight_state_vectors (icao24, time_position DESC);
+
+DO $$
+BEGIN
+    IF NOT (SELECT compression_enabled
+              FROM timescaledb_information.hypertables
+             WHERE hypertable_name = 'flight_state_vectors') THEN
+        ALTER TABLE flight_state_vectors SET (
+            timescaledb.compress,
+            timescaledb.compress_segmentby = 'icao24',
+            timescaledb.compress_orderby = 'time_position DESC'
+        );
+    END IF;
+END
+$$;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS uq_flight_state_vectors_natural_key;
@@ -0,0 +1,14 @@
+-- Redelivered messages and overlapping collector regions used to insert the
+-- same state vector more than once. Keep one copy before enforcing the key.
+DELETE FROM flight_state_vectors f
+USING (
+    SELECT tableoid, ctid,
+           row_number() OVER (PARTITION BY icao24, time_position, last_contact ORDER BY ctid) AS rn
+      FROM flight_state_vectors
+) d
+WHERE f.tableoid = d.tableoid
+  AND f.ctid = d.ctid
+  AND d.rn > 1;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_flight_state_vectors_natural_key
+    ON flight_state_vectors (icao24, time_position, last_contact);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS aircraft (
+    icao24       TEXT      PRIMARY KEY,
+    registration TEXT      NOT NULL DEFAULT '',
+    typecode     TEXT      NOT NULL DEFAULT '',
+    manufacturer TEXT      NOT NULL DEFAULT '',
+    model        TEXT      NOT NULL DEFAULT '',
+    operator     TEXT      NOT NULL DEFAULT '',
+    updated_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
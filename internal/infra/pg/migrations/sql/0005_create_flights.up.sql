@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS flights (
+    id            BIGSERIAL        PRIMARY KEY,
+    icao24        TEXT             NOT NULL,
+    callsign      TEXT,
+    start_time    TIMESTAMP        NOT NULL,
+    end_time      TIMESTAMP        NOT NULL,
+    start_lat     DOUBLE PRECISION NOT NULL,
+    start_lon     DOUBLE PRECISION NOT NULL,
+    end_lat       DOUBLE PRECISION NOT NULL,
+    end_lon       DOUBLE PRECISION NOT NULL,
+    max_altitude  DOUBLE PRECISION,
+    distance_m    DOUBLE PRECISION NOT NULL DEFAULT 0,
+    points        BIGINT           NOT NULL DEFAULT 0,
+    end_on_ground BOOLEAN          NOT NULL DEFAULT FALSE
+);
+
+-- A flight is identified by its aircraft and start, which the segmenter
+-- upserts on as the flight grows.
+CREATE UNIQUE INDEX IF NOT EXISTS uq_flights_icao24_start_time
+    ON flights (icao24, start_time);
+CREATE INDEX IF NOT EXISTS ix_flights_icao24_end_time
+    ON flights (icao24, end_time);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS airports (
+    ident        TEXT             PRIMARY KEY,
+    code         TEXT             NOT NULL,
+    name         TEXT             NOT NULL DEFAULT '',
+    type         TEXT             NOT NULL DEFAULT '',
+    lat          DOUBLE PRECISION NOT NULL,
+    lon          DOUBLE PRECISION NOT NULL,
+    elevation_ft DOUBLE PRECISION,
+    country      TEXT             NOT NULL DEFAULT ''
+);
+
+-- One row per runway end, so both directions can be matched to a track.
+CREATE TABLE IF NOT EXISTS runways (
+    airport_ident TEXT             NOT NULL REFERENCES airports (ident) ON DELETE CASCADE,
+    ident         TEXT             NOT NULL,
+    heading_deg   DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY (airport_ident, ident)
+);
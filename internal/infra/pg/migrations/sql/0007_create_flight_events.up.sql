@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS flight_events (
+    id       BIGSERIAL        PRIMARY KEY,
+    icao24   TEXT             NOT NULL,
+    callsign TEXT,
+    type     TEXT             NOT NULL,
+    airport  TEXT             NOT NULL,
+    runway   TEXT             NOT NULL DEFAULT '',
+    time     TIMESTAMP        NOT NULL,
+    lat      DOUBLE PRECISION NOT NULL,
+    lon      DOUBLE PRECISION NOT NULL
+);
+
+-- Redelivered batches detect the same events again.
+CREATE UNIQUE INDEX IF NOT EXISTS uq_flight_events_natural_key
+    ON flight_events (icao24, type, time);
+CREATE INDEX IF NOT EXISTS ix_flight_events_airport_time
+    ON flight_events (airport, time);
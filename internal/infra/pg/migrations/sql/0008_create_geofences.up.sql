@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS geofences (
+    id         BIGSERIAL PRIMARY KEY,
+    name       TEXT      NOT NULL,
+    active     BOOLEAN   NOT NULL DEFAULT TRUE,
+    -- The GeoJSON Polygon or MultiPolygon the fence was defined with.
+    geometry   JSONB     NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS quarantined_states (
+    id             BIGSERIAL PRIMARY KEY,
+    -- SHA-256 of the reason, detail and payload.
+    fingerprint    TEXT      NOT NULL,
+    icao24         TEXT      NOT NULL,
+    reason         TEXT      NOT NULL,
+    detail         TEXT      NOT NULL,
+    last_contact   BIGINT    NOT NULL,
+    -- The rejected event as it was consumed.
+    payload        JSONB     NOT NULL,
+    quarantined_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Retried and redelivered batches reject the same events again.
+CREATE UNIQUE INDEX IF NOT EXISTS uq_quarantined_states_fingerprint
+    ON quarantined_states (fingerprint);
+CREATE INDEX IF NOT EXISTS ix_quarantined_states_reason
+    ON quarantined_states (reason, quarantined_at);
@@ -0,0 +1,123 @@
+package pg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/validation"
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// QuarantineRecord is a rejected state vector with the rule it failed.
+// Fingerprint identifies the rejection, so a retried or redelivered batch
+// does not quarantine the same event twice.
+type QuarantineRecord struct {
+	ID            int64     `gorm:"primaryKey"`
+	Fingerprint   string    `gorm:"not null;uniqueIndex:uq_quarantined_states_fingerprint"`
+	Icao24        string    `gorm:"not null"`
+	Reason        string    `gorm:"not null"`
+	Detail        string    `gorm:"not null"`
+	LastContact   int64     `gorm:"not null"`
+	Payload       string    `gorm:"type:jsonb not null"`
+	QuarantinedAt time.Time `gorm:"type:timestamp not null;autoCreateTime"`
+}
+
+func (QuarantineRecord) TableName() string {
+	return "quarantined_states"
+}
+
+type PgQuarantineRepository struct {
+	DB *gorm.DB
+}
+
+// Save stores the rejections that are not quarantined yet and returns them.
+func (r *PgQuarantineRepository) Save(rejected []validation.Rejection) ([]validation.Rejection, error) {
+	if len(rejected) == 0 {
+		return nil, nil
+	}
+	rows := make([]QuarantineRecord, 0, len(rejected))
+	fresh := make(map[string]validation.Rejection, len(rejected))
+	for _, rej := range rejected {
+		payload, err := quarantinePayload(rej.Event)
+		if err != nil {
+			return nil, err
+		}
+		fp := fingerprint(rej, payload)
+		if _, ok := fresh[fp]; ok {
+			continue
+		}
+		fresh[fp] = rej
+		rows = append(rows, QuarantineRecord{
+			Fingerprint: fp,
+			Icao24:      rej.Event.Icao24,
+			Reason:      rej.Reason,
+			Detail:      rej.Detail,
+			LastContact: rej.Event.LastContact,
+			Payload:     string(payload),
+		})
+	}
+
+	var saved []validation.Rejection
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		fingerprints := make([]string, 0, len(rows))
+		for _, row := range rows {
+			fingerprints = append(fingerprints, row.Fingerprint)
+		}
+		var existing []string
+		if err := tx.Model(&QuarantineRecord{}).Where("fingerprint IN ?", fingerprints).
+			Pluck("fingerprint", &existing).Error; err != nil {
+			return err
+		}
+		for _, fp := range existing {
+			delete(fresh, fp)
+		}
+
+		insert := rows[:0]
+		for _, row := range rows {
+			if rej, ok := fresh[row.Fingerprint]; ok {
+				insert = append(insert, row)
+				saved = append(saved, rej)
+			}
+		}
+		if len(insert) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "fingerprint"}},
+			DoNothing: true,
+		}).Create(&insert).Error
+	})
+	if err != nil {
+		return nil, unprocessable(err)
+	}
+	return saved, nil
+}
+
+// fingerprint hashes what is stored of a rejection. Events with an invalid
+// icao24 have nothing better to be told apart by.
+func fingerprint(rej validation.Rejection, payload []byte) string {
+	h := sha256.New()
+	h.Write([]byte(rej.Reason))
+	h.Write([]byte{0})
+	h.Write([]byte(rej.Detail))
+	h.Write([]byte{0})
+	h.Write(payload)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// quarantinePayload encodes a rejected event. JSON cannot hold NaN or
+// infinities, which the range rules reject, so they are stored as null; the
+// rejection detail keeps the original value.
+func quarantinePayload(ev events.TelemetryRawEvent) ([]byte, error) {
+	for _, v := range []**float64{&ev.Lat, &ev.Lon, &ev.Velocity, &ev.BaroAltitude, &ev.GeoAltitude, &ev.TrueTrack, &ev.VerticalRate} {
+		if *v != nil && (math.IsNaN(**v) || math.IsInf(**v, 0)) {
+			*v = nil
+		}
+	}
+	return json.Marshal(ev)
+}
@@ -0,0 +1,95 @@
+package pg
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/validation"
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+func TestPgQuarantineRepository_Save(t *testing.T) {
+	flights := newTestRepository(t)
+	require.NoError(t, flights.DB.AutoMigrate(&QuarantineRecord{}))
+	repo := &PgQuarantineRepository{DB: flights.DB}
+
+	lat := 95.0
+	rejected := validation.Rejection{
+		Event:  events.TelemetryRawEvent{Icao24: "8a0001", Lat: &lat, LastContact: 1704110400},
+		Reason: validation.ReasonLatitudeRange,
+		Detail: "latitude 95 outside ±90",
+	}
+	saved, err := repo.Save([]validation.Rejection{rejected, rejected})
+	require.NoError(t, err)
+	assert.Equal(t, []validation.Rejection{rejected}, saved)
+	// Redelivered batches are ignored.
+	saved, err = repo.Save([]validation.Rejection{rejected})
+	require.NoError(t, err)
+	assert.Empty(t, saved)
+	saved, err = repo.Save(nil)
+	require.NoError(t, err)
+	assert.Empty(t, saved)
+
+	var rows []QuarantineRecord
+	require.NoError(t, flights.DB.Find(&rows).Error)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "latitude_range", rows[0].Reason)
+	assert.Equal(t, int64(1704110400), rows[0].LastContact)
+	assert.False(t, rows[0].QuarantinedAt.IsZero())
+
+	var payload events.TelemetryRawEvent
+	require.NoError(t, json.Unmarshal([]byte(rows[0].Payload), &payload))
+	assert.Equal(t, rejected.Event, payload)
+}
+
+func TestPgQuarantineRepository_SaveNonFinite(t *testing.T) {
+	flights := newTestRepository(t)
+	require.NoError(t, flights.DB.AutoMigrate(&QuarantineRecord{}))
+	repo := &PgQuarantineRepository{DB: flights.DB}
+
+	lat, lon, velocity := -6.1, math.NaN(), math.Inf(1)
+	ev := events.TelemetryRawEvent{Icao24: "8a0001", Lat: &lat, Lon: &lon, Velocity: &velocity, LastContact: 1704110400}
+	_, err := repo.Save([]validation.Rejection{
+		{Event: ev, Reason: validation.ReasonLongitudeRange, Detail: "longitude NaN outside ±180"},
+	})
+	require.NoError(t, err)
+	assert.True(t, math.IsNaN(*ev.Lon), "the event is not modified")
+
+	var row QuarantineRecord
+	require.NoError(t, flights.DB.Take(&row).Error)
+	var payload events.TelemetryRawEvent
+	require.NoError(t, json.Unmarshal([]byte(row.Payload), &payload))
+	assert.Nil(t, payload.Lon)
+	assert.Nil(t, payload.Velocity)
+	assert.Equal(t, -6.1, *payload.Lat)
+}
+
+func TestPgQuarantineRepository_SaveInvalidIcao24(t *testing.T) {
+	flights := newTestRepository(t)
+	require.NoError(t, flights.DB.AutoMigrate(&QuarantineRecord{}))
+	repo := &PgQuarantineRepository{DB: flights.DB}
+
+	reject := func(lat float64) validation.Rejection {
+		return validation.Rejection{
+			Event:  events.TelemetryRawEvent{Lat: &lat, LastContact: 1704110400},
+			Reason: validation.ReasonInvalidIcao24,
+			Detail: `icao24 "" is not 6 hex digits`,
+		}
+	}
+	saved, err := repo.Save([]validation.Rejection{reject(-6.1), reject(-6.2)})
+	require.NoError(t, err)
+	assert.Len(t, saved, 2, "distinct events without an icao24 are all kept")
+
+	saved, err = repo.Save([]validation.Rejection{reject(-6.1), reject(-6.3)})
+	require.NoError(t, err)
+	require.Len(t, saved, 1, "a retried batch does not quarantine them again")
+	assert.Equal(t, -6.3, *saved[0].Event.Lat)
+
+	var count int64
+	require.NoError(t, flights.DB.Model(&QuarantineRecord{}).Count(&count).Error)
+	assert.Equal(t, int64(3), count)
+}
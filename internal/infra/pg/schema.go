@@ -7,44 +7,88 @@ import (
 	"github.com/dandyZicky/opensky-collector/pkg/events"
 )
 
+// FlightStateVector is the persisted state vector. Values OpenSky did not
+// report are stored as NULL rather than zero, except time_position, which
+// partitions the hypertable and is always set. (icao24, time_position,
+// last_contact) is the natural key used to drop redelivered rows.
 type FlightStateVector struct {
-	ID            uint      `gorm:"primaryKey"`
-	Icao24        string    `gorm:"not null"`
-	OriginCountry string    `gorm:"not null"`
-	Lat           float64   `gorm:"not null"`
-	Lon           float64   `gorm:"not null"`
-	Velocity      float64   `gorm:"not null"`
-	TimePosition  time.Time `gorm:"type:timestamp not null"`
-	BaroAltitude  float64   `gorm:"not null"`
-	GeoAltitude   float64   `gorm:"not null"`
-	LastContact   time.Time `gorm:"type:timestamp not null"`
+	Icao24         string `gorm:"not null;uniqueIndex:uq_flight_state_vectors_natural_key"`
+	Callsign       *string
+	OriginCountry  string `gorm:"not null"`
+	Lat            *float64
+	Lon            *float64
+	Velocity       *float64
+	TimePosition   time.Time `gorm:"type:timestamp not null;uniqueIndex:uq_flight_state_vectors_natural_key"`
+	BaroAltitude   *float64
+	GeoAltitude    *float64
+	LastContact    time.Time `gorm:"type:timestamp not null;uniqueIndex:uq_flight_state_vectors_natural_key"`
+	OnGround       bool      `gorm:"not null;default:false"`
+	TrueTrack      *float64
+	VerticalRate   *float64
+	Sensors        []int `gorm:"type:jsonb;serializer:json"`
+	Squawk         *string
+	Spi            bool   `gorm:"not null;default:false"`
+	PositionSource int    `gorm:"not null;default:0"`
+	Category       int    `gorm:"not null;default:0"`
+	Region         string `gorm:"not null;default:''"`
 }
 
 func EventToFlightStateVector(event events.TelemetryRawEvent) FlightStateVector {
-	return FlightStateVector{
-		Icao24:        event.Icao24,
-		OriginCountry: event.OriginCountry,
-		Lat:           event.Lat,
-		Lon:           event.Lon,
-		Velocity:      event.Velocity,
-		TimePosition:  time.Unix(event.TimePosition, 0),
-		BaroAltitude:  event.BaroAltitude,
-		GeoAltitude:   event.GeoAltitude,
-		LastContact:   time.Unix(event.LastContact, 0),
-	}
+	return ToFlightStateVector(flight.EventToFlightState(event))
 }
 
 func ToFlightStateVector(flightState flight.FlightState) FlightStateVector {
 	return FlightStateVector{
+		Icao24:         flightState.Icao24,
+		Callsign:       flightState.Callsign,
+		OriginCountry:  flightState.OriginCountry,
+		Lat:            flightState.Lat,
+		Lon:            flightState.Lon,
+		Velocity:       flightState.Velocity,
+		TimePosition:   derefTime(flightState.TimePosition),
+		BaroAltitude:   flightState.BaroAltitude,
+		GeoAltitude:    flightState.GeoAltitude,
+		LastContact:    flightState.LastContact,
+		OnGround:       flightState.OnGround,
+		TrueTrack:      flightState.TrueTrack,
+		VerticalRate:   flightState.VerticalRate,
+		Sensors:        flightState.Sensors,
+		Squawk:         flightState.Squawk,
+		Spi:            flightState.Spi,
+		PositionSource: flightState.PositionSource,
+		Category:       flightState.Category,
+		Region:         flightState.Region,
+	}
+}
+
+func (v FlightStateVector) ToFlightState() flight.FlightState {
+	timePosition := v.TimePosition
+	return flight.FlightState{
+		Icao24:         v.Icao24,
+		Callsign:       v.Callsign,
+		OriginCountry:  v.OriginCountry,
+		Lat:            v.Lat,
+		Lon:            v.Lon,
+		Velocity:       v.Velocity,
+		TimePosition:   &timePosition,
+		BaroAltitude:   v.BaroAltitude,
+		GeoAltitude:    v.GeoAltitude,
+		LastContact:    v.LastContact,
+		OnGround:       v.OnGround,
+		TrueTrack:      v.TrueTrack,
+		VerticalRate:   v.VerticalRate,
+		Sensors:        v.Sensors,
+		Squawk:         v.Squawk,
+		Spi:            v.Spi,
+		PositionSource: v.PositionSource,
+		Category:       v.Category,
+		Region:         v.Region,
+	}
+}
 
-		Icao24:        flightState.Icao24,
-		OriginCountry: flightState.OriginCountry,
-		Lat:           flightState.Lat,
-		Lon:           flightState.Lon,
-		Velocity:      flightState.Velocity,
-		TimePosition:  flightState.TimePosition,
-		BaroAltitude:  flightState.BaroAltitude,
-		GeoAltitude:   flightState.GeoAltitude,
-		LastContact:   flightState.LastContact,
+func derefTime(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
 	}
+	return *t
 }
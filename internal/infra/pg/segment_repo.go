@@ -0,0 +1,116 @@
+package pg
+
+import (
+	"time"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/flight"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FlightRecord is a persisted flight, keyed by icao24 and start time.
+type FlightRecord struct {
+	ID          int64  `gorm:"primaryKey"`
+	Icao24      string `gorm:"not null;uniqueIndex:uq_flights_icao24_start_time"`
+	Callsign    *string
+	StartTime   time.Time `gorm:"type:timestamp not null;uniqueIndex:uq_flights_icao24_start_time"`
+	EndTime     time.Time `gorm:"type:timestamp not null"`
+	StartLat    float64   `gorm:"not null"`
+	StartLon    float64   `gorm:"not null"`
+	EndLat      float64   `gorm:"not null"`
+	EndLon      float64   `gorm:"not null"`
+	MaxAltitude *float64
+	DistanceM   float64 `gorm:"column:distance_m;not null;default:0"`
+	Points      int     `gorm:"not null;default:0"`
+	EndOnGround bool    `gorm:"not null;default:false"`
+}
+
+func (FlightRecord) TableName() string {
+	return "flights"
+}
+
+func (f FlightRecord) ToFlight() flight.Flight {
+	return flight.Flight{
+		ID:          f.ID,
+		Icao24:      f.Icao24,
+		Callsign:    f.Callsign,
+		StartTime:   f.StartTime,
+		EndTime:     f.EndTime,
+		StartLat:    f.StartLat,
+		StartLon:    f.StartLon,
+		EndLat:      f.EndLat,
+		EndLon:      f.EndLon,
+		MaxAltitude: f.MaxAltitude,
+		DistanceM:   f.DistanceM,
+		Points:      f.Points,
+		EndOnGround: f.EndOnGround,
+	}
+}
+
+func ToFlightRecord(f flight.Flight) FlightRecord {
+	return FlightRecord{
+		ID:          f.ID,
+		Icao24:      f.Icao24,
+		Callsign:    f.Callsign,
+		StartTime:   f.StartTime,
+		EndTime:     f.EndTime,
+		StartLat:    f.StartLat,
+		StartLon:    f.StartLon,
+		EndLat:      f.EndLat,
+		EndLon:      f.EndLon,
+		MaxAltitude: f.MaxAltitude,
+		DistanceM:   f.DistanceM,
+		Points:      f.Points,
+		EndOnGround: f.EndOnGround,
+	}
+}
+
+// PgSegmentRepository stores the flights built by the segmenter.
+type PgSegmentRepository struct {
+	DB *gorm.DB
+}
+
+func (r *PgSegmentRepository) Latest(icao24s []string, since time.Time) ([]flight.Flight, error) {
+	latest := r.DB.Model(&FlightRecord{}).
+		Select("icao24, MAX(start_time) AS start_time").
+		Where("icao24 IN ? AND end_time >= ?", icao24s, since).
+		Group("icao24")
+
+	var rows []FlightRecord
+	err := r.DB.Table("flights AS f").
+		Select("f.*").
+		Joins("JOIN (?) AS latest ON f.icao24 = latest.icao24 AND f.start_time = latest.start_time", latest).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return toFlights(rows), nil
+}
+
+// Save upserts on (icao24, start_time); the id of an existing flight is
+// kept.
+func (r *PgSegmentRepository) Save(flights []flight.Flight) error {
+	if len(flights) == 0 {
+		return nil
+	}
+	rows := make([]FlightRecord, 0, len(flights))
+	for _, f := range flights {
+		row := ToFlightRecord(f)
+		row.ID = 0
+		rows = append(rows, row)
+	}
+	return r.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "icao24"}, {Name: "start_time"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"callsign", "end_time", "end_lat", "end_lon", "max_altitude", "distance_m", "points", "end_on_ground",
+		}),
+	}).Omit("id").Create(&rows).Error
+}
+
+func toFlights(rows []FlightRecord) []flight.Flight {
+	flights := make([]flight.Flight, 0, len(rows))
+	for _, row := range rows {
+		flights = append(flights, row.ToFlight())
+	}
+	return flights
+}
@@ -0,0 +1,46 @@
+package pg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/flight"
+	"github.com/dandyZicky/opensky-collector/internal/domain/processor"
+)
+
+func TestPgSegmentRepository(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	flights := newTestRepository(t)
+	require.NoError(t, flights.DB.AutoMigrate(&FlightRecord{}))
+	repo := &PgSegmentRepository{DB: flights.DB}
+
+	morning := flight.Flight{Icao24: "8a0001", StartTime: base, EndTime: base.Add(time.Hour), Points: 60}
+	evening := flight.Flight{Icao24: "8a0001", StartTime: base.Add(6 * time.Hour), EndTime: base.Add(7 * time.Hour), Points: 10}
+	other := flight.Flight{Icao24: "8a0002", StartTime: base, EndTime: base.Add(time.Hour), Points: 5}
+	require.NoError(t, repo.Save([]flight.Flight{morning, evening, other}))
+
+	evening.EndTime = base.Add(8 * time.Hour)
+	evening.Points = 70
+	evening.MaxAltitude = ptr(11000.0)
+	require.NoError(t, repo.Save([]flight.Flight{evening}))
+
+	latest, err := repo.Latest([]string{"8a0001", "8a0002", "8a0003"}, base.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, latest, 1)
+	assert.Equal(t, 70, latest[0].Points)
+	assert.Equal(t, ptr(11000.0), latest[0].MaxAltitude)
+	assert.NotZero(t, latest[0].ID)
+
+	found, err := flights.Flights("8a0001", base.Add(30*time.Minute), base.Add(24*time.Hour), processor.Page{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.True(t, found[0].StartTime.Equal(base))
+	assert.Equal(t, 70, found[1].Points)
+
+	found, err = flights.Flights("8a0001", base.Add(2*time.Hour), base.Add(3*time.Hour), processor.Page{Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, found)
+}
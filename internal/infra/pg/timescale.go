@@ -0,0 +1,42 @@
+package pg
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+const flightStateVectorsTable = "flight_state_vectors"
+
+// ApplyTimescalePolicies applies the chunk, compression and retention
+// intervals from config to the flight_state_vectors hypertable created by
+// the migrations. It is safe to run on every start; policies are replaced so
+// config changes take effect. An empty CompressAfter or RetentionPeriod
+// disables that policy.
+func ApplyTimescalePolicies(db *gorm.DB, config Config) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT set_chunk_time_interval(?, ?::interval)", flightStateVectorsTable, config.ChunkInterval).Error; err != nil {
+			return fmt.Errorf("setting chunk interval: %w", err)
+		}
+
+		if err := tx.Exec("SELECT remove_compression_policy(?, if_exists => TRUE)", flightStateVectorsTable).Error; err != nil {
+			return fmt.Errorf("removing compression policy: %w", err)
+		}
+		if config.CompressAfter != "" {
+			if err := tx.Exec("SELECT add_compression_policy(?, ?::interval)", flightStateVectorsTable, config.CompressAfter).Error; err != nil {
+				return fmt.Errorf("adding compression policy: %w", err)
+			}
+		}
+
+		if err := tx.Exec("SELECT remove_retention_policy(?, if_exists => TRUE)", flightStateVectorsTable).Error; err != nil {
+			return fmt.Errorf("removing retention policy: %w", err)
+		}
+		if config.RetentionPeriod != "" {
+			if err := tx.Exec("SELECT add_retention_policy(?, ?::interval)", flightStateVectorsTable, config.RetentionPeriod).Error; err != nil {
+				return fmt.Errorf("adding retention policy: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
@@ -3,27 +3,118 @@ package sse
 import (
 	"context"
 	"encoding/json"
+	"expvar"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/dandyZicky/opensky-collector/pkg/events"
 	"github.com/rs/cors"
 )
 
+// Snapshotter provides the current state of every live aircraft.
+type Snapshotter interface {
+	Snapshot() []events.TelemetryRawEvent
+}
+
+// SSE event names. Every state carries its own ID; a snapshot carries the ID
+// of the last state it includes. Geofence events carry no ID and are not
+// replayed.
+const (
+	eventState     = "state"
+	eventSnapshot  = "snapshot"
+	eventHeartbeat = "heartbeat"
+	eventGeofence  = "geofence"
+)
+
+const (
+	// heartbeatEventInterval is how often clients get a heartbeat event they
+	// can watch to notice a stalled stream.
+	heartbeatEventInterval = 30 * time.Second
+	// retryAfter is suggested to clients turned away at the client limit.
+	retryAfter = 10 * time.Second
+)
+
+// batch is what a client channel carries: state entries, a full snapshot or
+// geofence events.
+type batch struct {
+	snapshot bool
+	id       uint64
+	states   []events.TelemetryRawEvent
+	entries  []entry
+	geofence []events.GeofenceEvent
+}
+
+// client is a joining subscription: its channel, what it wants to see and
+// the last event it saw on a previous connection, if any.
+type client struct {
+	ch          chan batch
+	filter      Filter
+	lastEventID uint64
+}
+
+// subscriber is a registered client as tracked by Run. A paused subscriber
+// receives nothing until it is resumed.
+type subscriber struct {
+	filter Filter
+	drops  int
+	paused bool
+}
+
+type controlKind int
+
+const (
+	controlFilter controlKind = iota
+	controlPause
+	controlResume
+)
+
+// control changes a registered client's subscription. It is applied by Run
+// so it is ordered with the batches the client receives.
+type control struct {
+	ch          chan batch
+	kind        controlKind
+	filter      Filter
+	lastEventID uint64
+	// done is closed once Run has applied the request.
+	done chan struct{}
+}
+
 type SSEBroadcaster struct {
-	clients        map[chan []events.TelemetryRawEvent]bool
-	register       chan chan []events.TelemetryRawEvent
-	unregister     chan chan []events.TelemetryRawEvent
-	messages       chan []events.TelemetryRawEvent
-	allowedOrigins []string
-	ctx            context.Context
+	// Live, when set, is sent to clients that join fresh or cannot be
+	// resumed from the replay buffer.
+	Live Snapshotter
+
+	clients    map[chan batch]*subscriber
+	register   chan client
+	unregister chan chan batch
+	controls   chan control
+	messages   chan []events.TelemetryRawEvent
+	geofence   chan []events.GeofenceEvent
+	config     Config
+	ctx        context.Context
+
+	// lastID is the ID of the most recent state. It starts at the current
+	// time in microseconds, so IDs keep increasing across restarts and a
+	// client's Last-Event-ID from before a restart is never mistaken for
+	// a newer one.
+	lastID  uint64
+	history *ring
 }
 
 type SSEServer struct {
 	broadcaster *SSEBroadcaster
 	port        string
+	routes      []route
+	active      atomic.Int64
+}
+
+type route struct {
+	pattern string
+	handler http.Handler
 }
 
 func NewSSEServer(broadcaster *SSEBroadcaster, port string) *SSEServer {
@@ -33,12 +124,23 @@ func NewSSEServer(broadcaster *SSEBroadcaster, port string) *SSEServer {
 	}
 }
 
+// Handle mounts an extra handler next to the SSE endpoint. It must be called
+// before Start.
+func (s *SSEServer) Handle(pattern string, handler http.Handler) {
+	s.routes = append(s.routes, route{pattern: pattern, handler: handler})
+}
+
 func (s *SSEServer) Start() error {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/sse/flights", s.handleSSE)
+	mux.HandleFunc("/ws/flights", s.handleWS)
+	mux.Handle("/debug/vars", expvar.Handler())
+	for _, r := range s.routes {
+		mux.Handle(r.pattern, r.handler)
+	}
 
 	c := cors.New(cors.Options{
-		AllowedOrigins:   s.broadcaster.allowedOrigins,
+		AllowedOrigins:   s.broadcaster.config.AllowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"*"},
 		AllowCredentials: true,
@@ -51,18 +153,58 @@ func (s *SSEServer) Start() error {
 }
 
 func (s *SSEServer) handleSSE(w http.ResponseWriter, r *http.Request) {
-	ch := s.broadcaster.Join()
+	if !s.acquire() {
+		reject(w)
+		return
+	}
+	defer s.release()
+
+	filter, err := ParseFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// An unparseable Last-Event-ID is treated as a fresh connection.
+	lastEventID, _ := strconv.ParseUint(r.Header.Get("Last-Event-ID"), 10, 64)
+	ch := s.broadcaster.Join(filter, lastEventID)
 	s.broadcaster.ServeSSE(w, r, ch)
 }
 
-func NewSSEBroadcaster(ctx context.Context, allowedOrigins []string) *SSEBroadcaster {
+// acquire takes a connection slot, or reports false at MaxClients.
+func (s *SSEServer) acquire() bool {
+	max := int64(s.broadcaster.config.MaxClients)
+	if n := s.active.Add(1); max > 0 && n > max {
+		s.active.Add(-1)
+		return false
+	}
+	metrics.Add("clients", 1)
+	return true
+}
+
+func (s *SSEServer) release() {
+	s.active.Add(-1)
+	metrics.Add("clients", -1)
+}
+
+// reject turns a client away at MaxClients.
+func reject(w http.ResponseWriter) {
+	metrics.Add("rejected", 1)
+	w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+	http.Error(w, "too many clients", http.StatusServiceUnavailable)
+}
+
+func NewSSEBroadcaster(ctx context.Context, config Config) *SSEBroadcaster {
 	return &SSEBroadcaster{
-		clients:        make(map[chan []events.TelemetryRawEvent]bool),
-		register:       make(chan chan []events.TelemetryRawEvent, 10),
-		unregister:     make(chan chan []events.TelemetryRawEvent, 10),
-		messages:       make(chan []events.TelemetryRawEvent, 100),
-		ctx:            ctx,
-		allowedOrigins: allowedOrigins,
+		clients:    make(map[chan batch]*subscriber),
+		register:   make(chan client, 10),
+		unregister: make(chan chan batch, 10),
+		controls:   make(chan control, 10),
+		messages:   make(chan []events.TelemetryRawEvent, 100),
+		geofence:   make(chan []events.GeofenceEvent, 100),
+		config:     config,
+		ctx:        ctx,
+		lastID:     uint64(time.Now().UnixMicro()),
+		history:    newRing(config.ReplayBuffer),
 	}
 }
 
@@ -75,33 +217,162 @@ func (b *SSEBroadcaster) Run() {
 				close(ch)
 			}
 			return
-		case ch := <-b.register:
-			b.clients[ch] = true
+		case c := <-b.register:
+			sub := &subscriber{filter: c.filter}
+			b.clients[c.ch] = sub
+			b.catchUp(c.ch, sub, c.lastEventID)
 			log.Println("Registered new session")
 		case ch := <-b.unregister:
-			log.Println("A session left")
-			delete(b.clients, ch)
-			close(ch)
+			// An evicted client has already been removed and closed.
+			if _, ok := b.clients[ch]; ok {
+				log.Println("A session left")
+				delete(b.clients, ch)
+				close(ch)
+			}
+		case c := <-b.controls:
+			b.apply(c)
+			close(c.done)
 		case msgs := <-b.messages:
-			for client := range b.clients {
-				select {
-				case client <- msgs:
-				default:
-					log.Println("Dropped")
+			entries := make([]entry, len(msgs))
+			for i, ev := range msgs {
+				b.lastID++
+				entries[i] = entry{id: b.lastID, event: ev}
+				b.history.push(entries[i])
+			}
+			for ch, sub := range b.clients {
+				if sub.paused {
+					continue
+				}
+				if matched := filterEntries(sub.filter, entries); len(matched) > 0 {
+					b.send(ch, sub, batch{entries: matched})
+				}
+			}
+		case alerts := <-b.geofence:
+			for ch, sub := range b.clients {
+				if sub.paused {
+					continue
+				}
+				if matched := filterGeofence(sub.filter, alerts); len(matched) > 0 {
+					b.send(ch, sub, batch{geofence: matched})
 				}
 			}
 		}
 	}
 }
 
-func (b *SSEBroadcaster) Join() chan []events.TelemetryRawEvent {
-	messageChannel := make(chan []events.TelemetryRawEvent)
-	b.register <- messageChannel
+// send queues a batch for a client without blocking Run.
+func (b *SSEBroadcaster) send(ch chan batch, sub *subscriber, next batch) {
+	select {
+	case ch <- next:
+		sub.drops = 0
+	default:
+		b.drop(ch, sub)
+	}
+}
+
+// apply carries out a control request of a registered client. A new filter
+// comes with a snapshot for it, so the client can redraw right away. Resume
+// catches up from the last event the client saw.
+func (b *SSEBroadcaster) apply(c control) {
+	sub, ok := b.clients[c.ch]
+	if !ok {
+		return
+	}
+	switch c.kind {
+	case controlFilter:
+		sub.filter = c.filter
+		if b.Live != nil && !sub.paused {
+			b.send(c.ch, sub, batch{snapshot: true, id: b.lastID, states: sub.filter.Apply(b.Live.Snapshot())})
+		}
+	case controlPause:
+		sub.paused = true
+	case controlResume:
+		if sub.paused {
+			sub.paused = false
+			b.catchUp(c.ch, sub, c.lastEventID)
+		}
+	}
+}
+
+// drop counts a batch a slow client missed and evicts the client once it
+// has missed MaxDrops in a row. Closing its channel ends its response; the
+// browser reconnects and resumes from its Last-Event-ID.
+func (b *SSEBroadcaster) drop(ch chan batch, sub *subscriber) {
+	sub.drops++
+	metrics.Add("dropped", 1)
+	if sub.drops < b.config.MaxDrops {
+		return
+	}
+	log.Printf("Evicting slow session after %d dropped batches", sub.drops)
+	metrics.Add("evicted", 1)
+	delete(b.clients, ch)
+	close(ch)
+}
+
+// catchUp sends a client the states it missed since lastEventID, or a
+// snapshot when it is new or the replay buffer no longer reaches back that
+// far. Running inside Run orders this before any later batch.
+func (b *SSEBroadcaster) catchUp(ch chan batch, sub *subscriber, lastEventID uint64) {
+	if lastEventID != 0 && lastEventID <= b.lastID {
+		if lastEventID == b.lastID {
+			return
+		}
+		if missed, ok := b.history.since(lastEventID); ok {
+			if matched := filterEntries(sub.filter, missed); len(matched) > 0 {
+				b.send(ch, sub, batch{entries: matched})
+			}
+			return
+		}
+	}
+	if b.Live != nil {
+		b.send(ch, sub, batch{snapshot: true, id: b.lastID, states: sub.filter.Apply(b.Live.Snapshot())})
+	}
+}
+
+// Join subscribes a client that only receives events matching filter.
+// lastEventID is the Last-Event-ID of a reconnecting client, or 0.
+func (b *SSEBroadcaster) Join(filter Filter, lastEventID uint64) chan batch {
+	messageChannel := make(chan batch, max(b.config.ClientBuffer, 1))
+	b.register <- client{ch: messageChannel, filter: filter, lastEventID: lastEventID}
 	return messageChannel
 }
 
-func (b *SSEBroadcaster) Leave(client chan []events.TelemetryRawEvent) {
-	b.unregister <- client
+// UpdateFilter replaces the filter of a joined client.
+func (b *SSEBroadcaster) UpdateFilter(client chan batch, filter Filter) {
+	b.control(control{ch: client, kind: controlFilter, filter: filter})
+}
+
+// Pause stops delivery to a joined client without leaving.
+func (b *SSEBroadcaster) Pause(client chan batch) {
+	b.control(control{ch: client, kind: controlPause})
+}
+
+// Resume restarts delivery, first sending what the client missed after
+// lastEventID, or a snapshot.
+func (b *SSEBroadcaster) Resume(client chan batch, lastEventID uint64) {
+	b.control(control{ch: client, kind: controlResume, lastEventID: lastEventID})
+}
+
+// control returns once Run has applied c, so e.g. no batch is queued for a
+// client after Pause returns.
+func (b *SSEBroadcaster) control(c control) {
+	c.done = make(chan struct{})
+	select {
+	case b.controls <- c:
+	case <-b.ctx.Done():
+		return
+	}
+	select {
+	case <-c.done:
+	case <-b.ctx.Done():
+	}
+}
+
+func (b *SSEBroadcaster) Leave(client chan batch) {
+	select {
+	case b.unregister <- client:
+	case <-b.ctx.Done():
+	}
 }
 
 func (b *SSEBroadcaster) Broadcast(event []events.TelemetryRawEvent) error {
@@ -109,7 +380,14 @@ func (b *SSEBroadcaster) Broadcast(event []events.TelemetryRawEvent) error {
 	return nil
 }
 
-func (b *SSEBroadcaster) ServeSSE(w http.ResponseWriter, r *http.Request, ch chan []events.TelemetryRawEvent) {
+// BroadcastGeofenceEvents sends geofence entries and exits to every client
+// whose filter matches them.
+func (b *SSEBroadcaster) BroadcastGeofenceEvents(geofenceEvents []events.GeofenceEvent) error {
+	b.geofence <- geofenceEvents
+	return nil
+}
+
+func (b *SSEBroadcaster) ServeSSE(w http.ResponseWriter, r *http.Request, ch chan batch) {
 	defer func() {
 		if r := recover(); r != nil {
 			log.Printf("Client goroutine panic: %v", r)
@@ -136,15 +414,100 @@ func (b *SSEBroadcaster) ServeSSE(w http.ResponseWriter, r *http.Request, ch cha
 		b.Leave(ch)
 	}()
 
-	for evs := range ch {
-		for _, event := range evs {
-			msg, err := events.SerializeTelemetryRawEvent(event)
-			if err != nil {
-				log.Printf("Failed to serialize event: %v", err)
-			}
+	heartbeat := time.NewTicker(b.config.heartbeat())
+	defer heartbeat.Stop()
+	heartbeatEvent := time.NewTicker(heartbeatEventInterval)
+	defer heartbeatEvent.Stop()
 
-			fmt.Fprintf(w, "data: %s\n\n", string(msg))
+	for {
+		select {
+		case next, ok := <-ch:
+			if !ok {
+				return
+			}
+			if next.snapshot {
+				writeSnapshot(w, next.id, next.states)
+				continue
+			}
+			for _, e := range next.entries {
+				msg, err := events.SerializeTelemetryRawEvent(e.event)
+				if err != nil {
+					log.Printf("Failed to serialize event: %v", err)
+					continue
+				}
+				writeEvent(w, e.id, eventState, msg)
+			}
+			for _, e := range next.geofence {
+				msg, err := events.SerializeGeofenceEvent(e)
+				if err != nil {
+					log.Printf("Failed to serialize geofence event: %v", err)
+					continue
+				}
+				writeEvent(w, 0, eventGeofence, msg)
+			}
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
 			w.(http.Flusher).Flush()
+		case <-heartbeatEvent.C:
+			msg, _ := json.Marshal(map[string]interface{}{
+				"type":      eventHeartbeat,
+				"timestamp": time.Now().Unix(),
+			})
+			writeEvent(w, 0, eventHeartbeat, msg)
+		}
+	}
+}
+
+// writeSnapshot sends the live states as one message, so a client can
+// replace its view at once instead of replaying every aircraft.
+func writeSnapshot(w http.ResponseWriter, id uint64, states []events.TelemetryRawEvent) {
+	if states == nil {
+		states = []events.TelemetryRawEvent{}
+	}
+	msg, err := json.Marshal(map[string]interface{}{
+		"type":      eventSnapshot,
+		"timestamp": time.Now().Unix(),
+		"states":    states,
+	})
+	if err != nil {
+		log.Printf("Failed to serialize snapshot: %v", err)
+		return
+	}
+	writeEvent(w, id, eventSnapshot, msg)
+}
+
+// writeEvent writes one SSE event. An id of 0 is omitted so the client's
+// Last-Event-ID stays at the last state it saw.
+func writeEvent(w http.ResponseWriter, id uint64, name string, data []byte) {
+	if id != 0 {
+		fmt.Fprintf(w, "id: %d\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data)
+	w.(http.Flusher).Flush()
+}
+
+func filterEntries(f Filter, entries []entry) []entry {
+	if f.empty() {
+		return entries
+	}
+	var matched []entry
+	for _, e := range entries {
+		if f.Match(e.event) {
+			matched = append(matched, e)
+		}
+	}
+	return matched
+}
+
+func filterGeofence(f Filter, geofenceEvents []events.GeofenceEvent) []events.GeofenceEvent {
+	if f.empty() {
+		return geofenceEvents
+	}
+	var matched []events.GeofenceEvent
+	for _, e := range geofenceEvents {
+		if f.MatchGeofence(e) {
+			matched = append(matched, e)
 		}
 	}
+	return matched
 }
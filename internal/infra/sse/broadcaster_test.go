@@ -0,0 +1,183 @@
+package sse
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+type staticSnapshot []events.TelemetryRawEvent
+
+func (s staticSnapshot) Snapshot() []events.TelemetryRawEvent {
+	return s
+}
+
+func newRunningBroadcaster(t *testing.T, replayBuffer int) *SSEBroadcaster {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	b := NewSSEBroadcaster(ctx, Config{ReplayBuffer: replayBuffer, ClientBuffer: 1, MaxDrops: 2})
+	b.Live = staticSnapshot{{Icao24: "8a0001"}, {Icao24: "8a0002"}}
+	go b.Run()
+	return b
+}
+
+func receive(t *testing.T, ch chan batch) batch {
+	t.Helper()
+	select {
+	case next := <-ch:
+		return next
+	case <-time.After(time.Second):
+		t.Fatal("no batch received")
+		return batch{}
+	}
+}
+
+func TestSSEBroadcaster_NewClientGetsSnapshot(t *testing.T) {
+	b := newRunningBroadcaster(t, 10)
+
+	ch := b.Join(Filter{}, 0)
+	first := receive(t, ch)
+	assert.True(t, first.snapshot)
+	assert.Len(t, first.states, 2)
+
+	require.NoError(t, b.Broadcast([]events.TelemetryRawEvent{{Icao24: "8a0003"}}))
+	next := receive(t, ch)
+	require.Len(t, next.entries, 1)
+	assert.Equal(t, first.id+1, next.entries[0].id)
+}
+
+func TestSSEBroadcaster_ReplaysAfterLastEventID(t *testing.T) {
+	b := newRunningBroadcaster(t, 10)
+	live := b.Join(Filter{}, 0)
+	first := receive(t, live)
+
+	require.NoError(t, b.Broadcast([]events.TelemetryRawEvent{{Icao24: "8a0003"}, {Icao24: "8a0004"}, {Icao24: "8a0005"}}))
+	// Run has handled the broadcast once the live client receives it.
+	receive(t, live)
+
+	ch := b.Join(Filter{}, first.id+1)
+	replay := receive(t, ch)
+	assert.False(t, replay.snapshot)
+	require.Len(t, replay.entries, 2)
+	assert.Equal(t, "8a0004", replay.entries[0].event.Icao24)
+	assert.Equal(t, "8a0005", replay.entries[1].event.Icao24)
+}
+
+func TestSSEBroadcaster_FallsBackToSnapshotOnGap(t *testing.T) {
+	b := newRunningBroadcaster(t, 2)
+	live := b.Join(Filter{}, 0)
+	first := receive(t, live)
+
+	require.NoError(t, b.Broadcast([]events.TelemetryRawEvent{{Icao24: "8a0003"}, {Icao24: "8a0004"}, {Icao24: "8a0005"}}))
+	// Run has handled the broadcast once the live client receives it.
+	receive(t, live)
+
+	// The buffer only reaches back to the second of the three states.
+	next := receive(t, b.Join(Filter{}, first.id))
+	assert.True(t, next.snapshot)
+	assert.Equal(t, first.id+3, next.id)
+}
+
+func TestSSEBroadcaster_GeofenceEvents(t *testing.T) {
+	b := newRunningBroadcaster(t, 10)
+	all := b.Join(Filter{}, 0)
+	one := b.Join(Filter{Icao24: map[string]bool{"8a0002": true}}, 0)
+	first := receive(t, all)
+	receive(t, one)
+
+	enter := events.GeofenceEvent{Type: events.GeofenceEnter, Icao24: "8a0001", FenceID: 1}
+	require.NoError(t, b.BroadcastGeofenceEvents([]events.GeofenceEvent{enter}))
+	next := receive(t, all)
+	assert.Equal(t, []events.GeofenceEvent{enter}, next.geofence)
+
+	// Geofence events take no IDs, so the next state follows the snapshot.
+	require.NoError(t, b.Broadcast([]events.TelemetryRawEvent{{Icao24: "8a0002"}}))
+	assert.Equal(t, first.id+1, receive(t, all).entries[0].id)
+	assert.Empty(t, receive(t, one).geofence)
+}
+
+func TestSSEBroadcaster_EvictsSlowClient(t *testing.T) {
+	b := newRunningBroadcaster(t, 10)
+	slow := b.Join(Filter{}, 0)
+	receive(t, slow)
+	fast := b.Join(Filter{}, 0)
+	receive(t, fast)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, b.Broadcast([]events.TelemetryRawEvent{{Icao24: "8a0003"}}))
+		receive(t, fast)
+	}
+
+	// The first batch was queued, the next two dropped, which evicts.
+	receive(t, slow)
+	_, open := <-slow
+	assert.False(t, open)
+}
+
+func TestSSEBroadcaster_KeepsClientThatCatchesUp(t *testing.T) {
+	b := newRunningBroadcaster(t, 10)
+	slow := b.Join(Filter{}, 0)
+	receive(t, slow)
+	fast := b.Join(Filter{}, 0)
+	receive(t, fast)
+
+	broadcast := func(icao24 string) {
+		require.NoError(t, b.Broadcast([]events.TelemetryRawEvent{{Icao24: icao24}}))
+		receive(t, fast)
+	}
+
+	// Each round queues one batch and drops the next; reading in between
+	// means the drops are never consecutive.
+	for _, round := range [][2]string{{"8a0003", "8a0004"}, {"8a0005", "8a0006"}} {
+		broadcast(round[0])
+		broadcast(round[1])
+		assert.Equal(t, round[0], receive(t, slow).entries[0].event.Icao24)
+	}
+	broadcast("8a0007")
+	assert.Equal(t, "8a0007", receive(t, slow).entries[0].event.Icao24)
+}
+
+func TestSSEServer_RejectsAboveMaxClients(t *testing.T) {
+	b := NewSSEBroadcaster(context.Background(), Config{MaxClients: 1})
+	s := NewSSEServer(b, "0")
+	require.True(t, s.acquire())
+
+	rec := httptest.NewRecorder()
+	s.handleSSE(rec, httptest.NewRequest(http.MethodGet, "/sse/flights", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "10", rec.Header().Get("Retry-After"))
+}
+
+func TestSSEBroadcaster_ServeSSESendsCommentHeartbeats(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b := NewSSEBroadcaster(ctx, Config{ClientBuffer: 1, HeartbeatMs: 10})
+	go b.Run()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b.ServeSSE(w, r, b.Join(Filter{}, 0))
+	}))
+	defer srv.Close()
+
+	// The timeout also ends a stream that never sends the comment.
+	client := &http.Client{Timeout: time.Second}
+	res, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	lines := bufio.NewScanner(res.Body)
+	for lines.Scan() {
+		if lines.Text() == ": heartbeat" {
+			return
+		}
+	}
+	t.Fatalf("no comment heartbeat on an idle stream: %v", lines.Err())
+}
@@ -0,0 +1,32 @@
+package sse
+
+import "time"
+
+// Config tunes the broadcaster and the server in front of it.
+type Config struct {
+	AllowedOrigins []string
+	// ReplayBuffer is the number of recent states kept for clients that
+	// reconnect with a Last-Event-ID.
+	ReplayBuffer int
+	// ClientBuffer is the number of batches queued per client. A client
+	// whose queue is full misses the batch; after MaxDrops missed batches
+	// in a row it is disconnected and has to resume with its Last-Event-ID.
+	ClientBuffer int
+	MaxDrops     int
+	// MaxClients caps concurrent SSE connections; further requests get a
+	// 503 with Retry-After. 0 means no limit.
+	MaxClients int
+	// HeartbeatMs is the interval of SSE comment lines and WebSocket pings,
+	// which keep idle connections open through proxies without reaching
+	// client event handlers.
+	HeartbeatMs int
+}
+
+const defaultHeartbeat = 15 * time.Second
+
+func (c Config) heartbeat() time.Duration {
+	if c.HeartbeatMs <= 0 {
+		return defaultHeartbeat
+	}
+	return time.Duration(c.HeartbeatMs) * time.Millisecond
+}
@@ -0,0 +1,184 @@
+package sse
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/dandyZicky/opensky-collector/internal/domain/flight"
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+// Filter selects the events a client receives. Zero fields match
+// everything; set fields must all match.
+type Filter struct {
+	Box            *flight.BoundingBox
+	Icao24         map[string]bool
+	OriginCountry  string
+	MinAltitude    *float64
+	MaxAltitude    *float64
+	OnGround       *bool
+	CallsignPrefix string
+}
+
+// ParseFilter reads a filter from the query of a subscription request:
+//
+//	bbox=lamin,lomin,lamax,lomax
+//	icao24=8a0001,8a0002
+//	origin_country=Indonesia
+//	min_altitude=1000&max_altitude=12000 (meters, barometric, else geometric)
+//	on_ground=false
+//	callsign=GIA
+func ParseFilter(q url.Values) (Filter, error) {
+	var f Filter
+	var err error
+
+	if f.Box, err = flight.ParseBoundingBox(q.Get("bbox")); err != nil {
+		return f, err
+	}
+	if v := q.Get("icao24"); v != "" {
+		f.Icao24 = make(map[string]bool)
+		for _, id := range strings.Split(v, ",") {
+			if id = strings.ToLower(strings.TrimSpace(id)); id != "" {
+				f.Icao24[id] = true
+			}
+		}
+	}
+	f.OriginCountry = strings.TrimSpace(q.Get("origin_country"))
+	f.CallsignPrefix = strings.ToUpper(strings.TrimSpace(q.Get("callsign")))
+
+	if f.MinAltitude, err = parseFloatParam(q, "min_altitude"); err != nil {
+		return f, err
+	}
+	if f.MaxAltitude, err = parseFloatParam(q, "max_altitude"); err != nil {
+		return f, err
+	}
+	if f.MinAltitude != nil && f.MaxAltitude != nil && *f.MinAltitude > *f.MaxAltitude {
+		return f, fmt.Errorf("min_altitude must not exceed max_altitude")
+	}
+	if v := q.Get("on_ground"); v != "" {
+		onGround, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, fmt.Errorf("on_ground must be true or false")
+		}
+		f.OnGround = &onGround
+	}
+	return f, nil
+}
+
+func parseFloatParam(q url.Values, name string) (*float64, error) {
+	v := q.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %q is not a number", name, v)
+	}
+	return &n, nil
+}
+
+// Match reports whether the event passes every set field. An event missing
+// a value the filter needs, such as a position for a box, does not match.
+func (f Filter) Match(ev events.TelemetryRawEvent) bool {
+	if f.Box != nil && (ev.Lat == nil || ev.Lon == nil || !f.Box.Contains(*ev.Lat, *ev.Lon)) {
+		return false
+	}
+	if f.Icao24 != nil && !f.Icao24[strings.ToLower(ev.Icao24)] {
+		return false
+	}
+	if f.OriginCountry != "" && !strings.EqualFold(ev.OriginCountry, f.OriginCountry) {
+		return false
+	}
+	if f.OnGround != nil && ev.OnGround != *f.OnGround {
+		return false
+	}
+	if f.CallsignPrefix != "" && (ev.Callsign == nil || !strings.HasPrefix(strings.ToUpper(*ev.Callsign), f.CallsignPrefix)) {
+		return false
+	}
+	if f.MinAltitude != nil || f.MaxAltitude != nil {
+		alt := flight.Altitude(ev.BaroAltitude, ev.GeoAltitude)
+		if alt == nil ||
+			(f.MinAltitude != nil && *alt < *f.MinAltitude) ||
+			(f.MaxAltitude != nil && *alt > *f.MaxAltitude) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchGeofence reports whether a geofence event passes the box, icao24 and
+// callsign fields. Fields describing a state, such as altitude, do not apply
+// to geofence events and are ignored.
+func (f Filter) MatchGeofence(e events.GeofenceEvent) bool {
+	if f.Box != nil && !f.Box.Contains(e.Lat, e.Lon) {
+		return false
+	}
+	if f.Icao24 != nil && !f.Icao24[strings.ToLower(e.Icao24)] {
+		return false
+	}
+	if f.CallsignPrefix != "" && (e.Callsign == nil || !strings.HasPrefix(strings.ToUpper(*e.Callsign), f.CallsignPrefix)) {
+		return false
+	}
+	return true
+}
+
+// Apply returns the matching events. It returns evs itself when the filter
+// is empty, so unfiltered clients share one slice.
+func (f Filter) Apply(evs []events.TelemetryRawEvent) []events.TelemetryRawEvent {
+	if f.empty() {
+		return evs
+	}
+	var matched []events.TelemetryRawEvent
+	for _, ev := range evs {
+		if f.Match(ev) {
+			matched = append(matched, ev)
+		}
+	}
+	return matched
+}
+
+func (f Filter) empty() bool {
+	return f.Box == nil && f.Icao24 == nil && f.OriginCountry == "" &&
+		f.MinAltitude == nil && f.MaxAltitude == nil && f.OnGround == nil && f.CallsignPrefix == ""
+}
+
+// FilterSpec is the JSON form of a filter used by the WebSocket protocol.
+// It takes the same fields as the query parameters of /sse/flights.
+type FilterSpec struct {
+	BBox          []float64 `json:"bbox,omitempty"`
+	Icao24        []string  `json:"icao24,omitempty"`
+	OriginCountry string    `json:"origin_country,omitempty"`
+	MinAltitude   *float64  `json:"min_altitude,omitempty"`
+	MaxAltitude   *float64  `json:"max_altitude,omitempty"`
+	OnGround      *bool     `json:"on_ground,omitempty"`
+	Callsign      string    `json:"callsign,omitempty"`
+}
+
+// Filter validates the spec the same way ParseFilter validates a query.
+func (s FilterSpec) Filter() (Filter, error) {
+	q := url.Values{}
+	if s.BBox != nil {
+		coords := make([]string, len(s.BBox))
+		for i, c := range s.BBox {
+			coords[i] = strconv.FormatFloat(c, 'f', -1, 64)
+		}
+		q.Set("bbox", strings.Join(coords, ","))
+	}
+	if len(s.Icao24) > 0 {
+		q.Set("icao24", strings.Join(s.Icao24, ","))
+	}
+	q.Set("origin_country", s.OriginCountry)
+	q.Set("callsign", s.Callsign)
+	if s.MinAltitude != nil {
+		q.Set("min_altitude", strconv.FormatFloat(*s.MinAltitude, 'f', -1, 64))
+	}
+	if s.MaxAltitude != nil {
+		q.Set("max_altitude", strconv.FormatFloat(*s.MaxAltitude, 'f', -1, 64))
+	}
+	if s.OnGround != nil {
+		q.Set("on_ground", strconv.FormatBool(*s.OnGround))
+	}
+	return ParseFilter(q)
+}
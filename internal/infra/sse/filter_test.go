@@ -0,0 +1,89 @@
+package sse
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func mustParseFilter(t *testing.T, query string) Filter {
+	t.Helper()
+	q, err := url.ParseQuery(query)
+	require.NoError(t, err)
+	f, err := ParseFilter(q)
+	require.NoError(t, err)
+	return f
+}
+
+func TestFilter_Match(t *testing.T) {
+	garuda := events.TelemetryRawEvent{
+		Icao24:        "8A0001",
+		Callsign:      ptr("GIA402"),
+		OriginCountry: "Indonesia",
+		Lat:           ptr(-6.12),
+		Lon:           ptr(106.65),
+		BaroAltitude:  ptr(10000.0),
+	}
+	grounded := events.TelemetryRawEvent{
+		Icao24:        "8a0002",
+		OriginCountry: "Indonesia",
+		OnGround:      true,
+	}
+
+	tests := []struct {
+		query    string
+		garuda   bool
+		grounded bool
+	}{
+		{"", true, true},
+		{"bbox=-7,106,-6,107", true, false},
+		{"icao24=8a0001,8a0003", true, false},
+		{"origin_country=indonesia", true, true},
+		{"origin_country=Malaysia", false, false},
+		{"min_altitude=5000&max_altitude=12000", true, false},
+		{"max_altitude=5000", false, false},
+		{"on_ground=true", false, true},
+		{"callsign=gia", true, false},
+		{"callsign=GIA&on_ground=true", false, false},
+	}
+	for _, tt := range tests {
+		f := mustParseFilter(t, tt.query)
+		assert.Equal(t, tt.garuda, f.Match(garuda), tt.query)
+		assert.Equal(t, tt.grounded, f.Match(grounded), tt.query)
+	}
+}
+
+func TestFilter_Apply(t *testing.T) {
+	evs := []events.TelemetryRawEvent{
+		{Icao24: "8a0001", OnGround: true},
+		{Icao24: "8a0002"},
+	}
+
+	assert.Equal(t, evs, Filter{}.Apply(evs))
+
+	matched := mustParseFilter(t, "on_ground=false").Apply(evs)
+	require.Len(t, matched, 1)
+	assert.Equal(t, "8a0002", matched[0].Icao24)
+}
+
+func TestParseFilter_Invalid(t *testing.T) {
+	for _, query := range []string{
+		"bbox=1,2,3",
+		"min_altitude=high",
+		"min_altitude=2000&max_altitude=1000",
+		"on_ground=maybe",
+	} {
+		q, err := url.ParseQuery(query)
+		require.NoError(t, err)
+		_, err = ParseFilter(q)
+		assert.Error(t, err, query)
+	}
+}
@@ -0,0 +1,8 @@
+package sse
+
+import "expvar"
+
+// Published through expvar under "sse": connected clients, batches dropped
+// for slow clients, clients evicted for dropping too many and connections
+// rejected at the client limit.
+var metrics = expvar.NewMap("sse")
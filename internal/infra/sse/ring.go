@@ -0,0 +1,55 @@
+package sse
+
+import "github.com/dandyZicky/opensky-collector/pkg/events"
+
+// entry is a state event with the ID it was streamed under.
+type entry struct {
+	id    uint64
+	event events.TelemetryRawEvent
+}
+
+// ring keeps the most recent entries, oldest first, so reconnecting clients
+// can be sent what they missed. IDs are pushed in increasing order.
+type ring struct {
+	entries []entry
+	start   int
+	size    int
+}
+
+func newRing(capacity int) *ring {
+	return &ring{entries: make([]entry, capacity)}
+}
+
+func (r *ring) push(e entry) {
+	if len(r.entries) == 0 {
+		return
+	}
+	if r.size < len(r.entries) {
+		r.entries[(r.start+r.size)%len(r.entries)] = e
+		r.size++
+		return
+	}
+	r.entries[r.start] = e
+	r.start = (r.start + 1) % len(r.entries)
+}
+
+// since returns the entries after id. ok is false when the entry right
+// after id has already been overwritten, i.e. there is a gap.
+func (r *ring) since(id uint64) (missed []entry, ok bool) {
+	if r.size == 0 {
+		return nil, false
+	}
+	if r.at(0).id > id+1 {
+		return nil, false
+	}
+	for i := 0; i < r.size; i++ {
+		if e := r.at(i); e.id > id {
+			missed = append(missed, e)
+		}
+	}
+	return missed, true
+}
+
+func (r *ring) at(i int) entry {
+	return r.entries[(r.start+i)%len(r.entries)]
+}
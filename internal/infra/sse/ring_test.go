@@ -0,0 +1,37 @@
+package sse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ids(entries []entry) []uint64 {
+	var out []uint64
+	for _, e := range entries {
+		out = append(out, e.id)
+	}
+	return out
+}
+
+func TestRing_Since(t *testing.T) {
+	r := newRing(3)
+	for id := uint64(1); id <= 5; id++ {
+		r.push(entry{id: id})
+	}
+
+	missed, ok := r.since(3)
+	assert.True(t, ok)
+	assert.Equal(t, []uint64{4, 5}, ids(missed))
+
+	missed, ok = r.since(2)
+	assert.True(t, ok)
+	assert.Equal(t, []uint64{3, 4, 5}, ids(missed))
+
+	_, ok = r.since(1)
+	assert.False(t, ok, "entry 2 was overwritten")
+
+	missed, ok = r.since(5)
+	assert.True(t, ok)
+	assert.Empty(t, missed)
+}
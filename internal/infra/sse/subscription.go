@@ -0,0 +1,58 @@
+package sse
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+// ErrStreamClosed is returned by Subscribe when the broadcaster ends the
+// subscription, because it shut down or the subscriber fell too far behind.
+var ErrStreamClosed = errors.New("stream closed by broadcaster")
+
+// Update is one delivery to a Subscribe callback: a state with its ID, a
+// geofence event when Geofence is set, or, when Snapshot is set, the live
+// states up to ID. Geofence events have no ID.
+type Update struct {
+	ID       uint64
+	Snapshot bool
+	State    events.TelemetryRawEvent
+	States   []events.TelemetryRawEvent
+	Geofence *events.GeofenceEvent
+}
+
+// Subscribe is Join for transports outside this package. It calls send for
+// every update until ctx is done, send fails or the stream is closed, and
+// leaves the broadcaster before returning.
+func (b *SSEBroadcaster) Subscribe(ctx context.Context, filter Filter, lastEventID uint64, send func(Update) error) error {
+	ch := b.Join(filter, lastEventID)
+	defer b.Leave(ch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case next, ok := <-ch:
+			if !ok {
+				return ErrStreamClosed
+			}
+			if next.snapshot {
+				if err := send(Update{ID: next.id, Snapshot: true, States: next.states}); err != nil {
+					return err
+				}
+				continue
+			}
+			for _, e := range next.entries {
+				if err := send(Update{ID: e.id, State: e.event}); err != nil {
+					return err
+				}
+			}
+			for i := range next.geofence {
+				if err := send(Update{Geofence: &next.geofence[i]}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
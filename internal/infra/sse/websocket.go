@@ -0,0 +1,245 @@
+package sse
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket control messages sent by clients.
+const (
+	wsSubscribe    = "subscribe"
+	wsUpdateFilter = "update_filter"
+	wsPause        = "pause"
+	wsResume       = "resume"
+	wsPing         = "ping"
+)
+
+const (
+	wsWriteTimeout = 10 * time.Second
+	wsMaxMessage   = 64 << 10
+)
+
+// wsRequest is a control message from the client. Filter is used by
+// subscribe and update_filter, LastEventID by subscribe to resume a previous
+// connection.
+type wsRequest struct {
+	Type        string     `json:"type"`
+	Filter      FilterSpec `json:"filter"`
+	LastEventID uint64     `json:"last_event_id,omitempty"`
+
+	// err is set by the reader for a message that is not valid JSON.
+	err error
+}
+
+// wsMessage is everything the server sends: states, snapshots, geofence
+// events, heartbeats, acknowledgements of control messages, pongs and errors.
+type wsMessage struct {
+	Type      string                     `json:"type"`
+	ID        uint64                     `json:"id,omitempty"`
+	State     *events.TelemetryRawEvent  `json:"state,omitempty"`
+	States    []events.TelemetryRawEvent `json:"states,omitempty"`
+	Geofence  *events.GeofenceEvent      `json:"geofence,omitempty"`
+	Of        string                     `json:"of,omitempty"`
+	Error     string                     `json:"error,omitempty"`
+	Timestamp int64                      `json:"timestamp"`
+}
+
+func (s *SSEServer) upgrader() *websocket.Upgrader {
+	return &websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			return originAllowed(s.broadcaster.config.AllowedOrigins, r.Header.Get("Origin"))
+		},
+	}
+}
+
+// originAllowed applies allowed_origins to browsers. Requests without an
+// Origin header come from non-browser clients, which CORS does not cover
+// either.
+func originAllowed(allowed []string, origin string) bool {
+	return origin == "" || slices.Contains(allowed, "*") || slices.Contains(allowed, origin)
+}
+
+func (s *SSEServer) handleWS(w http.ResponseWriter, r *http.Request) {
+	if !s.acquire() {
+		reject(w)
+		return
+	}
+	defer s.release()
+
+	conn, err := s.upgrader().Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already answered the request.
+		log.Printf("WebSocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	session := &wsSession{conn: conn, broadcaster: s.broadcaster}
+	session.serve()
+}
+
+// wsSession is one WebSocket client. A reader goroutine decodes control
+// messages; serve is the only writer, as gorilla/websocket requires.
+type wsSession struct {
+	conn        *websocket.Conn
+	broadcaster *SSEBroadcaster
+	ch          chan batch
+	lastID      uint64
+}
+
+func (ws *wsSession) serve() {
+	requests := make(chan wsRequest)
+	done := make(chan struct{})
+	quit := make(chan struct{})
+	defer close(quit)
+	go ws.read(requests, done, quit)
+
+	defer func() {
+		if ws.ch != nil {
+			ws.broadcaster.Leave(ws.ch)
+		}
+	}()
+
+	heartbeat := time.NewTicker(ws.broadcaster.config.heartbeat())
+	defer heartbeat.Stop()
+	heartbeatEvent := time.NewTicker(heartbeatEventInterval)
+	defer heartbeatEvent.Stop()
+
+	ws.write(wsMessage{Type: "connection_ack"})
+
+	for {
+		// A nil channel blocks, so nothing is delivered before subscribe.
+		select {
+		case <-done:
+			return
+		case req := <-requests:
+			ws.handle(req)
+		case next, ok := <-ws.ch:
+			if !ok {
+				ws.closeWith(websocket.CloseTryAgainLater, "stream closed")
+				return
+			}
+			ws.deliver(next)
+		case <-heartbeat.C:
+			deadline := time.Now().Add(wsWriteTimeout)
+			if err := ws.conn.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+				return
+			}
+		case <-heartbeatEvent.C:
+			ws.write(wsMessage{Type: eventHeartbeat})
+		}
+	}
+}
+
+// read forwards control messages until the connection fails, then closes
+// done. quit tells it serve has stopped listening.
+func (ws *wsSession) read(requests chan<- wsRequest, done chan<- struct{}, quit <-chan struct{}) {
+	defer close(done)
+
+	ws.conn.SetReadLimit(wsMaxMessage)
+	for {
+		_, data, err := ws.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var req wsRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			req = wsRequest{err: err}
+		}
+		select {
+		case requests <- req:
+		case <-quit:
+			return
+		}
+	}
+}
+
+func (ws *wsSession) handle(req wsRequest) {
+	if req.err != nil {
+		ws.writeError("", "invalid message: "+req.err.Error())
+		return
+	}
+	switch req.Type {
+	case wsPing:
+		ws.write(wsMessage{Type: "pong"})
+		return
+	case wsSubscribe, wsUpdateFilter:
+		filter, err := req.Filter.Filter()
+		if err != nil {
+			ws.writeError(req.Type, err.Error())
+			return
+		}
+		if req.Type == wsUpdateFilter {
+			if ws.ch == nil {
+				ws.writeError(req.Type, "not subscribed")
+				return
+			}
+			ws.broadcaster.UpdateFilter(ws.ch, filter)
+			break
+		}
+		if ws.ch != nil {
+			ws.writeError(req.Type, "already subscribed, use update_filter")
+			return
+		}
+		ws.ch = ws.broadcaster.Join(filter, req.LastEventID)
+	case wsPause, wsResume:
+		if ws.ch == nil {
+			ws.writeError(req.Type, "not subscribed")
+			return
+		}
+		if req.Type == wsPause {
+			ws.broadcaster.Pause(ws.ch)
+		} else {
+			ws.broadcaster.Resume(ws.ch, ws.lastID)
+		}
+	default:
+		ws.writeError(req.Type, fmt.Sprintf("unknown message type %q", req.Type))
+		return
+	}
+	ws.write(wsMessage{Type: "ack", Of: req.Type})
+}
+
+func (ws *wsSession) deliver(next batch) {
+	if next.snapshot {
+		states := next.states
+		if states == nil {
+			states = []events.TelemetryRawEvent{}
+		}
+		ws.lastID = next.id
+		ws.write(wsMessage{Type: eventSnapshot, ID: next.id, States: states})
+		return
+	}
+	for _, e := range next.entries {
+		ws.lastID = e.id
+		ws.write(wsMessage{Type: eventState, ID: e.id, State: &e.event})
+	}
+	for _, e := range next.geofence {
+		ws.write(wsMessage{Type: eventGeofence, Geofence: &e})
+	}
+}
+
+func (ws *wsSession) writeError(of, msg string) {
+	ws.write(wsMessage{Type: "error", Of: of, Error: msg})
+}
+
+// write sends one message. A failed write closes the connection, which
+// makes the reader return and serve exit.
+func (ws *wsSession) write(msg wsMessage) {
+	msg.Timestamp = time.Now().Unix()
+	ws.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if err := ws.conn.WriteJSON(msg); err != nil {
+		ws.conn.Close()
+	}
+}
+
+func (ws *wsSession) closeWith(code int, reason string) {
+	deadline := time.Now().Add(wsWriteTimeout)
+	ws.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), deadline)
+}
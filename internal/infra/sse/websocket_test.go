@@ -0,0 +1,130 @@
+package sse
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dandyZicky/opensky-collector/pkg/events"
+)
+
+func newWSServer(t *testing.T) (*SSEBroadcaster, string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	b := NewSSEBroadcaster(ctx, Config{
+		AllowedOrigins: []string{"http://localhost:3000"},
+		ReplayBuffer:   10,
+		ClientBuffer:   4,
+		MaxDrops:       2,
+	})
+	b.Live = staticSnapshot{{Icao24: "8a0001", OnGround: true}, {Icao24: "8a0002"}}
+	go b.Run()
+
+	srv := httptest.NewServer(http.HandlerFunc(NewSSEServer(b, "0").handleWS))
+	t.Cleanup(func() {
+		cancel()
+		srv.Close()
+	})
+	return b, "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func dial(t *testing.T, url string) *websocket.Conn {
+	t.Helper()
+	header := http.Header{"Origin": []string{"http://localhost:3000"}}
+	conn, _, err := websocket.DefaultDialer.Dial(url, header)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	assert.Equal(t, "connection_ack", readMessage(t, conn).Type)
+	return conn
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn) wsMessage {
+	t.Helper()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	var msg wsMessage
+	require.NoError(t, conn.ReadJSON(&msg))
+	return msg
+}
+
+func TestWebSocket_SubscribeAndUpdateFilter(t *testing.T) {
+	b, url := newWSServer(t)
+	conn := dial(t, url)
+
+	require.NoError(t, conn.WriteJSON(wsRequest{Type: wsSubscribe}))
+	assert.Equal(t, wsMessage{Type: "ack", Of: wsSubscribe}, withoutTimestamp(readMessage(t, conn)))
+	snapshot := readMessage(t, conn)
+	assert.Equal(t, eventSnapshot, snapshot.Type)
+	assert.Len(t, snapshot.States, 2)
+
+	require.NoError(t, b.Broadcast([]events.TelemetryRawEvent{{Icao24: "8a0003"}}))
+	state := readMessage(t, conn)
+	assert.Equal(t, eventState, state.Type)
+	assert.Equal(t, snapshot.ID+1, state.ID)
+	require.NotNil(t, state.State)
+	assert.Equal(t, "8a0003", state.State.Icao24)
+
+	onGround := true
+	require.NoError(t, conn.WriteJSON(wsRequest{Type: wsUpdateFilter, Filter: FilterSpec{OnGround: &onGround}}))
+	assert.Equal(t, "ack", readMessage(t, conn).Type)
+	snapshot = readMessage(t, conn)
+	require.Len(t, snapshot.States, 1)
+	assert.Equal(t, "8a0001", snapshot.States[0].Icao24)
+}
+
+func TestWebSocket_PauseAndResume(t *testing.T) {
+	b, url := newWSServer(t)
+	conn := dial(t, url)
+
+	require.NoError(t, conn.WriteJSON(wsRequest{Type: wsSubscribe}))
+	readMessage(t, conn)
+	readMessage(t, conn)
+
+	require.NoError(t, conn.WriteJSON(wsRequest{Type: wsPause}))
+	assert.Equal(t, "ack", readMessage(t, conn).Type)
+
+	require.NoError(t, b.Broadcast([]events.TelemetryRawEvent{{Icao24: "8a0003"}}))
+
+	require.NoError(t, conn.WriteJSON(wsRequest{Type: wsResume}))
+	assert.Equal(t, "ack", readMessage(t, conn).Type)
+	missed := readMessage(t, conn)
+	assert.Equal(t, eventState, missed.Type)
+	assert.Equal(t, "8a0003", missed.State.Icao24)
+}
+
+func TestWebSocket_ControlErrors(t *testing.T) {
+	_, url := newWSServer(t)
+	conn := dial(t, url)
+
+	require.NoError(t, conn.WriteJSON(wsRequest{Type: wsPing}))
+	assert.Equal(t, "pong", readMessage(t, conn).Type)
+
+	require.NoError(t, conn.WriteJSON(wsRequest{Type: wsPause}))
+	assert.Equal(t, "error", readMessage(t, conn).Type)
+
+	require.NoError(t, conn.WriteJSON(wsRequest{Type: wsSubscribe, Filter: FilterSpec{BBox: []float64{1, 2}}}))
+	assert.Equal(t, "error", readMessage(t, conn).Type)
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("{not json")))
+	assert.Equal(t, "error", readMessage(t, conn).Type)
+}
+
+func TestWebSocket_RejectsUnknownOrigin(t *testing.T) {
+	_, url := newWSServer(t)
+
+	header := http.Header{"Origin": []string{"http://evil.example"}}
+	_, resp, err := websocket.DefaultDialer.Dial(url, header)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func withoutTimestamp(msg wsMessage) wsMessage {
+	msg.Timestamp = 0
+	return msg
+}
@@ -0,0 +1,12 @@
+package events
+
+// Aircraft is registry metadata for one transponder address. Empty fields
+// are unknown to the registry.
+type Aircraft struct {
+	Icao24       string `json:"icao24"`
+	Registration string `json:"registration,omitempty"`
+	TypeCode     string `json:"typecode,omitempty"`
+	Manufacturer string `json:"manufacturer,omitempty"`
+	Model        string `json:"model,omitempty"`
+	Operator     string `json:"operator,omitempty"`
+}
@@ -0,0 +1,25 @@
+package events
+
+import "encoding/json"
+
+// AlertSchemaVersion is the Alert schema written by this build.
+const AlertSchemaVersion = 1
+
+// Alert is a match of an alerting rule against an aircraft, as published on
+// the alerts topic. Kind is the type of the rule. Time is the last contact
+// that matched, or for lost contact the time the silence was noticed.
+type Alert struct {
+	Rule     string   `json:"rule"`
+	Kind     string   `json:"kind"`
+	Severity string   `json:"severity"`
+	Icao24   string   `json:"icao24"`
+	Callsign *string  `json:"callsign"`
+	Time     int64    `json:"time"`
+	Lat      *float64 `json:"lat"`
+	Lon      *float64 `json:"lon"`
+	Message  string   `json:"message"`
+}
+
+func SerializeAlert(alert Alert) ([]byte, error) {
+	return json.Marshal(alert)
+}
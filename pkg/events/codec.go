@@ -0,0 +1,108 @@
+package events
+
+import (
+	"fmt"
+
+	openskyv1 "github.com/dandyZicky/opensky-collector/pkg/pb/opensky/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Encodings of TelemetryRawEvent on the wire.
+const (
+	FormatJSON     = "json"
+	FormatProtobuf = "protobuf"
+)
+
+// SchemaVersion is the TelemetryRawEvent schema written by this build. Bump
+// it with every change to the event, and keep decoding older versions.
+//
+// Version 2 adds aircraft, the registry metadata attached by the processor.
+const SchemaVersion = 2
+
+// Headers carrying the envelope of an encoded event. Messages without them
+// predate the envelope and are JSON at version 1.
+const (
+	HeaderFormat        = "format"
+	HeaderSchemaVersion = "schema_version"
+)
+
+// Codec encodes events in one format.
+type Codec interface {
+	Format() string
+	Encode(event TelemetryRawEvent) ([]byte, error)
+	// Decode reads data written at the given schema version.
+	Decode(data []byte, version int) (TelemetryRawEvent, error)
+}
+
+var codecs = map[string]Codec{
+	FormatJSON:     JSONCodec{},
+	FormatProtobuf: ProtobufCodec{},
+}
+
+// CodecFor returns the codec of a format; an empty format means JSON.
+func CodecFor(format string) (Codec, error) {
+	if format == "" {
+		format = FormatJSON
+	}
+	codec, ok := codecs[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown event format %q", format)
+	}
+	return codec, nil
+}
+
+// Decode picks the codec from the envelope. Version 0 means the message had
+// no version header and is read as version 1.
+func Decode(data []byte, format string, version int) (TelemetryRawEvent, error) {
+	codec, err := CodecFor(format)
+	if err != nil {
+		return TelemetryRawEvent{}, err
+	}
+	return codec.Decode(data, version)
+}
+
+func checkVersion(version int) error {
+	if version > SchemaVersion {
+		return fmt.Errorf("schema version %d is newer than supported version %d", version, SchemaVersion)
+	}
+	return nil
+}
+
+type JSONCodec struct{}
+
+func (JSONCodec) Format() string {
+	return FormatJSON
+}
+
+func (JSONCodec) Encode(event TelemetryRawEvent) ([]byte, error) {
+	return SerializeTelemetryRawEvent(event)
+}
+
+func (JSONCodec) Decode(data []byte, version int) (TelemetryRawEvent, error) {
+	if err := checkVersion(version); err != nil {
+		return TelemetryRawEvent{}, err
+	}
+	return RawMessageToTelemetryRawEvent(data)
+}
+
+// ProtobufCodec uses opensky.v1.FlightState, the message of the gRPC API.
+type ProtobufCodec struct{}
+
+func (ProtobufCodec) Format() string {
+	return FormatProtobuf
+}
+
+func (ProtobufCodec) Encode(event TelemetryRawEvent) ([]byte, error) {
+	return proto.Marshal(TelemetryRawEventToProto(event))
+}
+
+func (ProtobufCodec) Decode(data []byte, version int) (TelemetryRawEvent, error) {
+	if err := checkVersion(version); err != nil {
+		return TelemetryRawEvent{}, err
+	}
+	var state openskyv1.FlightState
+	if err := proto.Unmarshal(data, &state); err != nil {
+		return TelemetryRawEvent{}, err
+	}
+	return ProtoToTelemetryRawEvent(&state), nil
+}
@@ -0,0 +1,87 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestCodecs_RoundTrip(t *testing.T) {
+	event := TelemetryRawEvent{
+		Icao24:         "8a0001",
+		Callsign:       ptr("GIA402"),
+		OriginCountry:  "Indonesia",
+		Lat:            ptr(-6.12),
+		Lon:            ptr(106.65),
+		TimePosition:   ptr[int64](1700000000),
+		BaroAltitude:   ptr(0.0),
+		LastContact:    1700000001,
+		Sensors:        []int{1, 2},
+		PositionSource: 1,
+		Category:       3,
+		Region:         "java",
+	}
+
+	for _, format := range []string{FormatJSON, FormatProtobuf} {
+		codec, err := CodecFor(format)
+		require.NoError(t, err)
+
+		data, err := codec.Encode(event)
+		require.NoError(t, err)
+		decoded, err := Decode(data, codec.Format(), SchemaVersion)
+		require.NoError(t, err, format)
+		assert.Equal(t, event, decoded, format)
+		assert.Nil(t, decoded.Velocity, "absent values stay absent in %s", format)
+	}
+}
+
+func TestDecode_LegacyAndUnsupported(t *testing.T) {
+	legacy := []byte(`{"icao24":"8a0001","last_contact":1700000000}`)
+	event, err := Decode(legacy, "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "8a0001", event.Icao24)
+
+	_, err = Decode(legacy, FormatJSON, SchemaVersion+1)
+	assert.Error(t, err)
+
+	_, err = Decode(legacy, "avro", SchemaVersion)
+	assert.Error(t, err)
+}
+
+func TestCodecs_RoundTripAircraft(t *testing.T) {
+	event := TelemetryRawEvent{
+		Icao24:      "8a0001",
+		LastContact: 1700000001,
+		Aircraft: &Aircraft{
+			Icao24:       "8a0001",
+			Registration: "PK-GMA",
+			TypeCode:     "B738",
+			Manufacturer: "Boeing",
+			Model:        "737-8U3",
+			Operator:     "Garuda Indonesia",
+		},
+	}
+
+	for _, format := range []string{FormatJSON, FormatProtobuf} {
+		codec, err := CodecFor(format)
+		require.NoError(t, err)
+
+		data, err := codec.Encode(event)
+		require.NoError(t, err)
+		decoded, err := Decode(data, codec.Format(), SchemaVersion)
+		require.NoError(t, err, format)
+		assert.Equal(t, event, decoded, format)
+	}
+
+	// Version 1 events have no aircraft and still decode.
+	v1, err := ProtobufCodec{}.Encode(TelemetryRawEvent{Icao24: "8a0001", LastContact: 1700000001})
+	require.NoError(t, err)
+	decoded, err := Decode(v1, FormatProtobuf, 1)
+	require.NoError(t, err)
+	assert.Nil(t, decoded.Aircraft)
+}
@@ -0,0 +1,33 @@
+package events
+
+import "encoding/json"
+
+// Reasons a message ends up on the dead-letter topic.
+const (
+	DeadLetterDecode  = "decode"
+	DeadLetterPersist = "persist"
+)
+
+// DeadLetter wraps a message the processor could not handle. Payload, Key
+// and Headers hold the original message so it can be replayed unchanged.
+type DeadLetter struct {
+	Reason    string            `json:"reason"`
+	Error     string            `json:"error"`
+	Topic     string            `json:"topic"`
+	Partition int32             `json:"partition"`
+	Offset    int64             `json:"offset"`
+	Key       []byte            `json:"key"`
+	Payload   []byte            `json:"payload"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Timestamp int64             `json:"timestamp"`
+}
+
+func SerializeDeadLetter(dl DeadLetter) ([]byte, error) {
+	return json.Marshal(dl)
+}
+
+func DeserializeDeadLetter(raw []byte) (DeadLetter, error) {
+	var dl DeadLetter
+	err := json.Unmarshal(raw, &dl)
+	return dl, err
+}
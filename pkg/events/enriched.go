@@ -0,0 +1,49 @@
+package events
+
+import "encoding/json"
+
+// EnrichedSchemaVersion is the TelemetryEnrichedEvent schema written by this
+// build. Version 2 adds aircraft along with the raw event.
+const EnrichedSchemaVersion = 2
+
+// Flight phases derived from the ground flag and vertical rate.
+const (
+	PhaseGround  = "ground"
+	PhaseClimb   = "climb"
+	PhaseCruise  = "cruise"
+	PhaseDescent = "descent"
+)
+
+// Altitude bands by flight level: low below FL100, medium up to FL245 and
+// high from FL250.
+const (
+	BandGround = "ground"
+	BandLow    = "low"
+	BandMedium = "medium"
+	BandHigh   = "high"
+)
+
+// TelemetryEnrichedEvent is a raw event with values derived by the processor,
+// as published on the enriched telemetry topic. Derived pointer fields are nil
+// when the raw event lacks the inputs; Phase and AltitudeBand are then empty.
+type TelemetryEnrichedEvent struct {
+	TelemetryRawEvent
+
+	GroundSpeedKt *float64 `json:"ground_speed_kt"`
+	FlightLevel   *int     `json:"flight_level"`
+	AltitudeBand  string   `json:"altitude_band,omitempty"`
+	// DistanceM is the great-circle distance in meters from the previous
+	// position of the same aircraft.
+	DistanceM *float64 `json:"distance_m"`
+	Phase     string   `json:"phase,omitempty"`
+}
+
+func SerializeTelemetryEnrichedEvent(event TelemetryEnrichedEvent) ([]byte, error) {
+	return json.Marshal(event)
+}
+
+func DeserializeTelemetryEnrichedEvent(raw []byte) (TelemetryEnrichedEvent, error) {
+	var event TelemetryEnrichedEvent
+	err := json.Unmarshal(raw, &event)
+	return event, err
+}
@@ -0,0 +1,30 @@
+package events
+
+import "encoding/json"
+
+// FlightEventSchemaVersion is the FlightEvent schema written by this build.
+const FlightEventSchemaVersion = 1
+
+// Types of FlightEvent.
+const (
+	FlightEventTakeoff = "takeoff"
+	FlightEventLanding = "landing"
+)
+
+// FlightEvent is a takeoff or landing at an airport, as published on the
+// flight events topic. Runway is a guess from the aircraft's track and is
+// empty when no runway heading matched.
+type FlightEvent struct {
+	Type     string  `json:"type"`
+	Icao24   string  `json:"icao24"`
+	Callsign *string `json:"callsign"`
+	Airport  string  `json:"airport"`
+	Runway   string  `json:"runway,omitempty"`
+	Time     int64   `json:"time"`
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+}
+
+func SerializeFlightEvent(event FlightEvent) ([]byte, error) {
+	return json.Marshal(event)
+}
@@ -0,0 +1,31 @@
+package events
+
+import "encoding/json"
+
+// GeofenceEventSchemaVersion is the GeofenceEvent schema written by this
+// build.
+const GeofenceEventSchemaVersion = 1
+
+// Types of GeofenceEvent.
+const (
+	GeofenceEnter = "enter"
+	GeofenceExit  = "exit"
+)
+
+// GeofenceEvent is an aircraft entering or leaving a geofence, as published
+// on the geofence events topic. Time and position are those of the first
+// state reported inside, or outside, the fence.
+type GeofenceEvent struct {
+	Type      string  `json:"type"`
+	Icao24    string  `json:"icao24"`
+	Callsign  *string `json:"callsign"`
+	FenceID   int64   `json:"fence_id"`
+	FenceName string  `json:"fence_name"`
+	Time      int64   `json:"time"`
+	Lat       float64 `json:"lat"`
+	Lon       float64 `json:"lon"`
+}
+
+func SerializeGeofenceEvent(event GeofenceEvent) ([]byte, error) {
+	return json.Marshal(event)
+}
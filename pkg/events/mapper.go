@@ -2,31 +2,41 @@ package events
 
 import (
 	"encoding/json"
+	"strings"
 
 	"github.com/dandyZicky/opensky-collector/internal/dto"
 )
 
 func StateVectorToTelemetryRawEvent(state dto.State) TelemetryRawEvent {
 	return TelemetryRawEvent{
-		Icao24:        state.Icao24,
-		OriginCountry: state.OriginCountry,
-		Lat:           nilFloat64(state.Latitude),
-		Lon:           nilFloat64(state.Longitude),
-		Velocity:      nilFloat64(state.Velocity),
-		TimePosition:  nilInt64(state.TimePosition),
-		BaroAltitude:  nilFloat64(state.BaroAltitude),
-		GeoAltitude:   nilFloat64(state.GeoAltitude),
-		LastContact:   state.LastContact,
+		Icao24:         state.Icao24,
+		Callsign:       trimString(state.Callsign),
+		OriginCountry:  state.OriginCountry,
+		Lat:            state.Latitude,
+		Lon:            state.Longitude,
+		Velocity:       state.Velocity,
+		TimePosition:   state.TimePosition,
+		BaroAltitude:   state.BaroAltitude,
+		GeoAltitude:    state.GeoAltitude,
+		LastContact:    state.LastContact,
+		OnGround:       state.OnGround,
+		TrueTrack:      state.TrueTrack,
+		VerticalRate:   state.VerticalRate,
+		Sensors:        state.Sensors,
+		Squawk:         trimString(state.Squawk),
+		Spi:            state.Spi,
+		PositionSource: state.PositionSource,
+		Category:       state.Category,
 	}
 }
 
-func RawMessageToTelemetryRawEvent(rawMessage []byte) TelemetryRawEvent {
+func RawMessageToTelemetryRawEvent(rawMessage []byte) (TelemetryRawEvent, error) {
 	var event TelemetryRawEvent
 	err := json.Unmarshal(rawMessage, &event)
 	if err != nil {
-		return TelemetryRawEvent{}
+		return TelemetryRawEvent{}, err
 	}
-	return event
+	return event, nil
 }
 
 func SerializeTelemetryRawEvent(event TelemetryRawEvent) ([]byte, error) {
@@ -38,16 +48,15 @@ func SerializeTelemetryRawEvent(event TelemetryRawEvent) ([]byte, error) {
 	return b, nil
 }
 
-func nilFloat64(f *float64) float64 {
-	if f == nil {
-		return 0
+// trimString strips the padding OpenSky puts around fixed-width strings such
+// as callsigns and treats a blank result as absent.
+func trimString(s *string) *string {
+	if s == nil {
+		return nil
 	}
-	return *f
-}
-
-func nilInt64(i *int64) int64 {
-	if i == nil {
-		return 0
+	t := strings.TrimSpace(*s)
+	if t == "" {
+		return nil
 	}
-	return *i
+	return &t
 }
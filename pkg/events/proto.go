@@ -0,0 +1,110 @@
+package events
+
+import (
+	openskyv1 "github.com/dandyZicky/opensky-collector/pkg/pb/opensky/v1"
+)
+
+func TelemetryRawEventToProto(event TelemetryRawEvent) *openskyv1.FlightState {
+	var sensors []int32
+	if event.Sensors != nil {
+		sensors = make([]int32, len(event.Sensors))
+		for i, s := range event.Sensors {
+			sensors[i] = int32(s)
+		}
+	}
+	return &openskyv1.FlightState{
+		Icao24:         event.Icao24,
+		Callsign:       event.Callsign,
+		OriginCountry:  event.OriginCountry,
+		Lat:            event.Lat,
+		Lon:            event.Lon,
+		Velocity:       event.Velocity,
+		TimePosition:   event.TimePosition,
+		BaroAltitude:   event.BaroAltitude,
+		GeoAltitude:    event.GeoAltitude,
+		LastContact:    event.LastContact,
+		OnGround:       event.OnGround,
+		TrueTrack:      event.TrueTrack,
+		VerticalRate:   event.VerticalRate,
+		Sensors:        sensors,
+		Squawk:         event.Squawk,
+		Spi:            event.Spi,
+		PositionSource: int32(event.PositionSource),
+		Category:       int32(event.Category),
+		Region:         event.Region,
+		Aircraft:       aircraftToProto(event.Aircraft),
+	}
+}
+
+func ProtoToTelemetryRawEvent(state *openskyv1.FlightState) TelemetryRawEvent {
+	var sensors []int
+	if state.Sensors != nil {
+		sensors = make([]int, len(state.Sensors))
+		for i, s := range state.Sensors {
+			sensors[i] = int(s)
+		}
+	}
+	return TelemetryRawEvent{
+		Icao24:         state.Icao24,
+		Callsign:       state.Callsign,
+		OriginCountry:  state.OriginCountry,
+		Lat:            state.Lat,
+		Lon:            state.Lon,
+		Velocity:       state.Velocity,
+		TimePosition:   state.TimePosition,
+		BaroAltitude:   state.BaroAltitude,
+		GeoAltitude:    state.GeoAltitude,
+		LastContact:    state.LastContact,
+		OnGround:       state.OnGround,
+		TrueTrack:      state.TrueTrack,
+		VerticalRate:   state.VerticalRate,
+		Sensors:        sensors,
+		Squawk:         state.Squawk,
+		Spi:            state.Spi,
+		PositionSource: int(state.PositionSource),
+		Category:       int(state.Category),
+		Region:         state.Region,
+		Aircraft:       protoToAircraft(state.Aircraft),
+	}
+}
+
+func GeofenceEventToProto(event GeofenceEvent) *openskyv1.GeofenceEvent {
+	return &openskyv1.GeofenceEvent{
+		Type:      event.Type,
+		Icao24:    event.Icao24,
+		Callsign:  event.Callsign,
+		FenceId:   event.FenceID,
+		FenceName: event.FenceName,
+		Time:      event.Time,
+		Lat:       event.Lat,
+		Lon:       event.Lon,
+	}
+}
+
+func aircraftToProto(a *Aircraft) *openskyv1.Aircraft {
+	if a == nil {
+		return nil
+	}
+	return &openskyv1.Aircraft{
+		Icao24:       a.Icao24,
+		Registration: a.Registration,
+		Typecode:     a.TypeCode,
+		Manufacturer: a.Manufacturer,
+		Model:        a.Model,
+		Operator:     a.Operator,
+	}
+}
+
+func protoToAircraft(a *openskyv1.Aircraft) *Aircraft {
+	if a == nil {
+		return nil
+	}
+	return &Aircraft{
+		Icao24:       a.Icao24,
+		Registration: a.Registration,
+		TypeCode:     a.Typecode,
+		Manufacturer: a.Manufacturer,
+		Model:        a.Model,
+		Operator:     a.Operator,
+	}
+}
@@ -1,13 +1,29 @@
 package events
 
+// TelemetryRawEvent is a single OpenSky state vector as published on the raw
+// telemetry topic. Pointer fields are nil when OpenSky reported no value, so
+// consumers can tell an absent fix apart from a real zero.
 type TelemetryRawEvent struct {
-	Icao24        string  `json:"icao24"`
-	OriginCountry string  `json:"origin_country"`
-	Lat           float64 `json:"lat"`
-	Lon           float64 `json:"lon"`
-	Velocity      float64 `json:"velocity"`
-	TimePosition  int64   `json:"time_position"`
-	BaroAltitude  float64 `json:"baro_altitude"`
-	GeoAltitude   float64 `json:"geo_altitude"`
-	LastContact   int64   `json:"last_contact"`
+	Icao24         string   `json:"icao24"`
+	Callsign       *string  `json:"callsign"`
+	OriginCountry  string   `json:"origin_country"`
+	Lat            *float64 `json:"lat"`
+	Lon            *float64 `json:"lon"`
+	Velocity       *float64 `json:"velocity"`
+	TimePosition   *int64   `json:"time_position"`
+	BaroAltitude   *float64 `json:"baro_altitude"`
+	GeoAltitude    *float64 `json:"geo_altitude"`
+	LastContact    int64    `json:"last_contact"`
+	OnGround       bool     `json:"on_ground"`
+	TrueTrack      *float64 `json:"true_track"`
+	VerticalRate   *float64 `json:"vertical_rate"`
+	Sensors        []int    `json:"sensors"`
+	Squawk         *string  `json:"squawk"`
+	Spi            bool     `json:"spi"`
+	PositionSource int      `json:"position_source"`
+	Category       int      `json:"category"`
+	Region         string   `json:"region,omitempty"`
+	// Aircraft is attached by the processor from its registry; the
+	// collector never sets it.
+	Aircraft *Aircraft `json:"aircraft,omitempty"`
 }
@@ -5,13 +5,21 @@ type Topic string
 var (
 	TelemetryRaw      Topic
 	TelemetryEnriched Topic
+	TelemetryDLQ      Topic
+	FlightEvents      Topic
+	GeofenceEvents    Topic
+	Alerts            Topic
 )
 
 func (t Topic) String() string {
 	return string(t)
 }
 
-func InitTopics(raw, enriched string) {
+func InitTopics(raw, enriched, dlq, flightEvents, geofenceEvents, alerts string) {
 	TelemetryRaw = Topic(raw)
 	TelemetryEnriched = Topic(enriched)
+	TelemetryDLQ = Topic(dlq)
+	FlightEvents = Topic(flightEvents)
+	GeofenceEvents = Topic(geofenceEvents)
+	Alerts = Topic(alerts)
 }
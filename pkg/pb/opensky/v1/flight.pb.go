@@ -0,0 +1,1080 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: opensky/v1/flight.proto
+
+package openskyv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// FlightState is one OpenSky state vector. Optional fields are unset when
+// OpenSky reported no value. Times are unix seconds.
+type FlightState struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Icao24         string                 `protobuf:"bytes,1,opt,name=icao24,proto3" json:"icao24,omitempty"`
+	Callsign       *string                `protobuf:"bytes,2,opt,name=callsign,proto3,oneof" json:"callsign,omitempty"`
+	OriginCountry  string                 `protobuf:"bytes,3,opt,name=origin_country,json=originCountry,proto3" json:"origin_country,omitempty"`
+	Lat            *float64               `protobuf:"fixed64,4,opt,name=lat,proto3,oneof" json:"lat,omitempty"`
+	Lon            *float64               `protobuf:"fixed64,5,opt,name=lon,proto3,oneof" json:"lon,omitempty"`
+	Velocity       *float64               `protobuf:"fixed64,6,opt,name=velocity,proto3,oneof" json:"velocity,omitempty"`
+	TimePosition   *int64                 `protobuf:"varint,7,opt,name=time_position,json=timePosition,proto3,oneof" json:"time_position,omitempty"`
+	BaroAltitude   *float64               `protobuf:"fixed64,8,opt,name=baro_altitude,json=baroAltitude,proto3,oneof" json:"baro_altitude,omitempty"`
+	GeoAltitude    *float64               `protobuf:"fixed64,9,opt,name=geo_altitude,json=geoAltitude,proto3,oneof" json:"geo_altitude,omitempty"`
+	LastContact    int64                  `protobuf:"varint,10,opt,name=last_contact,json=lastContact,proto3" json:"last_contact,omitempty"`
+	OnGround       bool                   `protobuf:"varint,11,opt,name=on_ground,json=onGround,proto3" json:"on_ground,omitempty"`
+	TrueTrack      *float64               `protobuf:"fixed64,12,opt,name=true_track,json=trueTrack,proto3,oneof" json:"true_track,omitempty"`
+	VerticalRate   *float64               `protobuf:"fixed64,13,opt,name=vertical_rate,json=verticalRate,proto3,oneof" json:"vertical_rate,omitempty"`
+	Sensors        []int32                `protobuf:"varint,14,rep,packed,name=sensors,proto3" json:"sensors,omitempty"`
+	Squawk         *string                `protobuf:"bytes,15,opt,name=squawk,proto3,oneof" json:"squawk,omitempty"`
+	Spi            bool                   `protobuf:"varint,16,opt,name=spi,proto3" json:"spi,omitempty"`
+	PositionSource int32                  `protobuf:"varint,17,opt,name=position_source,json=positionSource,proto3" json:"position_source,omitempty"`
+	Category       int32                  `protobuf:"varint,18,opt,name=category,proto3" json:"category,omitempty"`
+	Region         string                 `protobuf:"bytes,19,opt,name=region,proto3" json:"region,omitempty"`
+	// Registry metadata attached by the processor, unset when the aircraft
+	// is not in the registry.
+	Aircraft      *Aircraft `protobuf:"bytes,20,opt,name=aircraft,proto3" json:"aircraft,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FlightState) Reset() {
+	*x = FlightState{}
+	mi := &file_opensky_v1_flight_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FlightState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FlightState) ProtoMessage() {}
+
+func (x *FlightState) ProtoReflect() protoreflect.Message {
+	mi := &file_opensky_v1_flight_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FlightState.ProtoReflect.Descriptor instead.
+func (*FlightState) Descriptor() ([]byte, []int) {
+	return file_opensky_v1_flight_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *FlightState) GetIcao24() string {
+	if x != nil {
+		return x.Icao24
+	}
+	return ""
+}
+
+func (x *FlightState) GetCallsign() string {
+	if x != nil && x.Callsign != nil {
+		return *x.Callsign
+	}
+	return ""
+}
+
+func (x *FlightState) GetOriginCountry() string {
+	if x != nil {
+		return x.OriginCountry
+	}
+	return ""
+}
+
+func (x *FlightState) GetLat() float64 {
+	if x != nil && x.Lat != nil {
+		return *x.Lat
+	}
+	return 0
+}
+
+func (x *FlightState) GetLon() float64 {
+	if x != nil && x.Lon != nil {
+		return *x.Lon
+	}
+	return 0
+}
+
+func (x *FlightState) GetVelocity() float64 {
+	if x != nil && x.Velocity != nil {
+		return *x.Velocity
+	}
+	return 0
+}
+
+func (x *FlightState) GetTimePosition() int64 {
+	if x != nil && x.TimePosition != nil {
+		return *x.TimePosition
+	}
+	return 0
+}
+
+func (x *FlightState) GetBaroAltitude() float64 {
+	if x != nil && x.BaroAltitude != nil {
+		return *x.BaroAltitude
+	}
+	return 0
+}
+
+func (x *FlightState) GetGeoAltitude() float64 {
+	if x != nil && x.GeoAltitude != nil {
+		return *x.GeoAltitude
+	}
+	return 0
+}
+
+func (x *FlightState) GetLastContact() int64 {
+	if x != nil {
+		return x.LastContact
+	}
+	return 0
+}
+
+func (x *FlightState) GetOnGround() bool {
+	if x != nil {
+		return x.OnGround
+	}
+	return false
+}
+
+func (x *FlightState) GetTrueTrack() float64 {
+	if x != nil && x.TrueTrack != nil {
+		return *x.TrueTrack
+	}
+	return 0
+}
+
+func (x *FlightState) GetVerticalRate() float64 {
+	if x != nil && x.VerticalRate != nil {
+		return *x.VerticalRate
+	}
+	return 0
+}
+
+func (x *FlightState) GetSensors() []int32 {
+	if x != nil {
+		return x.Sensors
+	}
+	return nil
+}
+
+func (x *FlightState) GetSquawk() string {
+	if x != nil && x.Squawk != nil {
+		return *x.Squawk
+	}
+	return ""
+}
+
+func (x *FlightState) GetSpi() bool {
+	if x != nil {
+		return x.Spi
+	}
+	return false
+}
+
+func (x *FlightState) GetPositionSource() int32 {
+	if x != nil {
+		return x.PositionSource
+	}
+	return 0
+}
+
+func (x *FlightState) GetCategory() int32 {
+	if x != nil {
+		return x.Category
+	}
+	return 0
+}
+
+func (x *FlightState) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
+func (x *FlightState) GetAircraft() *Aircraft {
+	if x != nil {
+		return x.Aircraft
+	}
+	return nil
+}
+
+// Aircraft is the registry entry of an airframe. Empty fields are unknown.
+type Aircraft struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Icao24        string                 `protobuf:"bytes,1,opt,name=icao24,proto3" json:"icao24,omitempty"`
+	Registration  string                 `protobuf:"bytes,2,opt,name=registration,proto3" json:"registration,omitempty"`
+	Typecode      string                 `protobuf:"bytes,3,opt,name=typecode,proto3" json:"typecode,omitempty"`
+	Manufacturer  string                 `protobuf:"bytes,4,opt,name=manufacturer,proto3" json:"manufacturer,omitempty"`
+	Model         string                 `protobuf:"bytes,5,opt,name=model,proto3" json:"model,omitempty"`
+	Operator      string                 `protobuf:"bytes,6,opt,name=operator,proto3" json:"operator,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Aircraft) Reset() {
+	*x = Aircraft{}
+	mi := &file_opensky_v1_flight_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Aircraft) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Aircraft) ProtoMessage() {}
+
+func (x *Aircraft) ProtoReflect() protoreflect.Message {
+	mi := &file_opensky_v1_flight_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Aircraft.ProtoReflect.Descriptor instead.
+func (*Aircraft) Descriptor() ([]byte, []int) {
+	return file_opensky_v1_flight_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Aircraft) GetIcao24() string {
+	if x != nil {
+		return x.Icao24
+	}
+	return ""
+}
+
+func (x *Aircraft) GetRegistration() string {
+	if x != nil {
+		return x.Registration
+	}
+	return ""
+}
+
+func (x *Aircraft) GetTypecode() string {
+	if x != nil {
+		return x.Typecode
+	}
+	return ""
+}
+
+func (x *Aircraft) GetManufacturer() string {
+	if x != nil {
+		return x.Manufacturer
+	}
+	return ""
+}
+
+func (x *Aircraft) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *Aircraft) GetOperator() string {
+	if x != nil {
+		return x.Operator
+	}
+	return ""
+}
+
+// BoundingBox is a WGS84 latitude/longitude rectangle.
+type BoundingBox struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Lamin         float64                `protobuf:"fixed64,1,opt,name=lamin,proto3" json:"lamin,omitempty"`
+	Lomin         float64                `protobuf:"fixed64,2,opt,name=lomin,proto3" json:"lomin,omitempty"`
+	Lamax         float64                `protobuf:"fixed64,3,opt,name=lamax,proto3" json:"lamax,omitempty"`
+	Lomax         float64                `protobuf:"fixed64,4,opt,name=lomax,proto3" json:"lomax,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BoundingBox) Reset() {
+	*x = BoundingBox{}
+	mi := &file_opensky_v1_flight_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BoundingBox) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BoundingBox) ProtoMessage() {}
+
+func (x *BoundingBox) ProtoReflect() protoreflect.Message {
+	mi := &file_opensky_v1_flight_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BoundingBox.ProtoReflect.Descriptor instead.
+func (*BoundingBox) Descriptor() ([]byte, []int) {
+	return file_opensky_v1_flight_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *BoundingBox) GetLamin() float64 {
+	if x != nil {
+		return x.Lamin
+	}
+	return 0
+}
+
+func (x *BoundingBox) GetLomin() float64 {
+	if x != nil {
+		return x.Lomin
+	}
+	return 0
+}
+
+func (x *BoundingBox) GetLamax() float64 {
+	if x != nil {
+		return x.Lamax
+	}
+	return 0
+}
+
+func (x *BoundingBox) GetLomax() float64 {
+	if x != nil {
+		return x.Lomax
+	}
+	return 0
+}
+
+// Filter selects the states a stream receives. Unset fields match
+// everything; set fields must all match.
+type Filter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Bbox          *BoundingBox           `protobuf:"bytes,1,opt,name=bbox,proto3" json:"bbox,omitempty"`
+	Icao24        []string               `protobuf:"bytes,2,rep,name=icao24,proto3" json:"icao24,omitempty"`
+	OriginCountry string                 `protobuf:"bytes,3,opt,name=origin_country,json=originCountry,proto3" json:"origin_country,omitempty"`
+	// Barometric altitude in meters, or geometric when barometric is missing.
+	MinAltitude    *float64 `protobuf:"fixed64,4,opt,name=min_altitude,json=minAltitude,proto3,oneof" json:"min_altitude,omitempty"`
+	MaxAltitude    *float64 `protobuf:"fixed64,5,opt,name=max_altitude,json=maxAltitude,proto3,oneof" json:"max_altitude,omitempty"`
+	OnGround       *bool    `protobuf:"varint,6,opt,name=on_ground,json=onGround,proto3,oneof" json:"on_ground,omitempty"`
+	CallsignPrefix string   `protobuf:"bytes,7,opt,name=callsign_prefix,json=callsignPrefix,proto3" json:"callsign_prefix,omitempty"`
+	// ID of the last update seen on a previous stream, to resume from.
+	LastEventId   uint64 `protobuf:"varint,8,opt,name=last_event_id,json=lastEventId,proto3" json:"last_event_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Filter) Reset() {
+	*x = Filter{}
+	mi := &file_opensky_v1_flight_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Filter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Filter) ProtoMessage() {}
+
+func (x *Filter) ProtoReflect() protoreflect.Message {
+	mi := &file_opensky_v1_flight_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Filter.ProtoReflect.Descriptor instead.
+func (*Filter) Descriptor() ([]byte, []int) {
+	return file_opensky_v1_flight_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Filter) GetBbox() *BoundingBox {
+	if x != nil {
+		return x.Bbox
+	}
+	return nil
+}
+
+func (x *Filter) GetIcao24() []string {
+	if x != nil {
+		return x.Icao24
+	}
+	return nil
+}
+
+func (x *Filter) GetOriginCountry() string {
+	if x != nil {
+		return x.OriginCountry
+	}
+	return ""
+}
+
+func (x *Filter) GetMinAltitude() float64 {
+	if x != nil && x.MinAltitude != nil {
+		return *x.MinAltitude
+	}
+	return 0
+}
+
+func (x *Filter) GetMaxAltitude() float64 {
+	if x != nil && x.MaxAltitude != nil {
+		return *x.MaxAltitude
+	}
+	return 0
+}
+
+func (x *Filter) GetOnGround() bool {
+	if x != nil && x.OnGround != nil {
+		return *x.OnGround
+	}
+	return false
+}
+
+func (x *Filter) GetCallsignPrefix() string {
+	if x != nil {
+		return x.CallsignPrefix
+	}
+	return ""
+}
+
+func (x *Filter) GetLastEventId() uint64 {
+	if x != nil {
+		return x.LastEventId
+	}
+	return 0
+}
+
+type Snapshot struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	States        []*FlightState         `protobuf:"bytes,1,rep,name=states,proto3" json:"states,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Snapshot) Reset() {
+	*x = Snapshot{}
+	mi := &file_opensky_v1_flight_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Snapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Snapshot) ProtoMessage() {}
+
+func (x *Snapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_opensky_v1_flight_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Snapshot.ProtoReflect.Descriptor instead.
+func (*Snapshot) Descriptor() ([]byte, []int) {
+	return file_opensky_v1_flight_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Snapshot) GetStates() []*FlightState {
+	if x != nil {
+		return x.States
+	}
+	return nil
+}
+
+// FlightUpdate is one state, a geofence entry or exit, or, first on a new
+// stream, a snapshot of every live aircraft. id increases with every state;
+// a snapshot carries the id of the last state it includes. Geofence events
+// have id 0 and are not replayed to a resumed stream.
+type FlightUpdate struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*FlightUpdate_State
+	//	*FlightUpdate_Snapshot
+	//	*FlightUpdate_Geofence
+	Payload       isFlightUpdate_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FlightUpdate) Reset() {
+	*x = FlightUpdate{}
+	mi := &file_opensky_v1_flight_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FlightUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FlightUpdate) ProtoMessage() {}
+
+func (x *FlightUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_opensky_v1_flight_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FlightUpdate.ProtoReflect.Descriptor instead.
+func (*FlightUpdate) Descriptor() ([]byte, []int) {
+	return file_opensky_v1_flight_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *FlightUpdate) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *FlightUpdate) GetPayload() isFlightUpdate_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *FlightUpdate) GetState() *FlightState {
+	if x != nil {
+		if x, ok := x.Payload.(*FlightUpdate_State); ok {
+			return x.State
+		}
+	}
+	return nil
+}
+
+func (x *FlightUpdate) GetSnapshot() *Snapshot {
+	if x != nil {
+		if x, ok := x.Payload.(*FlightUpdate_Snapshot); ok {
+			return x.Snapshot
+		}
+	}
+	return nil
+}
+
+func (x *FlightUpdate) GetGeofence() *GeofenceEvent {
+	if x != nil {
+		if x, ok := x.Payload.(*FlightUpdate_Geofence); ok {
+			return x.Geofence
+		}
+	}
+	return nil
+}
+
+type isFlightUpdate_Payload interface {
+	isFlightUpdate_Payload()
+}
+
+type FlightUpdate_State struct {
+	State *FlightState `protobuf:"bytes,2,opt,name=state,proto3,oneof"`
+}
+
+type FlightUpdate_Snapshot struct {
+	Snapshot *Snapshot `protobuf:"bytes,3,opt,name=snapshot,proto3,oneof"`
+}
+
+type FlightUpdate_Geofence struct {
+	Geofence *GeofenceEvent `protobuf:"bytes,4,opt,name=geofence,proto3,oneof"`
+}
+
+func (*FlightUpdate_State) isFlightUpdate_Payload() {}
+
+func (*FlightUpdate_Snapshot) isFlightUpdate_Payload() {}
+
+func (*FlightUpdate_Geofence) isFlightUpdate_Payload() {}
+
+// GeofenceEvent is an aircraft entering or leaving a geofence, at the time
+// and position of the first state reported inside, or outside, it.
+type GeofenceEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// "enter" or "exit".
+	Type          string  `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Icao24        string  `protobuf:"bytes,2,opt,name=icao24,proto3" json:"icao24,omitempty"`
+	Callsign      *string `protobuf:"bytes,3,opt,name=callsign,proto3,oneof" json:"callsign,omitempty"`
+	FenceId       int64   `protobuf:"varint,4,opt,name=fence_id,json=fenceId,proto3" json:"fence_id,omitempty"`
+	FenceName     string  `protobuf:"bytes,5,opt,name=fence_name,json=fenceName,proto3" json:"fence_name,omitempty"`
+	Time          int64   `protobuf:"varint,6,opt,name=time,proto3" json:"time,omitempty"`
+	Lat           float64 `protobuf:"fixed64,7,opt,name=lat,proto3" json:"lat,omitempty"`
+	Lon           float64 `protobuf:"fixed64,8,opt,name=lon,proto3" json:"lon,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GeofenceEvent) Reset() {
+	*x = GeofenceEvent{}
+	mi := &file_opensky_v1_flight_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GeofenceEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GeofenceEvent) ProtoMessage() {}
+
+func (x *GeofenceEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_opensky_v1_flight_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GeofenceEvent.ProtoReflect.Descriptor instead.
+func (*GeofenceEvent) Descriptor() ([]byte, []int) {
+	return file_opensky_v1_flight_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GeofenceEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *GeofenceEvent) GetIcao24() string {
+	if x != nil {
+		return x.Icao24
+	}
+	return ""
+}
+
+func (x *GeofenceEvent) GetCallsign() string {
+	if x != nil && x.Callsign != nil {
+		return *x.Callsign
+	}
+	return ""
+}
+
+func (x *GeofenceEvent) GetFenceId() int64 {
+	if x != nil {
+		return x.FenceId
+	}
+	return 0
+}
+
+func (x *GeofenceEvent) GetFenceName() string {
+	if x != nil {
+		return x.FenceName
+	}
+	return ""
+}
+
+func (x *GeofenceEvent) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *GeofenceEvent) GetLat() float64 {
+	if x != nil {
+		return x.Lat
+	}
+	return 0
+}
+
+func (x *GeofenceEvent) GetLon() float64 {
+	if x != nil {
+		return x.Lon
+	}
+	return 0
+}
+
+type GetAircraftRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Icao24        string                 `protobuf:"bytes,1,opt,name=icao24,proto3" json:"icao24,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAircraftRequest) Reset() {
+	*x = GetAircraftRequest{}
+	mi := &file_opensky_v1_flight_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAircraftRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAircraftRequest) ProtoMessage() {}
+
+func (x *GetAircraftRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_opensky_v1_flight_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAircraftRequest.ProtoReflect.Descriptor instead.
+func (*GetAircraftRequest) Descriptor() ([]byte, []int) {
+	return file_opensky_v1_flight_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetAircraftRequest) GetIcao24() string {
+	if x != nil {
+		return x.Icao24
+	}
+	return ""
+}
+
+type GetTrackRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Icao24 string                 `protobuf:"bytes,1,opt,name=icao24,proto3" json:"icao24,omitempty"`
+	// Position time range [from, to) in unix seconds. to defaults to now and
+	// from to 24 hours before to.
+	From int64 `protobuf:"varint,2,opt,name=from,proto3" json:"from,omitempty"`
+	To   int64 `protobuf:"varint,3,opt,name=to,proto3" json:"to,omitempty"`
+	// Page size, default 100 and at most 1000.
+	Limit         int32 `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32 `protobuf:"varint,5,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTrackRequest) Reset() {
+	*x = GetTrackRequest{}
+	mi := &file_opensky_v1_flight_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTrackRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTrackRequest) ProtoMessage() {}
+
+func (x *GetTrackRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_opensky_v1_flight_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTrackRequest.ProtoReflect.Descriptor instead.
+func (*GetTrackRequest) Descriptor() ([]byte, []int) {
+	return file_opensky_v1_flight_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetTrackRequest) GetIcao24() string {
+	if x != nil {
+		return x.Icao24
+	}
+	return ""
+}
+
+func (x *GetTrackRequest) GetFrom() int64 {
+	if x != nil {
+		return x.From
+	}
+	return 0
+}
+
+func (x *GetTrackRequest) GetTo() int64 {
+	if x != nil {
+		return x.To
+	}
+	return 0
+}
+
+func (x *GetTrackRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *GetTrackRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type GetTrackResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	States        []*FlightState         `protobuf:"bytes,1,rep,name=states,proto3" json:"states,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTrackResponse) Reset() {
+	*x = GetTrackResponse{}
+	mi := &file_opensky_v1_flight_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTrackResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTrackResponse) ProtoMessage() {}
+
+func (x *GetTrackResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_opensky_v1_flight_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTrackResponse.ProtoReflect.Descriptor instead.
+func (*GetTrackResponse) Descriptor() ([]byte, []int) {
+	return file_opensky_v1_flight_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetTrackResponse) GetStates() []*FlightState {
+	if x != nil {
+		return x.States
+	}
+	return nil
+}
+
+var File_opensky_v1_flight_proto protoreflect.FileDescriptor
+
+const file_opensky_v1_flight_proto_rawDesc = "" +
+	"\n" +
+	"\x17opensky/v1/flight.proto\x12\n" +
+	"opensky.v1\"\xa9\x06\n" +
+	"\vFlightState\x12\x16\n" +
+	"\x06icao24\x18\x01 \x01(\tR\x06icao24\x12\x1f\n" +
+	"\bcallsign\x18\x02 \x01(\tH\x00R\bcallsign\x88\x01\x01\x12%\n" +
+	"\x0eorigin_country\x18\x03 \x01(\tR\roriginCountry\x12\x15\n" +
+	"\x03lat\x18\x04 \x01(\x01H\x01R\x03lat\x88\x01\x01\x12\x15\n" +
+	"\x03lon\x18\x05 \x01(\x01H\x02R\x03lon\x88\x01\x01\x12\x1f\n" +
+	"\bvelocity\x18\x06 \x01(\x01H\x03R\bvelocity\x88\x01\x01\x12(\n" +
+	"\rtime_position\x18\a \x01(\x03H\x04R\ftimePosition\x88\x01\x01\x12(\n" +
+	"\rbaro_altitude\x18\b \x01(\x01H\x05R\fbaroAltitude\x88\x01\x01\x12&\n" +
+	"\fgeo_altitude\x18\t \x01(\x01H\x06R\vgeoAltitude\x88\x01\x01\x12!\n" +
+	"\flast_contact\x18\n" +
+	" \x01(\x03R\vlastContact\x12\x1b\n" +
+	"\ton_ground\x18\v \x01(\bR\bonGround\x12\"\n" +
+	"\n" +
+	"true_track\x18\f \x01(\x01H\aR\ttrueTrack\x88\x01\x01\x12(\n" +
+	"\rvertical_rate\x18\r \x01(\x01H\bR\fverticalRate\x88\x01\x01\x12\x18\n" +
+	"\asensors\x18\x0e \x03(\x05R\asensors\x12\x1b\n" +
+	"\x06squawk\x18\x0f \x01(\tH\tR\x06squawk\x88\x01\x01\x12\x10\n" +
+	"\x03spi\x18\x10 \x01(\bR\x03spi\x12'\n" +
+	"\x0fposition_source\x18\x11 \x01(\x05R\x0epositionSource\x12\x1a\n" +
+	"\bcategory\x18\x12 \x01(\x05R\bcategory\x12\x16\n" +
+	"\x06region\x18\x13 \x01(\tR\x06region\x120\n" +
+	"\baircraft\x18\x14 \x01(\v2\x14.opensky.v1.AircraftR\baircraftB\v\n" +
+	"\t_callsignB\x06\n" +
+	"\x04_latB\x06\n" +
+	"\x04_lonB\v\n" +
+	"\t_velocityB\x10\n" +
+	"\x0e_time_positionB\x10\n" +
+	"\x0e_baro_altitudeB\x0f\n" +
+	"\r_geo_altitudeB\r\n" +
+	"\v_true_trackB\x10\n" +
+	"\x0e_vertical_rateB\t\n" +
+	"\a_squawk\"\xb8\x01\n" +
+	"\bAircraft\x12\x16\n" +
+	"\x06icao24\x18\x01 \x01(\tR\x06icao24\x12\"\n" +
+	"\fregistration\x18\x02 \x01(\tR\fregistration\x12\x1a\n" +
+	"\btypecode\x18\x03 \x01(\tR\btypecode\x12\"\n" +
+	"\fmanufacturer\x18\x04 \x01(\tR\fmanufacturer\x12\x14\n" +
+	"\x05model\x18\x05 \x01(\tR\x05model\x12\x1a\n" +
+	"\boperator\x18\x06 \x01(\tR\boperator\"e\n" +
+	"\vBoundingBox\x12\x14\n" +
+	"\x05lamin\x18\x01 \x01(\x01R\x05lamin\x12\x14\n" +
+	"\x05lomin\x18\x02 \x01(\x01R\x05lomin\x12\x14\n" +
+	"\x05lamax\x18\x03 \x01(\x01R\x05lamax\x12\x14\n" +
+	"\x05lomax\x18\x04 \x01(\x01R\x05lomax\"\xe3\x02\n" +
+	"\x06Filter\x12+\n" +
+	"\x04bbox\x18\x01 \x01(\v2\x17.opensky.v1.BoundingBoxR\x04bbox\x12\x16\n" +
+	"\x06icao24\x18\x02 \x03(\tR\x06icao24\x12%\n" +
+	"\x0eorigin_country\x18\x03 \x01(\tR\roriginCountry\x12&\n" +
+	"\fmin_altitude\x18\x04 \x01(\x01H\x00R\vminAltitude\x88\x01\x01\x12&\n" +
+	"\fmax_altitude\x18\x05 \x01(\x01H\x01R\vmaxAltitude\x88\x01\x01\x12 \n" +
+	"\ton_ground\x18\x06 \x01(\bH\x02R\bonGround\x88\x01\x01\x12'\n" +
+	"\x0fcallsign_prefix\x18\a \x01(\tR\x0ecallsignPrefix\x12\"\n" +
+	"\rlast_event_id\x18\b \x01(\x04R\vlastEventIdB\x0f\n" +
+	"\r_min_altitudeB\x0f\n" +
+	"\r_max_altitudeB\f\n" +
+	"\n" +
+	"_on_ground\";\n" +
+	"\bSnapshot\x12/\n" +
+	"\x06states\x18\x01 \x03(\v2\x17.opensky.v1.FlightStateR\x06states\"\xc7\x01\n" +
+	"\fFlightUpdate\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12/\n" +
+	"\x05state\x18\x02 \x01(\v2\x17.opensky.v1.FlightStateH\x00R\x05state\x122\n" +
+	"\bsnapshot\x18\x03 \x01(\v2\x14.opensky.v1.SnapshotH\x00R\bsnapshot\x127\n" +
+	"\bgeofence\x18\x04 \x01(\v2\x19.opensky.v1.GeofenceEventH\x00R\bgeofenceB\t\n" +
+	"\apayload\"\xdb\x01\n" +
+	"\rGeofenceEvent\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x16\n" +
+	"\x06icao24\x18\x02 \x01(\tR\x06icao24\x12\x1f\n" +
+	"\bcallsign\x18\x03 \x01(\tH\x00R\bcallsign\x88\x01\x01\x12\x19\n" +
+	"\bfence_id\x18\x04 \x01(\x03R\afenceId\x12\x1d\n" +
+	"\n" +
+	"fence_name\x18\x05 \x01(\tR\tfenceName\x12\x12\n" +
+	"\x04time\x18\x06 \x01(\x03R\x04time\x12\x10\n" +
+	"\x03lat\x18\a \x01(\x01R\x03lat\x12\x10\n" +
+	"\x03lon\x18\b \x01(\x01R\x03lonB\v\n" +
+	"\t_callsign\",\n" +
+	"\x12GetAircraftRequest\x12\x16\n" +
+	"\x06icao24\x18\x01 \x01(\tR\x06icao24\"{\n" +
+	"\x0fGetTrackRequest\x12\x16\n" +
+	"\x06icao24\x18\x01 \x01(\tR\x06icao24\x12\x12\n" +
+	"\x04from\x18\x02 \x01(\x03R\x04from\x12\x0e\n" +
+	"\x02to\x18\x03 \x01(\x03R\x02to\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x05 \x01(\x05R\x06offset\"C\n" +
+	"\x10GetTrackResponse\x12/\n" +
+	"\x06states\x18\x01 \x03(\v2\x17.opensky.v1.FlightStateR\x06states2\xdf\x01\n" +
+	"\rFlightService\x12?\n" +
+	"\rStreamFlights\x12\x12.opensky.v1.Filter\x1a\x18.opensky.v1.FlightUpdate0\x01\x12F\n" +
+	"\vGetAircraft\x12\x1e.opensky.v1.GetAircraftRequest\x1a\x17.opensky.v1.FlightState\x12E\n" +
+	"\bGetTrack\x12\x1b.opensky.v1.GetTrackRequest\x1a\x1c.opensky.v1.GetTrackResponseBEZCgithub.com/dandyZicky/opensky-collector/pkg/pb/opensky/v1;openskyv1b\x06proto3"
+
+var (
+	file_opensky_v1_flight_proto_rawDescOnce sync.Once
+	file_opensky_v1_flight_proto_rawDescData []byte
+)
+
+func file_opensky_v1_flight_proto_rawDescGZIP() []byte {
+	file_opensky_v1_flight_proto_rawDescOnce.Do(func() {
+		file_opensky_v1_flight_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_opensky_v1_flight_proto_rawDesc), len(file_opensky_v1_flight_proto_rawDesc)))
+	})
+	return file_opensky_v1_flight_proto_rawDescData
+}
+
+var file_opensky_v1_flight_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_opensky_v1_flight_proto_goTypes = []any{
+	(*FlightState)(nil),        // 0: opensky.v1.FlightState
+	(*Aircraft)(nil),           // 1: opensky.v1.Aircraft
+	(*BoundingBox)(nil),        // 2: opensky.v1.BoundingBox
+	(*Filter)(nil),             // 3: opensky.v1.Filter
+	(*Snapshot)(nil),           // 4: opensky.v1.Snapshot
+	(*FlightUpdate)(nil),       // 5: opensky.v1.FlightUpdate
+	(*GeofenceEvent)(nil),      // 6: opensky.v1.GeofenceEvent
+	(*GetAircraftRequest)(nil), // 7: opensky.v1.GetAircraftRequest
+	(*GetTrackRequest)(nil),    // 8: opensky.v1.GetTrackRequest
+	(*GetTrackResponse)(nil),   // 9: opensky.v1.GetTrackResponse
+}
+var file_opensky_v1_flight_proto_depIdxs = []int32{
+	1,  // 0: opensky.v1.FlightState.aircraft:type_name -> opensky.v1.Aircraft
+	2,  // 1: opensky.v1.Filter.bbox:type_name -> opensky.v1.BoundingBox
+	0,  // 2: opensky.v1.Snapshot.states:type_name -> opensky.v1.FlightState
+	0,  // 3: opensky.v1.FlightUpdate.state:type_name -> opensky.v1.FlightState
+	4,  // 4: opensky.v1.FlightUpdate.snapshot:type_name -> opensky.v1.Snapshot
+	6,  // 5: opensky.v1.FlightUpdate.geofence:type_name -> opensky.v1.GeofenceEvent
+	0,  // 6: opensky.v1.GetTrackResponse.states:type_name -> opensky.v1.FlightState
+	3,  // 7: opensky.v1.FlightService.StreamFlights:input_type -> opensky.v1.Filter
+	7,  // 8: opensky.v1.FlightService.GetAircraft:input_type -> opensky.v1.GetAircraftRequest
+	8,  // 9: opensky.v1.FlightService.GetTrack:input_type -> opensky.v1.GetTrackRequest
+	5,  // 10: opensky.v1.FlightService.StreamFlights:output_type -> opensky.v1.FlightUpdate
+	0,  // 11: opensky.v1.FlightService.GetAircraft:output_type -> opensky.v1.FlightState
+	9,  // 12: opensky.v1.FlightService.GetTrack:output_type -> opensky.v1.GetTrackResponse
+	10, // [10:13] is the sub-list for method output_type
+	7,  // [7:10] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_opensky_v1_flight_proto_init() }
+func file_opensky_v1_flight_proto_init() {
+	if File_opensky_v1_flight_proto != nil {
+		return
+	}
+	file_opensky_v1_flight_proto_msgTypes[0].OneofWrappers = []any{}
+	file_opensky_v1_flight_proto_msgTypes[3].OneofWrappers = []any{}
+	file_opensky_v1_flight_proto_msgTypes[5].OneofWrappers = []any{
+		(*FlightUpdate_State)(nil),
+		(*FlightUpdate_Snapshot)(nil),
+		(*FlightUpdate_Geofence)(nil),
+	}
+	file_opensky_v1_flight_proto_msgTypes[6].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_opensky_v1_flight_proto_rawDesc), len(file_opensky_v1_flight_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_opensky_v1_flight_proto_goTypes,
+		DependencyIndexes: file_opensky_v1_flight_proto_depIdxs,
+		MessageInfos:      file_opensky_v1_flight_proto_msgTypes,
+	}.Build()
+	File_opensky_v1_flight_proto = out.File
+	file_opensky_v1_flight_proto_goTypes = nil
+	file_opensky_v1_flight_proto_depIdxs = nil
+}
@@ -0,0 +1,207 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: opensky/v1/flight.proto
+
+package openskyv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	FlightService_StreamFlights_FullMethodName = "/opensky.v1.FlightService/StreamFlights"
+	FlightService_GetAircraft_FullMethodName   = "/opensky.v1.FlightService/GetAircraft"
+	FlightService_GetTrack_FullMethodName      = "/opensky.v1.FlightService/GetTrack"
+)
+
+// FlightServiceClient is the client API for FlightService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type FlightServiceClient interface {
+	// StreamFlights streams live states matching the filter.
+	StreamFlights(ctx context.Context, in *Filter, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FlightUpdate], error)
+	// GetAircraft returns the last stored state of one aircraft.
+	GetAircraft(ctx context.Context, in *GetAircraftRequest, opts ...grpc.CallOption) (*FlightState, error)
+	// GetTrack returns stored states of one aircraft, oldest first.
+	GetTrack(ctx context.Context, in *GetTrackRequest, opts ...grpc.CallOption) (*GetTrackResponse, error)
+}
+
+type flightServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFlightServiceClient(cc grpc.ClientConnInterface) FlightServiceClient {
+	return &flightServiceClient{cc}
+}
+
+func (c *flightServiceClient) StreamFlights(ctx context.Context, in *Filter, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FlightUpdate], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &FlightService_ServiceDesc.Streams[0], FlightService_StreamFlights_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Filter, FlightUpdate]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FlightService_StreamFlightsClient = grpc.ServerStreamingClient[FlightUpdate]
+
+func (c *flightServiceClient) GetAircraft(ctx context.Context, in *GetAircraftRequest, opts ...grpc.CallOption) (*FlightState, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FlightState)
+	err := c.cc.Invoke(ctx, FlightService_GetAircraft_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *flightServiceClient) GetTrack(ctx context.Context, in *GetTrackRequest, opts ...grpc.CallOption) (*GetTrackResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetTrackResponse)
+	err := c.cc.Invoke(ctx, FlightService_GetTrack_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FlightServiceServer is the server API for FlightService service.
+// All implementations must embed UnimplementedFlightServiceServer
+// for forward compatibility.
+type FlightServiceServer interface {
+	// StreamFlights streams live states matching the filter.
+	StreamFlights(*Filter, grpc.ServerStreamingServer[FlightUpdate]) error
+	// GetAircraft returns the last stored state of one aircraft.
+	GetAircraft(context.Context, *GetAircraftRequest) (*FlightState, error)
+	// GetTrack returns stored states of one aircraft, oldest first.
+	GetTrack(context.Context, *GetTrackRequest) (*GetTrackResponse, error)
+	mustEmbedUnimplementedFlightServiceServer()
+}
+
+// UnimplementedFlightServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedFlightServiceServer struct{}
+
+func (UnimplementedFlightServiceServer) StreamFlights(*Filter, grpc.ServerStreamingServer[FlightUpdate]) error {
+	return status.Error(codes.Unimplemented, "method StreamFlights not implemented")
+}
+func (UnimplementedFlightServiceServer) GetAircraft(context.Context, *GetAircraftRequest) (*FlightState, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetAircraft not implemented")
+}
+func (UnimplementedFlightServiceServer) GetTrack(context.Context, *GetTrackRequest) (*GetTrackResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetTrack not implemented")
+}
+func (UnimplementedFlightServiceServer) mustEmbedUnimplementedFlightServiceServer() {}
+func (UnimplementedFlightServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafeFlightServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FlightServiceServer will
+// result in compilation errors.
+type UnsafeFlightServiceServer interface {
+	mustEmbedUnimplementedFlightServiceServer()
+}
+
+func RegisterFlightServiceServer(s grpc.ServiceRegistrar, srv FlightServiceServer) {
+	// If the following call panics, it indicates UnimplementedFlightServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&FlightService_ServiceDesc, srv)
+}
+
+func _FlightService_StreamFlights_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Filter)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FlightServiceServer).StreamFlights(m, &grpc.GenericServerStream[Filter, FlightUpdate]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type FlightService_StreamFlightsServer = grpc.ServerStreamingServer[FlightUpdate]
+
+func _FlightService_GetAircraft_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAircraftRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FlightServiceServer).GetAircraft(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FlightService_GetAircraft_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FlightServiceServer).GetAircraft(ctx, req.(*GetAircraftRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FlightService_GetTrack_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTrackRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FlightServiceServer).GetTrack(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FlightService_GetTrack_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FlightServiceServer).GetTrack(ctx, req.(*GetTrackRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// FlightService_ServiceDesc is the grpc.ServiceDesc for FlightService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var FlightService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "opensky.v1.FlightService",
+	HandlerType: (*FlightServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetAircraft",
+			Handler:    _FlightService_GetAircraft_Handler,
+		},
+		{
+			MethodName: "GetTrack",
+			Handler:    _FlightService_GetTrack_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamFlights",
+			Handler:       _FlightService_StreamFlights_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "opensky/v1/flight.proto",
+}